@@ -0,0 +1,113 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// UnknownAge is the Age of an item whose trashinfo records no valid
+// deletion date.
+const UnknownAge time.Duration = -1
+
+// Age returns how long ago the item was deleted, or UnknownAge if its
+// deletion date is unknown. An item deleted in the future, by a clock ahead
+// of this one, has age 0.
+func (item TrashItem) Age() time.Duration {
+	if item.DeletionDate.IsZero() {
+		return UnknownAge
+	}
+	if age := time.Since(item.DeletionDate); age > 0 {
+		return age
+	}
+	return 0
+}
+
+// OlderThan returns a predicate matching items deleted more than d ago.
+// Items of unknown age never match.
+func OlderThan(d time.Duration) func(TrashItem) bool {
+	return func(item TrashItem) bool {
+		age := item.Age()
+		return age != UnknownAge && age > d
+	}
+}
+
+// NewerThan returns a predicate matching items deleted less than d ago.
+// Items of unknown age never match.
+func NewerThan(d time.Duration) func(TrashItem) bool {
+	return func(item TrashItem) bool {
+		age := item.Age()
+		return age != UnknownAge && age < d
+	}
+}
+
+// OldestItem returns the item deleted longest ago, across the home trash
+// and every mounted filesystem. ok is false if no item has a deletion
+// date; undated items are ignored. Unlike searching List, it keeps only
+// the current extreme, not every item.
+func (tr *Trasher) OldestItem() (item TrashItem, ok bool, err error) {
+	return tr.extremeItem(func(a, b time.Time) bool { return a.Before(b) })
+}
+
+// NewestItem is like OldestItem but returns the most recently deleted item.
+func (tr *Trasher) NewestItem() (item TrashItem, ok bool, err error) {
+	return tr.extremeItem(func(a, b time.Time) bool { return a.After(b) })
+}
+
+// extremeItem returns the dated item whose deletion date beats every
+// other's according to better.
+func (tr *Trasher) extremeItem(better func(a, b time.Time) bool) (TrashItem, bool, error) {
+	var best TrashItem
+	found := false
+
+	dirs := append([]string{tr.homeTrash}, tr.mountTrashDirs()...)
+	for _, trashDir := range dirs {
+		names, err := tr.layout.Entries(trashDir, tr.fs.ReadDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return TrashItem{}, false, err
+		}
+
+		for _, name := range names {
+			item, err := tr.parseTrashInfo(trashDir, name)
+			if err != nil || item.DeletionDate.IsZero() {
+				continue
+			}
+			if !found || better(item.DeletionDate, best.DeletionDate) {
+				best, found = item, true
+			}
+		}
+	}
+
+	return best, found, nil
+}
+
+// PurgeOlderThan permanently removes every item deleted more than d ago,
+// across the home trash and every mounted filesystem, like trash-cli's
+// trash-empty with a number of days, and returns how many it removed.
+// Items without a valid deletion date are kept. It carries on past items
+// that fail to be removed and returns their errors joined.
+func (tr *Trasher) PurgeOlderThan(d time.Duration) (int, error) {
+	items, err := tr.List()
+	if err != nil {
+		return 0, err
+	}
+
+	older := OlderThan(d)
+	n := 0
+	var errs []error
+	for _, item := range items {
+		if !older(item) {
+			continue
+		}
+		if err := tr.deleteItem(item); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.Name, err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
@@ -0,0 +1,141 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAge(t *testing.T) {
+	recent := TrashItem{DeletionDate: time.Now().Add(-time.Minute)}
+	old := TrashItem{DeletionDate: time.Now().Add(-90 * 24 * time.Hour)}
+	undated := TrashItem{}
+
+	if age := recent.Age(); age < time.Minute || age > time.Hour {
+		t.Errorf("Recent item age = %v, want about a minute", age)
+	}
+	if age := old.Age(); age < 89*24*time.Hour {
+		t.Errorf("Old item age = %v, want about 90 days", age)
+	}
+	if age := undated.Age(); age != UnknownAge {
+		t.Errorf("Undated item age = %v, want UnknownAge", age)
+	}
+
+	olderThanMonth := OlderThan(30 * 24 * time.Hour)
+	newerThanDay := NewerThan(24 * time.Hour)
+	tests := []struct {
+		name         string
+		item         TrashItem
+		older, newer bool
+	}{
+		{"recent", recent, false, true},
+		{"old", old, true, false},
+		{"undated", undated, false, false},
+	}
+	for _, tt := range tests {
+		if got := olderThanMonth(tt.item); got != tt.older {
+			t.Errorf("OlderThan(30 days) for %s item = %v, want %v", tt.name, got, tt.older)
+		}
+		if got := newerThanDay(tt.item); got != tt.newer {
+			t.Errorf("NewerThan(1 day) for %s item = %v, want %v", tt.name, got, tt.newer)
+		}
+	}
+}
+
+func TestOldestNewestItem(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	if _, ok, err := tr.OldestItem(); ok || err != nil {
+		t.Fatalf("OldestItem on an empty trash = %v, %v; want false, nil", ok, err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	dates := map[string]time.Time{
+		"middle.txt": now.Add(-10 * 24 * time.Hour),
+		"oldest.txt": now.Add(-42 * 24 * time.Hour),
+		"newest.txt": now.Add(-time.Hour),
+	}
+	dir := t.TempDir()
+	for name, date := range dates {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.TrashAt(path, date); err != nil {
+			t.Fatalf("Failed to trash %s: %v", name, err)
+		}
+	}
+
+	// An item with an unreadable date is ignored rather than taken as oldest
+	undated := "[Trash Info]\nPath=/tmp/undated.txt\nDeletionDate=unknown\n"
+	if err := os.WriteFile(filepath.Join(tr.homeTrash, "info", "undated.txt.trashinfo"), []byte(undated), 0600); err != nil {
+		t.Fatalf("Failed to write trash info: %v", err)
+	}
+
+	oldest, ok, err := tr.OldestItem()
+	if err != nil || !ok {
+		t.Fatalf("OldestItem = %v, %v", ok, err)
+	}
+	if oldest.Name != "oldest.txt" || !oldest.DeletionDate.Equal(dates["oldest.txt"]) {
+		t.Errorf("OldestItem = %s at %v, want oldest.txt at %v", oldest.Name, oldest.DeletionDate, dates["oldest.txt"])
+	}
+
+	newest, ok, err := tr.NewestItem()
+	if err != nil || !ok {
+		t.Fatalf("NewestItem = %v, %v", ok, err)
+	}
+	if newest.Name != "newest.txt" || !newest.DeletionDate.Equal(dates["newest.txt"]) {
+		t.Errorf("NewestItem = %s at %v, want newest.txt at %v", newest.Name, newest.DeletionDate, dates["newest.txt"])
+	}
+}
+
+func TestPurgeOlderThan(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	const day = 24 * time.Hour
+	for name, age := range map[string]time.Duration{"old.txt": 40 * day, "older.txt": 400 * day, "new.txt": day} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.TrashWithOptions(path, TrashOptions{DeletionDate: time.Now().Add(-age)}); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	// An item whose date can't be parsed is never expired
+	undated := filepath.Join(tr.homeTrash, "info", "undated.txt.trashinfo")
+	if err := os.WriteFile(undated, []byte("[Trash Info]\nPath=/tmp/undated.txt\nDeletionDate=yesterday\n"), 0600); err != nil {
+		t.Fatalf("Failed to write trashinfo: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tr.homeTrash, "files", "undated.txt"), nil, 0600); err != nil {
+		t.Fatalf("Failed to write trashed file: %v", err)
+	}
+
+	n, err := tr.PurgeOlderThan(30 * day)
+	if err != nil {
+		t.Fatalf("PurgeOlderThan failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Purged %d items, want 2", n)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	left := map[string]bool{}
+	for _, item := range items {
+		left[item.Name] = true
+	}
+	if len(left) != 2 || !left["new.txt"] || !left["undated.txt"] {
+		t.Errorf("Items left = %v, want new.txt and undated.txt", left)
+	}
+	for _, name := range []string{"old.txt", "older.txt"} {
+		if _, err := os.Lstat(filepath.Join(tr.homeTrash, "files", name)); !os.IsNotExist(err) {
+			t.Errorf("Data of %s left behind: %v", name, err)
+		}
+	}
+}
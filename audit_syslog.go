@@ -0,0 +1,38 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package trash
+
+import (
+	"log/syslog"
+	"sync"
+)
+
+// WithSyslog makes the Trasher record every event to the system log under
+// tag, with the LOG_AUTHPRIV facility. The log is connected on the first
+// event, and retried on later ones if that fails; until then events are
+// dropped rather than failing the operation.
+func WithSyslog(tag string) Option {
+	return WithAuditSink(&syslogSink{tag: tag})
+}
+
+type syslogSink struct {
+	tag string
+
+	mu sync.Mutex
+	w  *syslog.Writer
+}
+
+func (s *syslogSink) Info(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_AUTHPRIV, s.tag)
+		if err != nil {
+			return err
+		}
+		s.w = w
+	}
+	return s.w.Info(msg)
+}
@@ -0,0 +1,115 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// A Batch groups files that are trashed as one logical deletion, so they
+// can later be restored or deleted together with RestoreBatch and
+// DeleteBatch. Paths are only queued by Trash and are trashed by Commit.
+type Batch struct {
+	tr    *Trasher
+	id    string
+	paths []string
+}
+
+// BeginBatch starts a new, empty Batch with a fresh ID.
+func (tr *Trasher) BeginBatch() *Batch {
+	return &Batch{tr: tr, id: randomSuffix()}
+}
+
+// ID returns the batch ID recorded on every item of the batch.
+func (b *Batch) ID() string {
+	return b.id
+}
+
+// Trash queues path to be trashed by Commit.
+func (b *Batch) Trash(path string) {
+	b.paths = append(b.paths, path)
+}
+
+// Commit trashes every queued path, tagging each with the batch ID. If any
+// path fails, the ones already trashed are restored, so the batch is
+// trashed either completely or not at all, and the error is returned.
+func (b *Batch) Commit() error {
+	var done []TrashItem
+	for _, path := range b.paths {
+		result, err := b.tr.trashPath(context.Background(), path, TrashOptions{batchID: b.id})
+		if err == nil {
+			done = append(done, result.TrashItem)
+			continue
+		}
+
+		err = fmt.Errorf("failed to trash %s: %w", path, err)
+		for i := len(done) - 1; i >= 0; i-- {
+			if rerr := b.tr.restoreItem(done[i], RestoreOptions{}); rerr != nil {
+				err = fmt.Errorf("%w (rolling back %s also failed: %v)", err, done[i].Name, rerr)
+			}
+		}
+		return err
+	}
+
+	b.paths = nil
+	return nil
+}
+
+// TrashAll trashes every path in paths, carrying on past the ones that
+// fail. Unlike a Batch, it never rolls back: the items returned, one per
+// path trashed successfully, stay in the trash whatever else fails. The
+// errors of the others are joined, each naming its path.
+func (tr *Trasher) TrashAll(paths []string) ([]TrashItem, error) {
+	var items []TrashItem
+	var errs []error
+	for _, path := range paths {
+		result, err := tr.trashPath(context.Background(), path, TrashOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to trash %s: %w", path, err))
+			continue
+		}
+		items = append(items, result.TrashItem)
+	}
+	return items, errors.Join(errs...)
+}
+
+// RestoreBatch restores every item trashed in the batch id. It carries on
+// past items that fail to restore and returns their errors joined.
+func (tr *Trasher) RestoreBatch(id string) error {
+	return tr.eachInBatch(id, func(item TrashItem) error {
+		return tr.restoreItem(item, RestoreOptions{})
+	})
+}
+
+// DeleteBatch permanently removes every item trashed in the batch id. It
+// carries on past items that fail to be removed and returns their errors
+// joined.
+func (tr *Trasher) DeleteBatch(id string) error {
+	return tr.eachInBatch(id, tr.deleteItem)
+}
+
+// eachInBatch calls fn for each item of batch id, returning
+// ErrFileNotInTrash if there are none.
+func (tr *Trasher) eachInBatch(id string, fn func(TrashItem) error) error {
+	items, err := tr.List()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	var errs []error
+	for _, item := range items {
+		if id == "" || item.BatchID != id {
+			continue
+		}
+		found = true
+		if err := fn(item); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.Name, err))
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w: batch %s", ErrFileNotInTrash, id)
+	}
+	return errors.Join(errs...)
+}
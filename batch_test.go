@@ -0,0 +1,141 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.o", "b.o", "keep.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := tr.Trash(paths[2]); err != nil {
+		t.Fatalf("Failed to trash file outside the batch: %v", err)
+	}
+
+	batch := tr.BeginBatch()
+	batch.Trash(paths[0])
+	batch.Trash(paths[1])
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Failed to commit batch: %v", err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	inBatch := 0
+	for _, item := range items {
+		if item.BatchID == batch.ID() {
+			inBatch++
+		}
+	}
+	if inBatch != 2 {
+		t.Fatalf("Expected 2 items in batch %s, got %d", batch.ID(), inBatch)
+	}
+
+	if err := tr.RestoreBatch(batch.ID()); err != nil {
+		t.Fatalf("Failed to restore batch: %v", err)
+	}
+	for _, path := range paths[:2] {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Batch member %s not restored: %v", path, err)
+		}
+	}
+	if items, err := tr.List(); err != nil || len(items) != 1 || items[0].Name != "keep.txt" {
+		t.Errorf("Expected only keep.txt left in the trash, got %v, %v", items, err)
+	}
+
+	batch = tr.BeginBatch()
+	batch.Trash(paths[0])
+	batch.Trash(paths[1])
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Failed to commit batch: %v", err)
+	}
+	if err := tr.DeleteBatch(batch.ID()); err != nil {
+		t.Fatalf("Failed to delete batch: %v", err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != 1 {
+		t.Errorf("Expected only keep.txt left in the trash, got %v, %v", items, err)
+	}
+	if err := tr.DeleteBatch(batch.ID()); !errors.Is(err, ErrFileNotInTrash) {
+		t.Errorf("Expected ErrFileNotInTrash for a deleted batch, got: %v", err)
+	}
+}
+
+func TestBatchCommitRollback(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.txt")
+	if err := os.WriteFile(present, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	batch := tr.BeginBatch()
+	batch.Trash(present)
+	batch.Trash(filepath.Join(dir, "missing.txt"))
+	if err := batch.Commit(); err == nil {
+		t.Fatal("Expected an error committing a batch with a missing file")
+	}
+
+	if _, err := os.Stat(present); err != nil {
+		t.Errorf("Trashed batch member was not rolled back: %v", err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != 0 {
+		t.Errorf("Expected an empty trash after rollback, got %v, %v", items, err)
+	}
+}
+
+func TestTrashAll(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	var paths []string
+	for _, name := range []string{"a.txt", "missing.txt", "b.txt", "gone.txt"} {
+		path := filepath.Join(dir, name)
+		paths = append(paths, path)
+		if !strings.HasPrefix(name, "missing") && !strings.HasPrefix(name, "gone") {
+			if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+		}
+	}
+
+	items, err := tr.TrashAll(paths)
+	if err == nil {
+		t.Fatal("Expected an error for the missing paths")
+	}
+	for _, failed := range []string{paths[1], paths[3]} {
+		if !strings.Contains(err.Error(), failed) {
+			t.Errorf("Error %q doesn't name %s", err, failed)
+		}
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the cause to be kept, got: %v", err)
+	}
+
+	if len(items) != 2 || items[0].OriginalPath != paths[0] || items[1].OriginalPath != paths[2] {
+		t.Fatalf("Trashed items = %+v, want a.txt and b.txt", items)
+	}
+	for _, item := range items {
+		if _, err := os.Stat(item.FilePath); err != nil {
+			t.Errorf("Trashed %s is not in the trash: %v", item.Name, err)
+		}
+		if _, err := os.Stat(item.OriginalPath); !os.IsNotExist(err) {
+			t.Errorf("%s still exists at its original path", item.Name)
+		}
+	}
+}
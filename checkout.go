@@ -0,0 +1,131 @@
+package trash
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CheckoutTo copies the data of item to a new file or directory under dir
+// and returns its path. The trash entry is left as it is, so the copy can
+// be inspected and the item then deleted with Delete or left in the trash.
+func (tr *Trasher) CheckoutTo(item TrashItem, dir string) (string, error) {
+	dst := filepath.Join(dir, fmt.Sprintf("%s.%s", item.Name, randomSuffix()))
+	if err := tr.copyOut(item, dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// RestoreToTemp copies trashName to a temporary path next to its original
+// path, for the caller to inspect before committing to the restore. commit
+// moves the copy into place as Restore would with opts, under the same
+// conflict policy, and only then removes the item from the trash; if it
+// fails, the copy and the trash entry are left as they were, to commit
+// again or abandon. abandon removes the copy and leaves the trash entry
+// untouched. Once commit has succeeded, abandon does nothing.
+func (tr *Trasher) RestoreToTemp(trashName string, opts RestoreOptions) (tempPath string, commit, abandon func() error, err error) {
+	item, err := tr.findTrashItem(trashName)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	dest, err := restorePath(item.OriginalPath, opts.Base)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	dir := filepath.Dir(dest)
+	if !opts.FollowSymlinks {
+		if err := tr.checkRestoreParent(dir); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	if err := tr.fs.MkdirAll(dir, 0755); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	tempPath = filepath.Join(dir, fmt.Sprintf(".%s.restore-%s", filepath.Base(dest), randomSuffix()))
+	if err := tr.copyOut(item, tempPath); err != nil {
+		return "", nil, nil, err
+	}
+
+	abandon = func() error {
+		if err := tr.fs.RemoveAll(tempPath); err != nil {
+			return fmt.Errorf("failed to remove temporary copy: %w", err)
+		}
+		return nil
+	}
+	commit = func() error {
+		if err := tr.restoreItemFrom(item, opts, tempPath); err != nil {
+			return err
+		}
+		// ConflictSkip leaves the copy where it was
+		return abandon()
+	}
+
+	return tempPath, commit, abandon, nil
+}
+
+// RestoreCopy restores a copy of trashName to its original path, leaving
+// the item in the trash so it can be restored again later, e.g. to try out
+// an old config file. It fails with ErrAlreadyExists, like Restore, if
+// something is in the way. The copy is made beside the original path and
+// renamed into place, so a failed copy never appears there half-written.
+func (tr *Trasher) RestoreCopy(trashName string) error {
+	return tr.RestoreCopyWithOptions(trashName, RestoreOptions{})
+}
+
+// RestoreCopyWithOptions is like RestoreCopy but restores the copy as
+// RestoreWithOptions would with opts, replacing a placeholder left by
+// TrashOptions.LeavePlaceholder and applying opts.Conflict to anything
+// else in the way.
+func (tr *Trasher) RestoreCopyWithOptions(trashName string, opts RestoreOptions) error {
+	opts.keepItem = true
+	_, commit, abandon, err := tr.RestoreToTemp(trashName, opts)
+	if err != nil {
+		return err
+	}
+	if err := commit(); err != nil {
+		abandon()
+		return err
+	}
+	return nil
+}
+
+// copyOut copies the data of item to dst, which must not exist.
+func (tr *Trasher) copyOut(item TrashItem, dst string) error {
+	info, err := tr.fs.Lstat(item.FilePath)
+	if os.IsNotExist(err) {
+		return ErrFileNotInTrash
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat trashed file: %w", err)
+	}
+
+	if _, err := tr.fs.Lstat(dst); err == nil {
+		return fmt.Errorf("%w: %s", ErrAlreadyExists, dst)
+	}
+
+	job := newCopyJob(context.Background(), TrashOptions{})
+	if info.IsDir() {
+		err = tr.copyDirAcrossDevices(job, item.FilePath, dst, 0)
+	} else {
+		err = tr.copyFileAcrossDevices(job, item.FilePath, dst, info)
+	}
+	if err != nil {
+		tr.fs.RemoveAll(dst)
+		return fmt.Errorf("failed to check out %s: %w", item.Name, err)
+	}
+
+	return nil
+}
+
+func randomSuffix() string {
+	randomBytes := make([]byte, 8)
+	rand.Read(randomBytes)
+	return hex.EncodeToString(randomBytes)
+}
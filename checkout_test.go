@@ -0,0 +1,214 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckoutTo(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "suspect.bin")
+	if err := os.WriteFile(testFile, []byte("payload"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	staging := t.TempDir()
+	path, err := tr.CheckoutTo(result.TrashItem, staging)
+	if err != nil {
+		t.Fatalf("Failed to check out item: %v", err)
+	}
+	if filepath.Dir(path) != staging {
+		t.Errorf("Checked out to %s, want a path under %s", path, staging)
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != "payload" {
+		t.Errorf("Checked out file = %q, %v; want the trashed content", content, err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != result.Name {
+		t.Fatalf("Expected %s to still be listed, got %v", result.Name, items)
+	}
+
+	if err := tr.Delete(result.Name); err != nil {
+		t.Fatalf("Failed to delete checked out item: %v", err)
+	}
+	if _, err := tr.CheckoutTo(result.TrashItem, staging); err != ErrFileNotInTrash {
+		t.Errorf("Expected ErrFileNotInTrash after delete, got: %v", err)
+	}
+}
+
+func TestRestoreToTemp(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithLayout(DatedLayout{}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "draft.txt")
+	if err := os.WriteFile(testFile, []byte("draft"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	// Abandon the first preview
+	tempPath, _, abandon, err := tr.RestoreToTemp("draft.txt", RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Failed to restore to temp: %v", err)
+	}
+	if filepath.Dir(tempPath) != filepath.Dir(testFile) {
+		t.Errorf("Temp path %s is not next to %s", tempPath, testFile)
+	}
+	if content, err := os.ReadFile(tempPath); err != nil || string(content) != "draft" {
+		t.Errorf("Temp file = %q, %v; want the trashed content", content, err)
+	}
+	if err := abandon(); err != nil {
+		t.Fatalf("Failed to abandon restore: %v", err)
+	}
+	if _, err := os.Lstat(tempPath); !os.IsNotExist(err) {
+		t.Error("Temp file still exists after abandon")
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("Abandoned restore created the original path")
+	}
+	if items, err := tr.List(); err != nil || len(items) != 1 {
+		t.Fatalf("Expected the trash entry to survive an abandoned restore, got %v, %v", items, err)
+	}
+
+	// Something takes the original path before the commit
+	tempPath, commit, abandon, err := tr.RestoreToTemp("draft.txt", RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Failed to restore to temp: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("newer"), 0644); err != nil {
+		t.Fatalf("Failed to create conflicting file: %v", err)
+	}
+	if err := commit(); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Commit over a new file = %v, want ErrAlreadyExists", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "newer" {
+		t.Errorf("Conflicting file = %q, %v; want it untouched", content, err)
+	}
+	if err := abandon(); err != nil {
+		t.Fatalf("Failed to abandon restore: %v", err)
+	}
+
+	// Then commit one that renames around the conflict
+	tempPath, commit, abandon, err = tr.RestoreToTemp("draft.txt", RestoreOptions{Conflict: ConflictRename})
+	if err != nil {
+		t.Fatalf("Failed to restore to temp: %v", err)
+	}
+	if err := commit(); err != nil {
+		t.Fatalf("Failed to commit restore: %v", err)
+	}
+	if err := abandon(); err != nil {
+		t.Errorf("Abandon after commit = %v, want nil", err)
+	}
+	if content, err := os.ReadFile(testFile + ".1"); err != nil || string(content) != "draft" {
+		t.Errorf("Restored file = %q, %v; want the trashed content", content, err)
+	}
+	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+		t.Error("Temp file still exists after commit")
+	}
+	if items, err := tr.List(); err != nil || len(items) != 0 {
+		t.Errorf("Expected an empty trash after commit, got %v, %v", items, err)
+	}
+	if entries, err := os.ReadDir(filepath.Join(tr.homeTrash, "files")); err != nil || len(entries) != 0 {
+		t.Errorf("Expected no date directories left after commit, got %v, %v", entries, err)
+	}
+}
+
+func TestRestoreCopy(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(testFile, []byte("old setting"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	if err := tr.RestoreCopy("app.conf"); err != nil {
+		t.Fatalf("Failed to restore copy: %v", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "old setting" {
+		t.Errorf("Restored file = %q, %v; want the trashed content", content, err)
+	}
+	items, err := tr.List()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected the item to stay in the trash, got %v, %v", items, err)
+	}
+	if content, err := os.ReadFile(items[0].FilePath); err != nil || string(content) != "old setting" {
+		t.Errorf("Trashed data = %q, %v; want it untouched", content, err)
+	}
+
+	// The restored copy is in the way of another
+	if err := tr.RestoreCopy("app.conf"); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Second RestoreCopy = %v, want ErrAlreadyExists", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the restored file in %s, got %v, %v", dir, entries, err)
+	}
+
+	// And it can still be restored for real once the copy is gone
+	if err := os.Remove(testFile); err != nil {
+		t.Fatalf("Failed to remove copy: %v", err)
+	}
+	if err := tr.Restore("app.conf"); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != 0 {
+		t.Errorf("Expected an empty trash after restore, got %v, %v", items, err)
+	}
+}
+
+func TestRestoreCopyWithOptions(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(testFile, []byte("old setting"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := tr.TrashWithResult(testFile, TrashOptions{LeavePlaceholder: true}); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	// The placeholder makes way for the copy
+	if err := tr.RestoreCopy("app.conf"); err != nil {
+		t.Fatalf("Failed to restore copy over placeholder: %v", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "old setting" {
+		t.Errorf("Restored file = %q, %v; want the trashed content", content, err)
+	}
+
+	// Anything else in the way is up to the conflict policy
+	if err := tr.RestoreCopyWithOptions("app.conf", RestoreOptions{Conflict: ConflictRename}); err != nil {
+		t.Fatalf("Failed to restore renamed copy: %v", err)
+	}
+	if content, err := os.ReadFile(testFile + ".1"); err != nil || string(content) != "old setting" {
+		t.Errorf("Renamed copy = %q, %v; want the trashed content", content, err)
+	}
+	if err := tr.RestoreCopyWithOptions("app.conf", RestoreOptions{Conflict: ConflictSkip}); err != nil {
+		t.Errorf("Skipped RestoreCopy = %v", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 2 {
+		t.Errorf("Expected the copy and the renamed copy in %s, got %v, %v", dir, entries, err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != 1 {
+		t.Errorf("Expected the item to stay in the trash, got %v, %v", items, err)
+	}
+}
@@ -0,0 +1,33 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+)
+
+// A fileKey identifies a file by device and inode number.
+type fileKey struct {
+	dev, ino uint64
+}
+
+// ancestors tracks the directories from the root of a traversal down to
+// the current one. Symlinks are never followed, but a bind mount can still
+// make a directory appear inside itself, and descending into it would not
+// end.
+type ancestors map[fileKey]bool
+
+// enter records the directory path, described by info, as the current one,
+// failing with ErrDirectoryCycle if it already is an ancestor. leave must be
+// called once its contents are done. Platforms without inode numbers
+// detect nothing.
+func (a ancestors) enter(path string, info os.FileInfo) (leave func(), err error) {
+	key, ok := fileID(info)
+	if !ok {
+		return func() {}, nil
+	}
+	if a[key] {
+		return nil, fmt.Errorf("%w: %s", ErrDirectoryCycle, path)
+	}
+	a[key] = true
+	return func() { delete(a, key) }, nil
+}
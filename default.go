@@ -0,0 +1,639 @@
+package trash
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+var (
+	defaultMu      sync.Mutex
+	defaultTrasher *Trasher
+)
+
+// getDefault returns the Trasher used by the package-level functions,
+// creating it on first use. Failures are not cached, so a transient problem
+// (e.g. HOME not yet set) can be retried.
+func getDefault() (*Trasher, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultTrasher != nil {
+		return defaultTrasher, nil
+	}
+
+	tr, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	defaultTrasher = tr
+	return tr, nil
+}
+
+// Trash moves path into the trash. See Trasher.Trash.
+func Trash(path string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.Trash(path)
+}
+
+// TrashWithOptions is like Trash but configurable through opts.
+func TrashWithOptions(path string, opts TrashOptions) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.TrashWithOptions(path, opts)
+}
+
+// TrashWithResult moves path into the trash and reports where it went. See
+// Trasher.TrashWithResult.
+func TrashWithResult(path string, opts TrashOptions) (TrashResult, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return TrashResult{}, err
+	}
+	return tr.TrashWithResult(path, opts)
+}
+
+// TrashWithReason moves path into the trash, recording why. See
+// Trasher.TrashWithReason.
+func TrashWithReason(path, reason string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.TrashWithReason(path, reason)
+}
+
+// TrashAs trashes path under a name derived from nameHint. See
+// Trasher.TrashAs.
+func TrashAs(path, nameHint string) (string, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return "", err
+	}
+	return tr.TrashAs(path, nameHint)
+}
+
+// TrashContext moves path into the trash unless ctx is done first. See
+// Trasher.TrashContext.
+func TrashContext(ctx context.Context, path string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.TrashContext(ctx, path)
+}
+
+// TrashWithTimeout moves path into the trash, giving up after d. See
+// Trasher.TrashWithTimeout.
+func TrashWithTimeout(path string, d time.Duration) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.TrashWithTimeout(path, d)
+}
+
+// TrashAt moves path into the trash, recording deletionTime as its deletion
+// date. See Trasher.TrashAt.
+func TrashAt(path string, deletionTime time.Time) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.TrashAt(path, deletionTime)
+}
+
+// List returns all trashed items. See Trasher.List.
+func List() ([]TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.List()
+}
+
+// ListFrom returns the items in the single trash directory trashDir. See
+// Trasher.ListFrom.
+func ListFrom(trashDir string) ([]TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.ListFrom(trashDir)
+}
+
+// ListWithOptions is like List but configurable through opts. See
+// Trasher.ListWithOptions.
+func ListWithOptions(opts ListOptions) ([]TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.ListWithOptions(opts)
+}
+
+// SizeOf returns the size of the data of item. See Trasher.SizeOf.
+func SizeOf(item TrashItem) (int64, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return 0, err
+	}
+	return tr.SizeOf(item)
+}
+
+// Find returns the trashed item trashName. See Trasher.Find.
+func Find(trashName string) (TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return TrashItem{}, err
+	}
+	return tr.Find(trashName)
+}
+
+// ListUnder returns the items deleted from dir or below it. See
+// Trasher.ListUnder.
+func ListUnder(dir string) ([]TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.ListUnder(dir)
+}
+
+// ListGrouped returns all trashed items keyed by the directory they were
+// deleted from. See Trasher.ListGrouped.
+func ListGrouped() (map[string][]TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.ListGrouped()
+}
+
+// Restore moves trashName back to its original path. See Trasher.Restore.
+func Restore(trashName string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.Restore(trashName)
+}
+
+// RestoreWithOptions is like Restore but configurable through opts.
+func RestoreWithOptions(trashName string, opts RestoreOptions) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.RestoreWithOptions(trashName, opts)
+}
+
+// RestoreByOriginalPath restores the latest item trashed from
+// originalPath. See Trasher.RestoreByOriginalPath.
+func RestoreByOriginalPath(originalPath string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.RestoreByOriginalPath(originalPath)
+}
+
+// RestoreAndTrashConflict restores trashName, trashing anything in its way.
+// See Trasher.RestoreAndTrashConflict.
+func RestoreAndTrashConflict(trashName string) (string, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return "", err
+	}
+	return tr.RestoreAndTrashConflict(trashName)
+}
+
+// Empty permanently removes every trashed item. See Trasher.Empty.
+func Empty() error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.Empty()
+}
+
+// EmptyContext is like Empty but can be cancelled through ctx and reports
+// its progress. See Trasher.EmptyContext.
+func EmptyContext(ctx context.Context) (EmptyResult, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return EmptyResult{}, err
+	}
+	return tr.EmptyContext(ctx)
+}
+
+// EmptyWithOptions is like EmptyContext but configurable through opts. See
+// Trasher.EmptyWithOptions.
+func EmptyWithOptions(ctx context.Context, opts EmptyOptions) (EmptyResult, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return EmptyResult{}, err
+	}
+	return tr.EmptyWithOptions(ctx, opts)
+}
+
+// Delete permanently removes trashName from the trash. See Trasher.Delete.
+func Delete(trashName string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.Delete(trashName)
+}
+
+// HasTrash reports whether any trash directory exists, without creating
+// one. See Trasher.HasTrash.
+func HasTrash() (bool, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return false, err
+	}
+	return tr.HasTrash()
+}
+
+// TrashDirExists reports whether the home trash exists, without creating
+// it. See Trasher.TrashDirExists.
+func TrashDirExists() bool {
+	tr, err := getDefault()
+	if err != nil {
+		return false
+	}
+	return tr.TrashDirExists()
+}
+
+// Stats reports the size of the trash per trash directory. See
+// Trasher.Stats.
+func Stats() (TrashStats, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return TrashStats{}, err
+	}
+	return tr.Stats()
+}
+
+// TrashDryRun reports what trashing each of paths would do, without doing
+// it. See Trasher.TrashDryRun.
+func TrashDryRun(paths []string) []TrashPlan {
+	tr, err := getDefault()
+	if err != nil {
+		plans := make([]TrashPlan, len(paths))
+		for i, path := range paths {
+			plans[i] = TrashPlan{Source: path, Err: err}
+		}
+		return plans
+	}
+	return tr.TrashDryRun(paths)
+}
+
+// CheckoutTo copies the item's data to a new path under dir, leaving it in
+// the trash. See Trasher.CheckoutTo.
+func (item TrashItem) CheckoutTo(dir string) (string, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return "", err
+	}
+	return tr.CheckoutTo(item, dir)
+}
+
+// InfoContent returns the raw contents of the item's .trashinfo file. See
+// Trasher.InfoContent.
+func (item TrashItem) InfoContent() (string, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return "", err
+	}
+	return tr.InfoContent(item)
+}
+
+// RewriteInfo rewrites the item's .trashinfo file in the canonical format.
+// See Trasher.RewriteInfo.
+func (item TrashItem) RewriteInfo() error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.RewriteInfo(item)
+}
+
+// GetQuota returns the configured size cap of the home trash. See
+// Trasher.GetQuota.
+func GetQuota() (int64, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return 0, err
+	}
+	return tr.GetQuota()
+}
+
+// SetQuota records a size cap for the home trash. See Trasher.SetQuota.
+func SetQuota(bytes int64) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.SetQuota(bytes)
+}
+
+// RestoreToTemp copies trashName next to its original path for inspection,
+// returning functions that complete or abandon the restore. See
+// Trasher.RestoreToTemp.
+func RestoreToTemp(trashName string, opts RestoreOptions) (string, func() error, func() error, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return tr.RestoreToTemp(trashName, opts)
+}
+
+// RestoreCopy restores a copy of trashName, leaving it in the trash. See
+// Trasher.RestoreCopy.
+func RestoreCopy(trashName string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.RestoreCopy(trashName)
+}
+
+// RestoreCopyWithOptions is like RestoreCopy but configurable through
+// opts. See Trasher.RestoreCopyWithOptions.
+func RestoreCopyWithOptions(trashName string, opts RestoreOptions) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.RestoreCopyWithOptions(trashName, opts)
+}
+
+// BeginBatch starts a group of files to be trashed together. See
+// Trasher.BeginBatch.
+func BeginBatch() (*Batch, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.BeginBatch(), nil
+}
+
+// RestoreBatch restores every item trashed in the batch id. See
+// Trasher.RestoreBatch.
+func RestoreBatch(id string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.RestoreBatch(id)
+}
+
+// DeleteBatch permanently removes every item trashed in the batch id. See
+// Trasher.DeleteBatch.
+func DeleteBatch(id string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.DeleteBatch(id)
+}
+
+// ListSuspicious returns trashed items whose original path doesn't fit the
+// trash holding them. See Trasher.ListSuspicious.
+func ListSuspicious() ([]TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.ListSuspicious()
+}
+
+// ReTrash trashes the file at the item's original path again and returns
+// the new trash name. See Trasher.ReTrash.
+func (item TrashItem) ReTrash() (string, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return "", err
+	}
+	return tr.ReTrash(item)
+}
+
+// Health checks that the home trash is still private to the user. See
+// Trasher.Health.
+func Health() ([]HealthIssue, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.Health()
+}
+
+// FixPermissions makes the home trash private to the user again. See
+// Trasher.FixPermissions.
+func FixPermissions() error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.FixPermissions()
+}
+
+// ListPage returns one page of the sorted trash and the total number of
+// items. See Trasher.ListPage.
+func ListPage(offset, limit int, sortBy SortField) ([]TrashItem, int, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, 0, err
+	}
+	return tr.ListPage(offset, limit, sortBy)
+}
+
+// Exists reports whether trashName is in the trash and which trash
+// directory holds it. See Trasher.Exists.
+func Exists(trashName string) (bool, string, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return false, "", err
+	}
+	return tr.Exists(trashName)
+}
+
+// TrashExcept trashes the contents of dir except entries matching
+// excludes. See Trasher.TrashExcept.
+func TrashExcept(dir string, excludes []string) (int, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return 0, err
+	}
+	return tr.TrashExcept(dir, excludes)
+}
+
+// Disable makes later calls to Trash fail with ErrTrashDisabled until
+// Enable is called. See Trasher.Disable.
+func Disable() error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	tr.Disable()
+	return nil
+}
+
+// Enable undoes Disable. See Trasher.Enable.
+func Enable() error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	tr.Enable()
+	return nil
+}
+
+// OldestItem returns the item deleted longest ago. See Trasher.OldestItem.
+func OldestItem() (TrashItem, bool, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return TrashItem{}, false, err
+	}
+	return tr.OldestItem()
+}
+
+// NewestItem returns the most recently deleted item. See
+// Trasher.NewestItem.
+func NewestItem() (TrashItem, bool, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return TrashItem{}, false, err
+	}
+	return tr.NewestItem()
+}
+
+// Remove permanently deletes path, refusing the paths Trash refuses. See
+// Trasher.Remove.
+func Remove(path string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.Remove(path)
+}
+
+// CheckDirectorySizes reports directorysizes cache entries that disagree
+// with the trashed directories, optionally fixing them. See
+// Trasher.CheckDirectorySizes.
+func CheckDirectorySizes(tolerance int64, fix bool) ([]DirSizeMismatch, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.CheckDirectorySizes(tolerance, fix)
+}
+
+// TrashFS returns a read-only fs.FS over the trashed data. See
+// Trasher.TrashFS.
+func TrashFS() (fs.FS, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.TrashFS()
+}
+
+// ListKnownDevices returns the filesystems trashed to outside the home
+// trash, mounted or not. See Trasher.ListKnownDevices.
+func ListKnownDevices() ([]KnownDevice, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.ListKnownDevices()
+}
+
+// SelfTest checks that trashing works in every writable trash directory.
+// See Trasher.SelfTest.
+func SelfTest() error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.SelfTest()
+}
+
+// RestoreGlob restores every item whose original path matches pattern. See
+// Trasher.RestoreGlob.
+func RestoreGlob(pattern string) (int, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return 0, err
+	}
+	return tr.RestoreGlob(pattern)
+}
+
+// DeleteGlob permanently removes every item whose original path matches
+// pattern. See Trasher.DeleteGlob.
+func DeleteGlob(pattern string) (int, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return 0, err
+	}
+	return tr.DeleteGlob(pattern)
+}
+
+// TrashAll trashes every path, carrying on past failures. See
+// Trasher.TrashAll.
+func TrashAll(paths []string) ([]TrashItem, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return tr.TrashAll(paths)
+}
+
+// PurgeOlderThan permanently removes every item deleted more than d ago.
+// See Trasher.PurgeOlderThan.
+func PurgeOlderThan(d time.Duration) (int, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return 0, err
+	}
+	return tr.PurgeOlderThan(d)
+}
+
+// EmptyDir permanently removes every item from the single trash directory
+// trashDir. See Trasher.EmptyDir.
+func EmptyDir(trashDir string) error {
+	tr, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return tr.EmptyDir(trashDir)
+}
+
+// Fsck reports the inconsistencies in the single trash directory trashDir.
+// See Trasher.Fsck.
+func Fsck(trashDir string) (TrashReport, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return TrashReport{}, err
+	}
+	return tr.Fsck(trashDir)
+}
+
+// PruneOrphans removes the inconsistencies Fsck reports in trashDir. See
+// Trasher.PruneOrphans.
+func PruneOrphans(trashDir string) (TrashReport, error) {
+	tr, err := getDefault()
+	if err != nil {
+		return TrashReport{}, err
+	}
+	return tr.PruneOrphans(trashDir)
+}
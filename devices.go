@@ -0,0 +1,245 @@
+package trash
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// devicesFile, in the home trash, remembers the removable and other
+// non-home filesystems this user has trashed to, with their identity as
+// recorded in the trashinfo files of their own trash, which is out of
+// reach while they are unmounted. It is only kept once the home trash
+// exists; trashing to a mount never creates the home trash for it.
+const devicesFile = "devices"
+
+// A KnownDevice is a filesystem outside the home trash that items have been
+// trashed to.
+type KnownDevice struct {
+	// UUID and Label identify the filesystem, as blkid reports them.
+	UUID  string
+	Label string
+
+	// MountPoint is where the filesystem is mounted now, or was last seen
+	// mounted if Mounted is false.
+	MountPoint string
+	Mounted    bool
+
+	// Items is the number of items in the filesystem's trash, counted now
+	// if it is mounted, or when it was last seen otherwise.
+	Items    int
+	LastSeen time.Time
+}
+
+// ListKnownDevices returns the filesystems other than the home one this
+// Trasher has trashed to, recognized by UUID, with whether each is mounted
+// and how many items its trash holds. An unmounted device with items means
+// plugging it back in will reveal more trash than List shows now. Devices
+// whose identity can't be determined, such as tmpfs, are not tracked, and
+// unmounted ones are only remembered once the home trash exists.
+func (tr *Trasher) ListKnownDevices() ([]KnownDevice, error) {
+	// Refresh whatever is mounted now
+	var mounted []KnownDevice
+	for _, trashDir := range tr.mountTrashDirs() {
+		if dev, ok := tr.identifyDevice(trashDir); ok {
+			mounted = append(mounted, dev)
+		}
+	}
+
+	if len(mounted) == 0 {
+		known, err := tr.readKnownDevices()
+		if err != nil {
+			return nil, err
+		}
+		return sortedDevices(known), nil
+	}
+	return tr.updateKnownDevices(func(known map[string]KnownDevice) {
+		for _, dev := range mounted {
+			known[dev.UUID] = dev
+		}
+	})
+}
+
+// rememberDevice records the filesystem holding the mount trash trashDir
+// in the devices file, after an item was trashed to it, with its trash
+// recounted.
+func (tr *Trasher) rememberDevice(trashDir string) error {
+	dev, ok := tr.identifyDevice(trashDir)
+	if !ok {
+		return nil
+	}
+
+	_, err := tr.updateKnownDevices(func(known map[string]KnownDevice) {
+		known[dev.UUID] = dev
+	})
+	return err
+}
+
+// recountDevice updates the item count of the known device holding the
+// trash directory trashDir after items left it, so an emptied drive isn't
+// reported with items once it is unplugged. Devices not known yet are left
+// for rememberDevice, and failing to update is no reason to fail the
+// change.
+func (tr *Trasher) recountDevice(trashDir string) {
+	if trashDir == tr.homeTrash {
+		return
+	}
+	known, err := tr.readKnownDevices()
+	if err != nil || len(known) == 0 {
+		return
+	}
+	dev, ok := tr.identifyDevice(trashDir)
+	if !ok {
+		return
+	}
+	if _, ok := known[dev.UUID]; !ok {
+		return
+	}
+
+	tr.updateKnownDevices(func(known map[string]KnownDevice) {
+		if _, ok := known[dev.UUID]; ok {
+			known[dev.UUID] = dev
+		}
+	})
+}
+
+// updateKnownDevices applies update to the known devices, keyed by UUID,
+// and saves them, all under the home trash's lock. Without a home trash
+// they are not saved. It returns every known device.
+func (tr *Trasher) updateKnownDevices(update func(known map[string]KnownDevice)) ([]KnownDevice, error) {
+	defer tr.lockTrashDir(tr.homeTrash)()
+
+	known, err := tr.readKnownDevices()
+	if err != nil {
+		return nil, err
+	}
+	update(known)
+
+	devices := sortedDevices(known)
+	if _, err := tr.fs.Lstat(tr.homeTrash); os.IsNotExist(err) {
+		return devices, nil
+	}
+	var buf bytes.Buffer
+	for _, dev := range devices {
+		fmt.Fprintf(&buf, "%s %s %s %d %d\n",
+			escapeField(dev.UUID), escapeField(dev.Label), escapeField(dev.MountPoint),
+			dev.Items, dev.LastSeen.Unix())
+	}
+	if err := tr.writeFileAtomic(filepath.Join(tr.homeTrash, devicesFile), buf.Bytes(), 0600); err != nil {
+		return devices, fmt.Errorf("failed to write known devices: %w", err)
+	}
+	return devices, nil
+}
+
+func sortedDevices(known map[string]KnownDevice) []KnownDevice {
+	devices := make([]KnownDevice, 0, len(known))
+	for _, dev := range known {
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].UUID < devices[j].UUID })
+	return devices
+}
+
+// identifyDevice describes the mounted filesystem holding the mount trash
+// trashDir. Where the device can't be looked up now, e.g. without udev, it
+// is known by the identity its trashinfo files record.
+func (tr *Trasher) identifyDevice(trashDir string) (KnownDevice, bool) {
+	mount := tr.trashDirMount(trashDir)
+	names, _ := tr.layout.Entries(trashDir, tr.fs.ReadDir)
+	uuid, label := tr.deviceLabel(mount)
+	if uuid == "" {
+		uuid, label = tr.recordedDevice(trashDir, names)
+	}
+	if uuid == "" {
+		return KnownDevice{}, false
+	}
+
+	return KnownDevice{
+		UUID:       uuid,
+		Label:      label,
+		MountPoint: mount,
+		Mounted:    true,
+		Items:      len(names),
+		LastSeen:   time.Now(),
+	}, true
+}
+
+// recordedDevice returns the device identity recorded in the first of the
+// trashinfo files of trashDir named by names that has one.
+func (tr *Trasher) recordedDevice(trashDir string, names []string) (uuid, label string) {
+	for _, name := range names {
+		if item, err := tr.parseTrashInfo(trashDir, name); err == nil && item.DeviceUUID != "" {
+			return item.DeviceUUID, item.DeviceLabel
+		}
+	}
+	return "", ""
+}
+
+// readKnownDevices reads the devices file, keyed by UUID. Devices are
+// reported as unmounted; callers refresh the mounted ones.
+func (tr *Trasher) readKnownDevices() (map[string]KnownDevice, error) {
+	known := make(map[string]KnownDevice)
+
+	content, err := tr.readFile(filepath.Join(tr.homeTrash, devicesFile))
+	if os.IsNotExist(err) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known devices: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		// uuid label mount-point items last-seen, all but the numbers
+		// URI-escaped
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 5 {
+			continue
+		}
+		items, err := strconv.Atoi(fields[3])
+		if err != nil {
+			continue
+		}
+		seen, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			continue
+		}
+		dev := KnownDevice{
+			UUID:       unescapeField(fields[0]),
+			Label:      unescapeField(fields[1]),
+			MountPoint: unescapeField(fields[2]),
+			Items:      items,
+			LastSeen:   time.Unix(seen, 0),
+		}
+		known[dev.UUID] = dev
+	}
+	return known, nil
+}
+
+// escapeField escapes s as one space-separated field; an empty s is "-".
+func escapeField(s string) string {
+	switch s {
+	case "":
+		return "-"
+	case "-":
+		return "%2D"
+	}
+	return url.PathEscape(s)
+}
+
+func unescapeField(s string) string {
+	if s == "-" {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+//go:build linux
+// +build linux
+
+package trash
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lookupDeviceLabel returns the filesystem UUID and label of the device
+// mounted at mount, found as blkid would through the udev symlinks in
+// /dev/disk. Either is "" if unknown.
+func lookupDeviceLabel(mount string) (uuid, label string) {
+	source := mountSource(mount)
+	if source == "" {
+		return "", ""
+	}
+	dev, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return "", ""
+	}
+
+	return diskLink("/dev/disk/by-uuid", dev), diskLink("/dev/disk/by-label", dev)
+}
+
+// mountSource returns the device mounted at mount according to
+// /proc/mounts, the last one if several are stacked.
+func mountSource(mount string) string {
+	file, err := os.Open("/proc/mounts")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var source string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && unescapeMountPoint(fields[1]) == mount {
+			source = unescapeMountPoint(fields[0])
+		}
+	}
+	return source
+}
+
+// diskLink returns the name of the symlink in dir pointing at dev.
+func diskLink(dir, dev string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		target, err := filepath.EvalSymlinks(filepath.Join(dir, entry.Name()))
+		if err == nil && target == dev {
+			// udev escapes unusual characters in labels as \xNN
+			return unescapeUdev(entry.Name())
+		}
+	}
+	return ""
+}
+
+// unescapeUdev decodes the \xNN escapes udev uses in /dev/disk names.
+func unescapeUdev(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] == 'x' && isHex(s[i+2]) && isHex(s[i+3]) {
+			b.WriteByte(unhex(s[i+2])<<4 | unhex(s[i+3]))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
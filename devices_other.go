@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package trash
+
+// lookupDeviceLabel is not implemented outside Linux, so no devices are
+// tracked.
+func lookupDeviceLabel(mount string) (uuid, label string) {
+	return "", ""
+}
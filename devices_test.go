@@ -0,0 +1,172 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListKnownDevices(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+	tr.deviceLabel = func(m string) (string, string) {
+		if m == mount {
+			return "1234-ABCD", "MY USB"
+		}
+		return "", ""
+	}
+
+	// A drive seen in an earlier session, not plugged in now
+	seeded := "5678-EF01 BACKUP%20DISK /media/backup 3 1700000000\n"
+	if err := os.MkdirAll(tr.homeTrash, 0700); err != nil {
+		t.Fatalf("Failed to create trash: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tr.homeTrash, devicesFile), []byte(seeded), 0600); err != nil {
+		t.Fatalf("Failed to seed known devices: %v", err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		path := filepath.Join(mount, name)
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	devices, err := tr.ListKnownDevices()
+	if err != nil {
+		t.Fatalf("ListKnownDevices failed: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("Expected 2 devices, got %+v", devices)
+	}
+	usb, backup := devices[0], devices[1]
+	if usb.UUID != "1234-ABCD" || usb.Label != "MY USB" || !usb.Mounted || usb.Items != 2 || usb.MountPoint != mount {
+		t.Errorf("Mounted device = %+v, want MY USB at %s with 2 items", usb, mount)
+	}
+	if backup.UUID != "5678-EF01" || backup.Label != "BACKUP DISK" || backup.Mounted || backup.Items != 3 || backup.MountPoint != "/media/backup" {
+		t.Errorf("Seeded device = %+v, want unmounted BACKUP DISK with 3 items", backup)
+	}
+
+	// Once unplugged, the drive's items are still accounted for
+	tr.mountPoints = func() ([]string, error) { return []string{"/"}, nil }
+	devices, err = tr.ListKnownDevices()
+	if err != nil {
+		t.Fatalf("ListKnownDevices failed: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("Expected 2 devices, got %+v", devices)
+	}
+	if usb := devices[0]; usb.Mounted || usb.Items != 2 || usb.Label != "MY USB" {
+		t.Errorf("Unplugged device = %+v, want unmounted MY USB with 2 items", usb)
+	}
+}
+
+func TestKnownDeviceRecount(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+	tr.deviceLabel = func(m string) (string, string) {
+		if m == mount {
+			return "1234-ABCD", "MY USB"
+		}
+		return "", ""
+	}
+	// Devices are only remembered in an existing home trash
+	if err := os.MkdirAll(tr.homeTrash, 0700); err != nil {
+		t.Fatalf("Failed to create trash: %v", err)
+	}
+
+	var results []TrashResult
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		path := filepath.Join(mount, name)
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		result, err := tr.TrashWithResult(path, TrashOptions{})
+		if err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	// Each way items leave the drive's trash is counted before it is
+	// unplugged
+	unplugged := func() int {
+		t.Helper()
+		known, err := tr.readKnownDevices()
+		if err != nil {
+			t.Fatalf("Failed to read known devices: %v", err)
+		}
+		return known["1234-ABCD"].Items
+	}
+	if n := unplugged(); n != 4 {
+		t.Fatalf("Known device has %d items after trashing, want 4", n)
+	}
+	if err := tr.Restore(results[0].Name); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if n := unplugged(); n != 3 {
+		t.Errorf("Known device has %d items after a restore, want 3", n)
+	}
+	if err := tr.Delete(results[1].Name); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if n := unplugged(); n != 2 {
+		t.Errorf("Known device has %d items after a delete, want 2", n)
+	}
+	if err := tr.Empty(); err != nil {
+		t.Fatalf("Failed to empty: %v", err)
+	}
+	if n := unplugged(); n != 0 {
+		t.Errorf("Known device has %d items after emptying, want 0", n)
+	}
+}
+
+func TestKnownDeviceTrashInfo(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+	tr.deviceLabel = func(m string) (string, string) {
+		if m == mount {
+			return "1234-ABCD", "MY USB"
+		}
+		return "", ""
+	}
+
+	testFile := filepath.Join(mount, "a.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	content, err := os.ReadFile(result.InfoPath)
+	if err != nil {
+		t.Fatalf("Failed to read trash info: %v", err)
+	}
+	if !strings.Contains(string(content), "\nX-Device-UUID=1234-ABCD\nX-Device-Label=MY%20USB\n") {
+		t.Errorf("Trash info = %q, want the device identity", content)
+	}
+	if _, err := os.Lstat(tr.homeTrash); !os.IsNotExist(err) {
+		t.Errorf("Trashing to a mount created the home trash: %v", err)
+	}
+
+	// Without a way to look the device up, the trashinfo still tells
+	tr.deviceLabel = func(string) (string, string) { return "", "" }
+	devices, err := tr.ListKnownDevices()
+	if err != nil {
+		t.Fatalf("ListKnownDevices failed: %v", err)
+	}
+	if len(devices) != 1 || devices[0].UUID != "1234-ABCD" || devices[0].Label != "MY USB" || devices[0].Items != 1 {
+		t.Errorf("Devices = %+v, want MY USB with 1 item", devices)
+	}
+	if _, err := os.Lstat(tr.homeTrash); !os.IsNotExist(err) {
+		t.Errorf("ListKnownDevices created the home trash: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package trash
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// directorySizesFile is the cache of trashed directory sizes defined by
+// version 1.0 of the specification, kept at the top of a trash directory
+// by tools such as gio and trash-cli. Trash adds an entry for every
+// directory it trashes, and the entry is dropped when the item leaves the
+// trash.
+const directorySizesFile = "directorysizes"
+
+// A DirSizeMismatch is a directorysizes entry that disagrees with the
+// trashed directory it describes.
+type DirSizeMismatch struct {
+	TrashDir string
+	Name     string
+
+	// Cached is the size recorded in directorysizes, and Actual the size
+	// of the directory now, in bytes.
+	Cached int64
+	Actual int64
+
+	// Missing is set if the entry names no trashed directory at all.
+	Missing bool
+}
+
+// dirSizeEntry is one line of a directorysizes file: the size in bytes,
+// the trashinfo modification time in seconds and the escaped entry name.
+type dirSizeEntry struct {
+	size  int64
+	mtime int64
+	name  string
+}
+
+// CheckDirectorySizes compares the directorysizes cache of every trash
+// directory with the actual sizes of the trashed directories, and returns
+// the entries off by more than tolerance bytes or naming directories no
+// longer in the trash. A stale cache means
+// another tool changed a trashed directory behind its back. If fix is set,
+// the cache is rewritten with the current sizes and without the missing
+// entries. Trash directories without a cache are skipped.
+func (tr *Trasher) CheckDirectorySizes(tolerance int64, fix bool) ([]DirSizeMismatch, error) {
+	var mismatches []DirSizeMismatch
+
+	dirs := append([]string{tr.homeTrash}, tr.mountTrashDirs()...)
+	for _, trashDir := range dirs {
+		found, err := tr.checkDirectorySizes(trashDir, tolerance, fix)
+		if err != nil {
+			return mismatches, err
+		}
+		mismatches = append(mismatches, found...)
+	}
+
+	return mismatches, nil
+}
+
+func (tr *Trasher) checkDirectorySizes(trashDir string, tolerance int64, fix bool) ([]DirSizeMismatch, error) {
+	if fix {
+		defer tr.lockTrashDir(trashDir)()
+	}
+
+	cachePath := filepath.Join(trashDir, directorySizesFile)
+	content, err := tr.readFile(cachePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory sizes: %w", err)
+	}
+
+	var mismatches []DirSizeMismatch
+	var fixed []dirSizeEntry
+	for _, entry := range parseDirectorySizes(content) {
+		name, err := url.PathUnescape(entry.name)
+		if err != nil {
+			name = entry.name
+		}
+
+		item, err := tr.parseTrashInfo(trashDir, name)
+		var actual int64
+		if err == nil {
+			actual, err = tr.diskUsage(item.FilePath)
+		}
+		if err != nil {
+			mismatches = append(mismatches, DirSizeMismatch{TrashDir: trashDir, Name: name, Cached: entry.size, Missing: true})
+			continue
+		}
+
+		if diff := actual - entry.size; diff > tolerance || -diff > tolerance {
+			mismatches = append(mismatches, DirSizeMismatch{TrashDir: trashDir, Name: name, Cached: entry.size, Actual: actual})
+			entry.size = actual
+			if info, err := tr.fs.Stat(item.InfoPath); err == nil {
+				entry.mtime = info.ModTime().Unix()
+			}
+		}
+		fixed = append(fixed, entry)
+	}
+
+	if fix && len(mismatches) > 0 {
+		if err := tr.writeDirectorySizes(cachePath, fixed); err != nil {
+			return mismatches, err
+		}
+	}
+	return mismatches, nil
+}
+
+// parseDirectorySizes returns the well-formed entries of a directorysizes
+// file, skipping any others.
+func parseDirectorySizes(content []byte) []dirSizeEntry {
+	var entries []dirSizeEntry
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		mtime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, dirSizeEntry{size: size, mtime: mtime, name: fields[2]})
+	}
+	return entries
+}
+
+// writeDirectorySizes replaces the cache at cachePath with entries,
+// through a temporary file renamed into place as the specification asks,
+// so readers never see it half written.
+func (tr *Trasher) writeDirectorySizes(cachePath string, entries []dirSizeEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		fmt.Fprintf(&buf, "%d %d %s\n", entry.size, entry.mtime, entry.name)
+	}
+
+	if err := tr.writeFileAtomic(cachePath, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write directory sizes: %w", err)
+	}
+	return nil
+}
+
+// readDirectorySizes returns the directorysizes entries of trashDir keyed
+// by entry name. A missing or unreadable cache has no entries.
+func (tr *Trasher) readDirectorySizes(trashDir string) map[string]dirSizeEntry {
+	content, err := tr.readFile(filepath.Join(trashDir, directorySizesFile))
+	if err != nil {
+		return nil
+	}
+	entries := make(map[string]dirSizeEntry)
+	for _, entry := range parseDirectorySizes(content) {
+		entries[entry.unescapedName()] = entry
+	}
+	return entries
+}
+
+// unescapedName returns the entry name, which should be escaped like a
+// trashinfo Path but may be written raw.
+func (e dirSizeEntry) unescapedName() string {
+	if name, err := url.PathUnescape(e.name); err == nil {
+		return name
+	}
+	return e.name
+}
+
+// sizeOf is SizeOf with the directorysizes entries of item's trash
+// directory at hand. An entry is used only while its modification time
+// still matches the trashinfo's, as the specification asks; otherwise,
+// and for anything but directories, the size is computed afresh.
+func (tr *Trasher) sizeOf(item TrashItem, cache map[string]dirSizeEntry) (int64, error) {
+	if entry, ok := cache[item.Name]; ok {
+		dataInfo, err := tr.fs.Lstat(item.FilePath)
+		if err == nil && dataInfo.IsDir() {
+			if info, err := tr.fs.Stat(item.InfoPath); err == nil && info.ModTime().Unix() == entry.mtime {
+				return entry.size, nil
+			}
+		}
+	}
+
+	size, err := tr.diskUsage(item.FilePath)
+	if os.IsNotExist(err) {
+		return 0, ErrFileNotInTrash
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute size: %w", err)
+	}
+	return size, nil
+}
+
+// recordDirectorySize adds the newly trashed directory item to the
+// directorysizes cache of its trash directory.
+func (tr *Trasher) recordDirectorySize(item TrashItem) error {
+	size, err := tr.diskUsage(item.FilePath)
+	if err != nil {
+		return err
+	}
+	info, err := tr.fs.Stat(item.InfoPath)
+	if err != nil {
+		return err
+	}
+	entry := dirSizeEntry{size: size, mtime: info.ModTime().Unix(), name: escapeTrashInfoPath(item.Name)}
+
+	defer tr.lockTrashDir(item.TrashDir)()
+	return tr.updateDirectorySizes(item.TrashDir, func(e dirSizeEntry) bool {
+		return e.unescapedName() != item.Name
+	}, entry)
+}
+
+// forgetDirectorySize drops the entry of item, which has left the trash,
+// from the directorysizes cache. The caller holds the trash directory lock.
+// Failing to is harmless, as a stale entry is never used.
+func (tr *Trasher) forgetDirectorySize(item TrashItem) {
+	tr.updateDirectorySizes(item.TrashDir, func(e dirSizeEntry) bool {
+		return e.unescapedName() != item.Name
+	})
+}
+
+// pruneDirectorySizes drops the entries of items no longer in trashDir from
+// its directorysizes cache. The caller holds the trash directory lock.
+func (tr *Trasher) pruneDirectorySizes(trashDir string) {
+	tr.updateDirectorySizes(trashDir, func(e dirSizeEntry) bool {
+		_, err := tr.fs.Lstat(tr.layout.InfoPath(trashDir, e.unescapedName()))
+		return err == nil
+	})
+}
+
+// updateDirectorySizes rewrites the directorysizes cache of trashDir with
+// the entries for which keep is true, followed by added. It leaves the
+// cache alone if that changes nothing, and removes it once it is empty.
+func (tr *Trasher) updateDirectorySizes(trashDir string, keep func(dirSizeEntry) bool, added ...dirSizeEntry) error {
+	cachePath := filepath.Join(trashDir, directorySizesFile)
+	content, err := tr.readFile(cachePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read directory sizes: %w", err)
+	}
+
+	entries := parseDirectorySizes(content)
+	kept := entries[:0:0]
+	for _, entry := range entries {
+		if keep(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(entries) && len(added) == 0 {
+		return nil
+	}
+
+	kept = append(kept, added...)
+	if len(kept) == 0 {
+		if err := tr.fs.Remove(cachePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove directory sizes: %w", err)
+		}
+		return nil
+	}
+	return tr.writeDirectorySizes(cachePath, kept)
+}
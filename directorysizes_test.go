@@ -0,0 +1,192 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckDirectorySizes(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testDir := filepath.Join(t.TempDir(), "photos")
+	if err := os.MkdirAll(testDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for name, size := range map[string]int{"a.jpg": 1000, "b.jpg": 500} {
+		if err := os.WriteFile(filepath.Join(testDir, name), make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := tr.Trash(testDir); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+
+	// Another tool cached the size before a file was added, and has an
+	// entry for a directory since removed
+	cachePath := filepath.Join(tr.homeTrash, directorySizesFile)
+	stale := "1000 1700000000 photos\n4096 1700000000 gone%20dir\n"
+	if err := os.WriteFile(cachePath, []byte(stale), 0600); err != nil {
+		t.Fatalf("Failed to write directory sizes: %v", err)
+	}
+
+	// Within the tolerance, only the missing entry is reported
+	mismatches, err := tr.CheckDirectorySizes(600, false)
+	if err != nil {
+		t.Fatalf("CheckDirectorySizes failed: %v", err)
+	}
+	if len(mismatches) != 1 || mismatches[0].Name != "gone dir" || !mismatches[0].Missing {
+		t.Errorf("Mismatches within tolerance = %+v, want only the missing entry", mismatches)
+	}
+
+	mismatches, err = tr.CheckDirectorySizes(0, false)
+	if err != nil {
+		t.Fatalf("CheckDirectorySizes failed: %v", err)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("Expected 2 mismatches, got %+v", mismatches)
+	}
+	if m := mismatches[0]; m.Name != "photos" || m.Cached != 1000 || m.Actual != 1500 || m.Missing {
+		t.Errorf("Mismatch = %+v, want photos cached 1000, actually 1500", m)
+	}
+	if content, _ := os.ReadFile(cachePath); string(content) != stale {
+		t.Errorf("Check without fix changed the cache to %q", content)
+	}
+
+	if _, err := tr.CheckDirectorySizes(0, true); err != nil {
+		t.Fatalf("CheckDirectorySizes failed to fix: %v", err)
+	}
+	content, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("Failed to read directory sizes: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "1500 ") || !strings.HasSuffix(lines[0], " photos") {
+		t.Errorf("Fixed cache = %q, want a single corrected photos entry", content)
+	}
+
+	mismatches, err = tr.CheckDirectorySizes(0, false)
+	if err != nil {
+		t.Fatalf("CheckDirectorySizes failed: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("Expected no mismatches after fixing, got %+v", mismatches)
+	}
+}
+
+func TestDirectorySizesCache(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+	cachePath := filepath.Join(tr.homeTrash, directorySizesFile)
+
+	trashDir := func(name string, size int) TrashItem {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("Failed to create test directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "data"), make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		result, err := tr.TrashWithResult(path, TrashOptions{})
+		if err != nil {
+			t.Fatalf("Failed to trash directory: %v", err)
+		}
+		return result.TrashItem
+	}
+	cache := func() string {
+		t.Helper()
+		content, err := os.ReadFile(cachePath)
+		if os.IsNotExist(err) {
+			return ""
+		}
+		if err != nil {
+			t.Fatalf("Failed to read directory sizes: %v", err)
+		}
+		return string(content)
+	}
+	sizeOf := func(item TrashItem) int64 {
+		t.Helper()
+		size, err := tr.SizeOf(item)
+		if err != nil {
+			t.Fatalf("SizeOf(%s) failed: %v", item.Name, err)
+		}
+		return size
+	}
+
+	photos := trashDir("my photos", 1500)
+	music := trashDir("music", 700)
+	info, err := os.Stat(photos.InfoPath)
+	if err != nil {
+		t.Fatalf("Failed to stat trashinfo: %v", err)
+	}
+	mtime := strconv.FormatInt(info.ModTime().Unix(), 10)
+	if !strings.HasPrefix(cache(), "1500 "+mtime+" my%20photos\n700 ") {
+		t.Errorf("Cache after trashing is %q, want entries for both directories", cache())
+	}
+
+	// The entry is used while it matches the trashinfo
+	if err := os.WriteFile(cachePath, []byte("99 "+mtime+" my%20photos\n"), 0600); err != nil {
+		t.Fatalf("Failed to write directory sizes: %v", err)
+	}
+	if size := sizeOf(photos); size != 99 {
+		t.Errorf("SizeOf = %d, want the cached 99", size)
+	}
+	items, err := tr.ListWithOptions(ListOptions{ComputeSizes: true})
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	for _, item := range items {
+		if want := map[string]int64{"my photos": 99, "music": 700}[item.Name]; item.Size != want {
+			t.Errorf("Size of %s = %d, want %d", item.Name, item.Size, want)
+		}
+	}
+
+	// A stale entry, for a trashinfo changed since, is ignored
+	old := info.ModTime().Add(-time.Hour)
+	if err := os.Chtimes(photos.InfoPath, old, old); err != nil {
+		t.Fatalf("Failed to age trashinfo: %v", err)
+	}
+	if size := sizeOf(photos); size != 1500 {
+		t.Errorf("SizeOf with stale cache = %d, want 1500 from a walk", size)
+	}
+
+	// So is a corrupt cache
+	if err := os.WriteFile(cachePath, []byte("garbage\n-\n\x00 x y z\n"), 0600); err != nil {
+		t.Fatalf("Failed to write directory sizes: %v", err)
+	}
+	if size := sizeOf(photos); size != 1500 {
+		t.Errorf("SizeOf with corrupt cache = %d, want 1500 from a walk", size)
+	}
+	if size := sizeOf(music); size != 700 {
+		t.Errorf("SizeOf with corrupt cache = %d, want 700 from a walk", size)
+	}
+
+	// Entries leave the cache with their items
+	if err := tr.Empty(); err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	photos = trashDir("my photos", 1500)
+	music = trashDir("music", 700)
+	trashDir("films", 300)
+	if err := tr.Restore(photos.Name); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if c := cache(); strings.Contains(c, "photos") || !strings.Contains(c, " music\n") {
+		t.Errorf("Cache after restore is %q, want only music and films", c)
+	}
+	if err := tr.Delete(music.Name); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if c := cache(); strings.Contains(c, "music") || !strings.Contains(c, " films\n") {
+		t.Errorf("Cache after delete is %q, want only films", c)
+	}
+	if err := tr.Empty(); err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Errorf("Cache left behind by Empty: %q", cache())
+	}
+}
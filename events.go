@@ -0,0 +1,85 @@
+package trash
+
+import (
+	"fmt"
+	"time"
+)
+
+// An EventOp is the kind of change an Event reports.
+type EventOp int
+
+const (
+	// EventTrash is reported when a file is moved to the trash.
+	EventTrash EventOp = iota
+	// EventRestore is reported when a trashed item is restored.
+	EventRestore
+	// EventDelete is reported when a trashed item is removed for good,
+	// by Delete or Empty.
+	EventDelete
+	// EventRemove is reported when a file is deleted for good without
+	// going to the trash, by Remove or by Trash on a disabled Trasher
+	// created WithDeleteWhenDisabled. Only the Name, OriginalPath and
+	// DeletionDate of its Item are set.
+	EventRemove
+)
+
+func (op EventOp) String() string {
+	switch op {
+	case EventTrash:
+		return "trash"
+	case EventRestore:
+		return "restore"
+	case EventDelete:
+		return "delete"
+	case EventRemove:
+		return "remove"
+	}
+	return fmt.Sprintf("EventOp(%d)", int(op))
+}
+
+// An Event describes a completed change to the trash.
+type Event struct {
+	Op   EventOp
+	Item TrashItem
+	UID  string
+	Time time.Time
+}
+
+// WithOnEvent makes the Trasher call fn after every successful trash,
+// restore, delete and remove. fn runs synchronously, so it should be quick; it may
+// be given several times to register several hooks.
+func WithOnEvent(fn func(Event)) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, fn)
+	}
+}
+
+// An AuditSink records audit messages, e.g. a *syslog.Writer.
+type AuditSink interface {
+	Info(msg string) error
+}
+
+// WithAuditSink makes the Trasher record every event to sink as a single
+// line. Errors from sink are ignored, so an unavailable audit log never
+// stops a trash operation.
+func WithAuditSink(sink AuditSink) Option {
+	return WithOnEvent(func(e Event) {
+		sink.Info(auditRecord(e))
+	})
+}
+
+func auditRecord(e Event) string {
+	return fmt.Sprintf("%s uid=%s path=%q name=%q time=%s",
+		e.Op, e.UID, e.Item.OriginalPath, e.Item.Name, e.Time.UTC().Format(time.RFC3339))
+}
+
+func (tr *Trasher) emit(op EventOp, item TrashItem) {
+	if len(tr.hooks) == 0 {
+		return
+	}
+
+	e := Event{Op: op, Item: item, UID: tr.uid, Time: time.Now()}
+	for _, fn := range tr.hooks {
+		fn(e)
+	}
+}
@@ -0,0 +1,102 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingSink keeps every audit message and then fails, like a syslog
+// daemon that stopped accepting messages.
+type recordingSink struct {
+	records []string
+}
+
+func (s *recordingSink) Info(msg string) error {
+	s.records = append(s.records, msg)
+	return errors.New("audit log unavailable")
+}
+
+func TestAuditSink(t *testing.T) {
+	sink := &recordingSink{}
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithAuditSink(sink))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "audited.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash despite a failing sink: %v", err)
+	}
+	if err := tr.Restore("audited.txt"); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file again: %v", err)
+	}
+	if err := tr.Delete("audited.txt"); err != nil {
+		t.Fatalf("Failed to delete file: %v", err)
+	}
+
+	want := []string{"trash", "restore", "trash", "delete"}
+	if len(sink.records) != len(want) {
+		t.Fatalf("Expected %d audit records, got %d: %v", len(want), len(sink.records), sink.records)
+	}
+	for i, record := range sink.records {
+		if !strings.HasPrefix(record, want[i]+" ") {
+			t.Errorf("Record %d = %q, want a %s record", i, record, want[i])
+		}
+		if !strings.Contains(record, "uid="+tr.uid) || !strings.Contains(record, `path="`+testFile+`"`) {
+			t.Errorf("Record %d = %q, want the uid and original path", i, record)
+		}
+	}
+}
+
+// recordEvents makes tr keep every event it reports in *events.
+func recordEvents(tr *Trasher, events *[]Event) {
+	tr.hooks = append(tr.hooks, func(e Event) { *events = append(*events, e) })
+}
+
+func TestRemoveEvent(t *testing.T) {
+	tr := newTestTrasher(t)
+	var events []Event
+	recordEvents(tr, &events)
+
+	testFile := filepath.Join(t.TempDir(), "removed.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Remove(testFile); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	if len(events) != 1 || events[0].Op != EventRemove || events[0].Item.OriginalPath != testFile {
+		t.Errorf("Events = %+v, want a remove of %s", events, testFile)
+	}
+}
+
+func TestTrashDisabledEvent(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithDeleteWhenDisabled())
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+	var events []Event
+	recordEvents(tr, &events)
+
+	testFile := filepath.Join(t.TempDir(), "disabled.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	tr.Disable()
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to delete while disabled: %v", err)
+	}
+	if len(events) != 1 || events[0].Op != EventRemove || events[0].Item.Name != "disabled.txt" {
+		t.Errorf("Events = %+v, want a remove of %s", events, testFile)
+	}
+}
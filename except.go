@@ -0,0 +1,90 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// TrashExcept trashes the contents of dir, leaving dir itself and every
+// entry matching one of excludes in place. Excludes are filepath.Match
+// patterns relative to dir, such as ".git" or "build/*.keep"; a directory
+// containing an excluded entry is descended into rather than trashed whole.
+// Entries that fail to trash are reported together in the returned error
+// and do not stop the rest; trashed is the number of entries moved to the
+// trash.
+func (tr *Trasher) TrashExcept(dir string, excludes []string) (trashed int, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	patterns := make([][]string, 0, len(excludes))
+	for _, exclude := range excludes {
+		exclude = filepath.Clean(exclude)
+		if _, err := filepath.Match(exclude, ""); err != nil {
+			return 0, fmt.Errorf("invalid exclude %q: %w", exclude, err)
+		}
+		patterns = append(patterns, strings.Split(filepath.ToSlash(exclude), "/"))
+	}
+
+	var errs []error
+	trashed = tr.trashExcept(dir, nil, patterns, &errs)
+	return trashed, errors.Join(errs...)
+}
+
+func (tr *Trasher) trashExcept(dir string, rel []string, patterns [][]string, errs *[]error) int {
+	entries, err := tr.fs.ReadDir(dir)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("failed to read %s: %w", dir, err))
+		return 0
+	}
+
+	trashed := 0
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		entryRel := append(rel[:len(rel):len(rel)], entry.Name())
+
+		excluded, partial := matchExcludes(entryRel, patterns)
+		switch {
+		case excluded:
+			continue
+		case partial && entry.IsDir():
+			trashed += tr.trashExcept(path, entryRel, patterns, errs)
+			continue
+		}
+
+		if err := tr.Trash(path); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		trashed++
+	}
+	return trashed
+}
+
+// matchExcludes reports whether the path given by its components matches
+// a pattern, or is a directory some pattern may match entries inside of.
+func matchExcludes(rel []string, patterns [][]string) (excluded, partial bool) {
+	for _, pattern := range patterns {
+		if len(pattern) < len(rel) {
+			continue
+		}
+		matched := true
+		for i, name := range rel {
+			if ok, _ := filepath.Match(pattern[i], name); !ok {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if len(pattern) == len(rel) {
+			return true, false
+		}
+		partial = true
+	}
+	return false, partial
+}
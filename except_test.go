@@ -0,0 +1,70 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestTrashExcept(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	project := filepath.Join(t.TempDir(), "project")
+	for _, path := range []string{
+		".git/HEAD",
+		".git/objects/ab",
+		"main.go",
+		"docs/guide.md",
+		"build/out.bin",
+		"build/cache.keep",
+	} {
+		full := filepath.Join(project, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(path), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	trashed, err := tr.TrashExcept(project, []string{".git", "build/*.keep"})
+	if err != nil {
+		t.Fatalf("TrashExcept failed: %v", err)
+	}
+	if trashed != 3 {
+		t.Errorf("Trashed %d entries, want 3", trashed)
+	}
+
+	// The directory, the excluded subdirectory and the excluded file stay
+	for _, path := range []string{".git/HEAD", ".git/objects/ab", "build/cache.keep"} {
+		if _, err := os.Stat(filepath.Join(project, path)); err != nil {
+			t.Errorf("Excluded %s was removed: %v", path, err)
+		}
+	}
+	for _, path := range []string{"main.go", "docs", "build/out.bin"} {
+		if _, err := os.Lstat(filepath.Join(project, path)); !os.IsNotExist(err) {
+			t.Errorf("%s was not trashed", path)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+	want := []string{"docs", "main.go", "out.bin"}
+	if len(names) != len(want) {
+		t.Fatalf("Trash holds %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("Trash holds %v, want %v", names, want)
+			break
+		}
+	}
+}
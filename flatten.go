@@ -0,0 +1,58 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// trashFlattened trashes every file below the directory dir separately and
+// removes the directories emptied, as described for
+// TrashOptions.FlattenDirectories.
+func (tr *Trasher) trashFlattened(ctx context.Context, dir string, opts TrashOptions, ancestors ancestors) error {
+	info, err := tr.fs.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to stat directory: %w", err)
+	}
+	leave, err := ancestors.enter(dir, info)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	entries, err := tr.fs.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	opts.OriginalPath = ""
+	opts.NameHint = ""
+
+	var errs []error
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if err := tr.trashFlattened(ctx, path, opts, ancestors); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if _, err := tr.trashPath(ctx, path, opts); err != nil && !errors.Is(err, ErrSkippedEmpty) {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// Files skipped as empty, or created meanwhile, keep it in place
+	if err := tr.fs.Remove(dir); err != nil && !opts.SkipEmpty {
+		return fmt.Errorf("failed to remove emptied directory: %w", err)
+	}
+	return nil
+}
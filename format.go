@@ -0,0 +1,80 @@
+package trash
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// dateLayout is how deletion dates are shown to users.
+	dateLayout = "2006-01-02 15:04:05"
+
+	// maxPathWidth is the widest original path FormatTable prints before
+	// truncating it from the left, since the end of a path is usually the
+	// most telling part.
+	maxPathWidth = 60
+)
+
+// String returns the item as "name  original-path  deletion-date", followed
+// by the reason it was trashed if one was recorded.
+func (item TrashItem) String() string {
+	s := fmt.Sprintf("%s  %s  %s", item.Name, item.OriginalPath, formatDate(item))
+	if item.Reason != "" {
+		s += "  " + formatReason(item.Reason)
+	}
+	return s
+}
+
+// FormatTable writes items to w as a table with aligned name, original
+// path and deletion date columns, and a reason column if any item has a
+// reason. Long paths are truncated.
+func FormatTable(w io.Writer, items []TrashItem) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	withReason := false
+	for _, item := range items {
+		if item.Reason != "" {
+			withReason = true
+			break
+		}
+	}
+
+	if withReason {
+		fmt.Fprintln(tw, "NAME\tORIGINAL PATH\tDELETED\tREASON")
+	} else {
+		fmt.Fprintln(tw, "NAME\tORIGINAL PATH\tDELETED")
+	}
+	for _, item := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s", item.Name, truncatePath(item.OriginalPath, maxPathWidth), formatDate(item))
+		if withReason {
+			fmt.Fprintf(tw, "\t%s", formatReason(item.Reason))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// formatReason puts a possibly multi-line reason on one line.
+func formatReason(reason string) string {
+	return strings.Join(strings.Fields(reason), " ")
+}
+
+func formatDate(item TrashItem) string {
+	if item.DeletionDate.IsZero() {
+		return "-"
+	}
+	return item.DeletionDate.Local().Format(dateLayout)
+}
+
+// truncatePath shortens path to at most width runes by replacing its
+// beginning with "...".
+func truncatePath(path string, width int) string {
+	runes := []rune(path)
+	if len(runes) <= width {
+		return path
+	}
+	return "..." + string(runes[len(runes)-(width-3):])
+}
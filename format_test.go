@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatTable(t *testing.T) {
+	date := time.Date(2023, time.June, 7, 8, 9, 10, 0, time.Local)
+	items := []TrashItem{
+		{Name: "a.txt", OriginalPath: "/home/user/a.txt", DeletionDate: date},
+		{Name: "a much longer name.txt", OriginalPath: "/tmp/x", DeletionDate: date},
+		{Name: "undated", OriginalPath: "/" + strings.Repeat("deep/", 20) + "file"},
+	}
+
+	var buf strings.Builder
+	if err := FormatTable(&buf, items); err != nil {
+		t.Fatalf("FormatTable failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(items)+1 {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(items)+1, len(lines), buf.String())
+	}
+
+	pathCol := strings.Index(lines[0], "ORIGINAL PATH")
+	dateCol := strings.Index(lines[0], "DELETED")
+	for i, item := range items {
+		line := lines[i+1]
+		if !strings.HasPrefix(line, item.Name+" ") {
+			t.Errorf("Line %d doesn't start with the name: %q", i+1, line)
+		}
+		if line[pathCol-1] != ' ' || line[pathCol] == ' ' {
+			t.Errorf("Path column misaligned in line %d: %q", i+1, line)
+		}
+		if line[dateCol-1] != ' ' || line[dateCol] == ' ' {
+			t.Errorf("Date column misaligned in line %d: %q", i+1, line)
+		}
+	}
+
+	if !strings.Contains(lines[1], "2023-06-07 08:09:10") {
+		t.Errorf("Deletion date not formatted: %q", lines[1])
+	}
+	if !strings.Contains(lines[3], "...") || strings.Contains(lines[3], items[2].OriginalPath) {
+		t.Errorf("Long path not truncated: %q", lines[3])
+	}
+	if !strings.HasSuffix(lines[3], "-") {
+		t.Errorf("Zero date not shown as '-': %q", lines[3])
+	}
+}
+
+func TestTrashItemString(t *testing.T) {
+	item := TrashItem{
+		Name:         "report.pdf.1",
+		OriginalPath: "/home/user/report.pdf",
+		DeletionDate: time.Date(2023, time.June, 7, 8, 9, 10, 0, time.Local),
+	}
+
+	want := "report.pdf.1  /home/user/report.pdf  2023-06-07 08:09:10"
+	if got := item.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
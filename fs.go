@@ -0,0 +1,103 @@
+package trash
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// FS is the set of filesystem operations a Trasher performs. Passing
+// another implementation to WithFS keeps the trash somewhere other than the
+// local disk, such as remote or in-memory storage; MemFS is a reference
+// implementation. Errors for missing files must satisfy os.IsNotExist.
+type FS interface {
+	Lstat(name string) (os.FileInfo, error)
+	Stat(name string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	MkdirAll(path string, perm os.FileMode) error
+	ReadDir(name string) ([]os.DirEntry, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Readlink(name string) (string, error)
+	Symlink(oldname, newname string) error
+	Chtimes(name string, atime, mtime time.Time) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+// File is an open file returned by FS.OpenFile.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+	Stat() (os.FileInfo, error)
+}
+
+// osFS implements FS with the os package.
+type osFS struct{}
+
+func (osFS) Lstat(name string) (os.FileInfo, error)       { return os.Lstat(name) }
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Readlink(name string) (string, error)         { return os.Readlink(name) }
+func (osFS) Symlink(oldname, newname string) error        { return os.Symlink(oldname, newname) }
+
+func (osFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+func (osFS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (tr *Trasher) readFile(name string) ([]byte, error) {
+	f, err := tr.fs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+func (tr *Trasher) writeFile(name string, data []byte, perm os.FileMode) error {
+	f, err := tr.fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeFileAtomic is like writeFile but writes to a temporary file beside
+// name and renames it into place, so an interrupted write never leaves name
+// truncated: it holds either its old content or the new.
+func (tr *Trasher) writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp := name + "." + randomSuffix() + ".tmp"
+	if err := tr.writeFile(tmp, data, perm); err != nil {
+		tr.fs.Remove(tmp)
+		return err
+	}
+	if err := tr.fs.Rename(tmp, name); err != nil {
+		tr.fs.Remove(tmp)
+		return err
+	}
+	return nil
+}
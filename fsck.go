@@ -0,0 +1,192 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// A TrashReport lists the inconsistencies Fsck found in a trash directory,
+// typically the halves of items left behind by a crash between writing a
+// trashinfo and moving the data.
+type TrashReport struct {
+	TrashDir string
+
+	// MissingData are the items whose trashinfo is intact but whose data
+	// is gone.
+	MissingData []TrashItem
+
+	// OrphanedFiles are the paths of files and directories in the trash
+	// that no trashinfo accounts for, such as data moved in without its
+	// trashinfo, or a temporary file of an interrupted write.
+	OrphanedFiles []string
+
+	// InvalidInfo are the paths of trashinfo files that can't be parsed,
+	// which List skips.
+	InvalidInfo []string
+}
+
+// Clean reports whether the report found nothing wrong.
+func (r TrashReport) Clean() bool {
+	return len(r.MissingData) == 0 && len(r.OrphanedFiles) == 0 && len(r.InvalidInfo) == 0
+}
+
+// Fsck checks the single trash directory trashDir, which must be the home
+// trash or a trash directory of a mounted filesystem, as used by List, and
+// reports trashinfo files without data, data without a trashinfo, and
+// trashinfo files that can't be parsed. It changes nothing; see
+// PruneOrphans.
+func (tr *Trasher) Fsck(trashDir string) (TrashReport, error) {
+	trashDir, err := tr.knownTrashDir(trashDir)
+	if err != nil {
+		return TrashReport{}, err
+	}
+
+	defer tr.lockTrashDir(trashDir)()
+	report, _, err := tr.fsck(trashDir)
+	return report, err
+}
+
+// PruneOrphans is like Fsck but also removes what it reports, recovering
+// the space taken by data no item refers to: the trashinfo of items
+// without data, orphaned files, and unparseable trashinfo files together
+// with any data under the same name. The report lists what was removed.
+// It carries on past entries that fail to be removed and returns the
+// errors joined.
+func (tr *Trasher) PruneOrphans(trashDir string) (TrashReport, error) {
+	trashDir, err := tr.knownTrashDir(trashDir)
+	if err != nil {
+		return TrashReport{}, err
+	}
+
+	defer tr.lockTrashDir(trashDir)()
+	report, invalid, err := tr.fsck(trashDir)
+	if err != nil {
+		return report, err
+	}
+
+	var errs []error
+	for _, item := range report.MissingData {
+		if err := tr.fs.Remove(item.InfoPath); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("failed to remove info file: %w", err))
+			continue
+		}
+		tr.forgetDirectorySize(item)
+		tr.emit(EventDelete, item)
+	}
+	for _, path := range report.OrphanedFiles {
+		if err := tr.fs.RemoveAll(path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove file: %w", err))
+			continue
+		}
+		tr.pruneDataDirs(TrashItem{FilePath: path, TrashDir: trashDir})
+	}
+	for _, path := range report.InvalidInfo {
+		if err := tr.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("failed to remove info file: %w", err))
+		}
+	}
+	for _, name := range invalid {
+		item := TrashItem{
+			Name:     name,
+			FilePath: tr.layout.DataPath(trashDir, name, time.Time{}),
+			TrashDir: trashDir,
+		}
+		if err := tr.fs.RemoveAll(item.FilePath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove file: %w", err))
+		}
+		tr.forgetDirectorySize(item)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// fsck is Fsck for an absolute trashDir whose lock the caller holds. It
+// also returns the entry names of the unparseable trashinfo files.
+func (tr *Trasher) fsck(trashDir string) (TrashReport, []string, error) {
+	report := TrashReport{TrashDir: trashDir}
+
+	names, err := tr.layout.Entries(trashDir, tr.fs.ReadDir)
+	if err != nil {
+		return report, nil, fmt.Errorf("failed to read info directory: %w", err)
+	}
+
+	// The paths entries account for, and the directories leading to them
+	paths := fsckPaths{owned: map[string]bool{}, parents: map[string]bool{}}
+	expect := func(path string) {
+		paths.owned[path] = true
+		for dir := filepath.Dir(path); dir != trashDir && within(dir, trashDir); dir = filepath.Dir(dir) {
+			paths.parents[dir] = true
+		}
+	}
+
+	var invalid []string
+	for _, name := range names {
+		infoPath := tr.layout.InfoPath(trashDir, name)
+		expect(infoPath)
+
+		item, err := tr.parseTrashInfo(trashDir, name)
+		if err != nil {
+			report.InvalidInfo = append(report.InvalidInfo, infoPath)
+			invalid = append(invalid, name)
+			expect(tr.layout.DataPath(trashDir, name, time.Time{}))
+			continue
+		}
+		expect(item.FilePath)
+		if _, err := tr.fs.Lstat(item.FilePath); os.IsNotExist(err) {
+			report.MissingData = append(report.MissingData, item)
+		} else if err != nil {
+			return report, nil, fmt.Errorf("failed to stat trashed file: %w", err)
+		}
+	}
+
+	for _, dir := range tr.layout.Dirs(trashDir) {
+		if err := tr.findOrphans(dir, paths, &report); err != nil {
+			return report, nil, err
+		}
+	}
+
+	sort.Slice(report.MissingData, func(i, j int) bool {
+		return report.MissingData[i].Name < report.MissingData[j].Name
+	})
+	sort.Strings(report.OrphanedFiles)
+	sort.Strings(report.InvalidInfo)
+	return report, invalid, nil
+}
+
+// fsckPaths are the paths in a trash directory that its entries account
+// for: owned ones, an entry's trashinfo or data, and the parent
+// directories a layout nests them in.
+type fsckPaths struct {
+	owned   map[string]bool
+	parents map[string]bool
+}
+
+// findOrphans adds what in dir no entry accounts for to the report's
+// orphaned files, descending into the directories leading to entries.
+func (tr *Trasher) findOrphans(dir string, paths fsckPaths, report *TrashReport) error {
+	entries, err := tr.fs.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		switch {
+		case paths.owned[path]:
+		case paths.parents[path] && entry.IsDir():
+			if err := tr.findOrphans(path, paths, report); err != nil {
+				return err
+			}
+		default:
+			report.OrphanedFiles = append(report.OrphanedFiles, path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFsck(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	var results []TrashResult
+	for _, name := range []string{"intact.txt", "lost.txt", "garbled.txt"} {
+		testFile := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		result, err := tr.TrashWithResult(testFile, TrashOptions{})
+		if err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	report, err := tr.Fsck(tr.homeTrash)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if !report.Clean() {
+		t.Fatalf("Fsck of a consistent trash = %+v, want clean", report)
+	}
+
+	// Break the trash the ways a crash or another tool would
+	if err := os.Remove(results[1].FilePath); err != nil {
+		t.Fatalf("Failed to remove trashed data: %v", err)
+	}
+	garbled := tr.layout.InfoPath(tr.homeTrash, results[2].Name)
+	if err := os.WriteFile(garbled, []byte("not a trashinfo"), 0600); err != nil {
+		t.Fatalf("Failed to garble trashinfo: %v", err)
+	}
+	orphan := filepath.Join(tr.homeTrash, "files", "orphan")
+	if err := os.MkdirAll(filepath.Join(orphan, "sub"), 0700); err != nil {
+		t.Fatalf("Failed to create orphaned data: %v", err)
+	}
+	partial := filepath.Join(tr.homeTrash, "info", ".partial.trashinfo.tmp")
+	if err := os.WriteFile(partial, []byte("[Trash Info]\n"), 0600); err != nil {
+		t.Fatalf("Failed to create partial trashinfo: %v", err)
+	}
+
+	report, err = tr.Fsck(tr.homeTrash)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if len(report.MissingData) != 1 || report.MissingData[0].Name != results[1].Name {
+		t.Errorf("MissingData = %+v, want %s", report.MissingData, results[1].Name)
+	}
+	if want := []string{orphan, partial}; !reflect.DeepEqual(report.OrphanedFiles, want) {
+		t.Errorf("OrphanedFiles = %v, want %v", report.OrphanedFiles, want)
+	}
+	if want := []string{garbled}; !reflect.DeepEqual(report.InvalidInfo, want) {
+		t.Errorf("InvalidInfo = %v, want %v", report.InvalidInfo, want)
+	}
+
+	// Fsck only reports
+	if _, err := os.Stat(orphan); err != nil {
+		t.Errorf("Fsck removed orphaned data: %v", err)
+	}
+
+	pruned, err := tr.PruneOrphans(tr.homeTrash)
+	if err != nil {
+		t.Fatalf("PruneOrphans failed: %v", err)
+	}
+	if !reflect.DeepEqual(pruned, report) {
+		t.Errorf("PruneOrphans = %+v, want %+v", pruned, report)
+	}
+	for _, path := range []string{orphan, partial, garbled, results[2].FilePath, results[1].InfoPath} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after PruneOrphans: %v", path, err)
+		}
+	}
+
+	report, err = tr.Fsck(tr.homeTrash)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if !report.Clean() {
+		t.Errorf("Fsck after PruneOrphans = %+v, want clean", report)
+	}
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != results[0].Name {
+		t.Errorf("List() = %+v, want only %s", items, results[0].Name)
+	}
+}
+
+func TestFsckDatedLayout(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithLayout(DatedLayout{}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "dated.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	orphan := filepath.Join(filepath.Dir(result.FilePath), "stray.txt")
+	if err := os.WriteFile(orphan, []byte("stray"), 0600); err != nil {
+		t.Fatalf("Failed to create orphaned data: %v", err)
+	}
+
+	report, err := tr.Fsck(tr.homeTrash)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if want := []string{orphan}; !reflect.DeepEqual(report.OrphanedFiles, want) || len(report.MissingData) != 0 {
+		t.Errorf("Fsck() = %+v, want only %s orphaned", report, orphan)
+	}
+}
+
+func TestFsckUnknownDir(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	if _, err := tr.Fsck(t.TempDir()); !errors.Is(err, ErrTrashNotFound) {
+		t.Errorf("Fsck of a non-trash directory: expected ErrTrashNotFound, got %v", err)
+	}
+}
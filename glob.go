@@ -0,0 +1,53 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
+// RestoreGlob restores every item whose original path matches pattern, as
+// for path.Match, e.g. "/home/me/art/*.psd". Like a shell glob, '*' does
+// not match across '/', and on Windows the original path is matched with
+// forward slashes. It carries on past items that fail to restore, returning
+// how many were restored along with their errors joined. A malformed
+// pattern fails with path.ErrBadPattern before anything is restored.
+func (tr *Trasher) RestoreGlob(pattern string) (int, error) {
+	return tr.eachGlob(pattern, func(item TrashItem) error {
+		return tr.restoreItem(item, RestoreOptions{})
+	})
+}
+
+// DeleteGlob permanently removes every item whose original path matches
+// pattern, as described for RestoreGlob, returning how many were removed.
+func (tr *Trasher) DeleteGlob(pattern string) (int, error) {
+	return tr.eachGlob(pattern, tr.deleteItem)
+}
+
+// eachGlob calls fn for each item whose original path matches pattern and
+// counts the calls that succeed.
+func (tr *Trasher) eachGlob(pattern string, fn func(TrashItem) error) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	var errs []error
+	for _, item := range items {
+		if ok, _ := path.Match(pattern, filepath.ToSlash(item.OriginalPath)); !ok {
+			continue
+		}
+		if err := fn(item); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.Name, err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreGlob(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+	art := filepath.Join(dir, "art")
+	if err := os.MkdirAll(filepath.Join(art, "old"), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+
+	files := []string{
+		filepath.Join(art, "cat.psd"),
+		filepath.Join(art, "dog.psd"),
+		filepath.Join(art, "notes.txt"),
+		filepath.Join(art, "old", "bird.psd"),
+		filepath.Join(dir, "other.psd"),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(file); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	if _, err := tr.RestoreGlob("["); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("RestoreGlob with bad pattern = %v, want ErrBadPattern", err)
+	}
+
+	n, err := tr.RestoreGlob(filepath.ToSlash(art) + "/*.psd")
+	if err != nil {
+		t.Fatalf("RestoreGlob failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Restored %d items, want 2", n)
+	}
+	for i, file := range files {
+		_, err := os.Stat(file)
+		if restored := err == nil; restored != (i < 2) {
+			t.Errorf("%s restored = %v, want %v", file, restored, i < 2)
+		}
+	}
+
+	// A conflict is reported without stopping the others, and '*' doesn't
+	// reach into subdirectories
+	if err := os.WriteFile(files[2], []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create conflicting file: %v", err)
+	}
+	n, err = tr.RestoreGlob(filepath.ToSlash(art) + "/*")
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("RestoreGlob = %v, want ErrAlreadyExists", err)
+	}
+	if n != 0 {
+		t.Errorf("Restored %d items, want 0", n)
+	}
+	n, err = tr.RestoreGlob(filepath.ToSlash(art) + "/*/*")
+	if err != nil || n != 1 {
+		t.Errorf("RestoreGlob = %d, %v; want 1 item restored", n, err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("Expected notes.txt and other.psd to remain, got %+v", items)
+	}
+}
+
+func TestDeleteGlob(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	for _, name := range []string{"a.log", "b.log", "keep.txt"} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(file); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	n, err := tr.DeleteGlob(filepath.ToSlash(dir) + "/*.log")
+	if err != nil {
+		t.Fatalf("DeleteGlob failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Deleted %d items, want 2", n)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "keep.txt" {
+		t.Errorf("Expected only keep.txt to remain, got %+v", items)
+	}
+}
@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// A HealthIssue is a problem with a trash directory found by Health.
+type HealthIssue struct {
+	Path    string
+	Problem string
+}
+
+func (i HealthIssue) String() string {
+	return i.Path + ": " + i.Problem
+}
+
+// Health checks that the home trash and its subdirectories are still
+// private to the user, i.e. have mode 0700, as they were created. A looser
+// mode, e.g. from a umask or another tool, lets other local users read
+// trashed files. Missing directories are not an issue, since they are
+// created as needed. Windows permissions are not checked.
+func (tr *Trasher) Health() ([]HealthIssue, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+
+	var issues []HealthIssue
+	for _, dir := range tr.privateDirs() {
+		info, err := tr.fs.Lstat(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return issues, fmt.Errorf("failed to stat trash directory: %w", err)
+		}
+
+		if perm := info.Mode().Perm(); perm != 0700 {
+			issues = append(issues, HealthIssue{
+				Path:    dir,
+				Problem: fmt.Sprintf("mode is %04o, want 0700", perm),
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// FixPermissions resets the home trash and its subdirectories to mode
+// 0700, the fix for the issues reported by Health.
+func (tr *Trasher) FixPermissions() error {
+	for _, dir := range tr.privateDirs() {
+		err := tr.fs.Chmod(dir, 0700)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to fix trash directory permissions: %w", err)
+		}
+	}
+	return nil
+}
+
+func (tr *Trasher) privateDirs() []string {
+	return append([]string{tr.homeTrash}, tr.layout.Dirs(tr.homeTrash)...)
+}
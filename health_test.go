@@ -0,0 +1,48 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Permissions are not checked on Windows")
+	}
+
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "private.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	if issues, err := tr.Health(); err != nil || len(issues) != 0 {
+		t.Fatalf("Expected a healthy trash, got %v, %v", issues, err)
+	}
+
+	filesDir := filepath.Join(tr.homeTrash, "files")
+	if err := os.Chmod(filesDir, 0755); err != nil {
+		t.Fatalf("Failed to loosen permissions: %v", err)
+	}
+
+	issues, err := tr.Health()
+	if err != nil {
+		t.Fatalf("Failed to check health: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Path != filesDir {
+		t.Fatalf("Expected one issue for %s, got %v", filesDir, issues)
+	}
+
+	if err := tr.FixPermissions(); err != nil {
+		t.Fatalf("Failed to fix permissions: %v", err)
+	}
+	if issues, err := tr.Health(); err != nil || len(issues) != 0 {
+		t.Errorf("Expected a healthy trash after the fix, got %v, %v", issues, err)
+	}
+}
@@ -0,0 +1,96 @@
+package trash
+
+import (
+	"os"
+	"sync"
+)
+
+// nameIndex maps trash names to the trash directory holding them. It is
+// only a hint: entries are checked against the filesystem before use, so a
+// stale index costs a scan, never a wrong answer.
+type nameIndex struct {
+	mu   sync.Mutex
+	dirs map[string]string
+}
+
+func (idx *nameIndex) get(trashName string) (string, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	dir, ok := idx.dirs[trashName]
+	return dir, ok
+}
+
+func (idx *nameIndex) set(trashName, trashDir string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.dirs == nil {
+		idx.dirs = make(map[string]string)
+	}
+	idx.dirs[trashName] = trashDir
+}
+
+func (idx *nameIndex) remove(trashName string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	delete(idx.dirs, trashName)
+}
+
+// reset replaces the index with the given listing.
+func (idx *nameIndex) reset(items []TrashItem) {
+	dirs := make(map[string]string, len(items))
+	for _, item := range items {
+		// The home trash is listed first and wins, as in findTrashItem
+		if _, ok := dirs[item.Name]; !ok {
+			dirs[item.Name] = item.TrashDir
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.dirs = dirs
+}
+
+// Exists reports whether trashName is in the trash and, if so, which trash
+// directory holds it. Names seen by an earlier List or lookup are checked
+// with a single stat; others fall back to searching the home trash and then
+// every mounted filesystem. An error is returned only if trashName was not
+// found and a trash directory could not be searched.
+func (tr *Trasher) Exists(trashName string) (bool, string, error) {
+	trashDir, ok, err := tr.locate(trashName)
+	return ok, trashDir, err
+}
+
+// locate returns the trash directory holding trashName.
+func (tr *Trasher) locate(trashName string) (string, bool, error) {
+	if trashDir, ok := tr.index.get(trashName); ok {
+		if found, _ := tr.hasInfo(trashDir, trashName); found {
+			return trashDir, true, nil
+		}
+		tr.index.remove(trashName)
+	}
+
+	// Check home trash first, then all mounted filesystems
+	var firstErr error
+	for _, trashDir := range append([]string{tr.homeTrash}, tr.mountTrashDirs()...) {
+		found, err := tr.hasInfo(trashDir, trashName)
+		if found {
+			tr.index.set(trashName, trashDir)
+			return trashDir, true, nil
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return "", false, firstErr
+}
+
+func (tr *Trasher) hasInfo(trashDir, trashName string) (bool, error) {
+	_, err := tr.fs.Stat(tr.layout.InfoPath(trashDir, trashName))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
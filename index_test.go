@@ -0,0 +1,63 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExists(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	mountTrash := filepath.Join(mount, ".Trash-"+tr.uid)
+
+	homeFile := filepath.Join(t.TempDir(), "home.txt")
+	mountFile := filepath.Join(mount, "mount.txt")
+	for _, path := range []string{homeFile, mountFile} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash %s: %v", path, err)
+		}
+	}
+
+	check := func(name, wantDir string) {
+		t.Helper()
+		ok, dir, err := tr.Exists(name)
+		if err != nil {
+			t.Fatalf("Exists(%q) failed: %v", name, err)
+		}
+		if !ok || dir != wantDir {
+			t.Errorf("Exists(%q) = %v, %q; want true, %q", name, ok, dir, wantDir)
+		}
+	}
+
+	// Before any listing, lookups search the trashes
+	check("home.txt", tr.homeTrash)
+	check("mount.txt", mountTrash)
+
+	// After a listing, lookups go through the index
+	if _, err := tr.List(); err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	check("home.txt", tr.homeTrash)
+	check("mount.txt", mountTrash)
+
+	if ok, _, err := tr.Exists("missing.txt"); ok || err != nil {
+		t.Errorf("Exists(missing.txt) = %v, %v; want false, nil", ok, err)
+	}
+
+	// A stale index entry must not be trusted
+	if err := tr.Restore("mount.txt"); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if ok, _, _ := tr.Exists("mount.txt"); ok {
+		t.Error("Exists reported a restored item")
+	}
+	if err := tr.Restore("home.txt"); err != nil {
+		t.Fatalf("Failed to restore through the index: %v", err)
+	}
+}
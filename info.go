@@ -0,0 +1,28 @@
+package trash
+
+import "fmt"
+
+// InfoContent returns the raw contents of item's .trashinfo file.
+func (tr *Trasher) InfoContent(item TrashItem) (string, error) {
+	content, err := tr.readFile(item.InfoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read trash info: %w", err)
+	}
+	return string(content), nil
+}
+
+// RewriteInfo re-reads item's .trashinfo file and writes it back in the
+// canonical format, with the path percent-encoded and the deletion date in
+// UTC without a zone. The original path, the instant of deletion and the
+// extension keys are kept; only their serialization changes.
+func (tr *Trasher) RewriteInfo(item TrashItem) error {
+	current, err := tr.parseTrashInfo(item.TrashDir, item.Name)
+	if err != nil {
+		return fmt.Errorf("failed to parse trash info: %w", err)
+	}
+
+	if err := tr.writeTrashInfo(current); err != nil {
+		return fmt.Errorf("failed to write trash info: %w", err)
+	}
+	return nil
+}
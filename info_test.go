@@ -0,0 +1,120 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRewriteInfo(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "two words.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	deleted := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	result, err := tr.TrashWithResult(testFile, TrashOptions{DeletionDate: deleted})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	canonical, err := tr.InfoContent(result.TrashItem)
+	if err != nil {
+		t.Fatalf("Failed to read trash info: %v", err)
+	}
+
+	// An unescaped path and a zoned date, as other implementations write
+	corrupt := "[Trash Info]\nPath=" + testFile + "\nDeletionDate=2024-03-01T14:30:00+02:00\n"
+	if err := os.WriteFile(result.InfoPath, []byte(corrupt), 0600); err != nil {
+		t.Fatalf("Failed to corrupt trash info: %v", err)
+	}
+
+	if content, err := tr.InfoContent(result.TrashItem); err != nil || content != corrupt {
+		t.Fatalf("InfoContent = %q, %v; want the corrupted content", content, err)
+	}
+
+	if err := tr.RewriteInfo(result.TrashItem); err != nil {
+		t.Fatalf("Failed to rewrite trash info: %v", err)
+	}
+
+	content, err := tr.InfoContent(result.TrashItem)
+	if err != nil {
+		t.Fatalf("Failed to read rewritten trash info: %v", err)
+	}
+	if content != canonical {
+		t.Errorf("Rewritten info = %q, want %q", content, canonical)
+	}
+}
+
+// tornFS fails trashinfo writes half way through, as a crash or a full
+// disk would.
+type tornFS struct {
+	osFS
+}
+
+type tornFile struct {
+	File
+}
+
+func (f tornFile) Write(p []byte) (int, error) {
+	n, _ := f.File.Write(p[:len(p)/2])
+	return n, errors.New("interrupted")
+}
+
+func (fs tornFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := fs.osFS.OpenFile(name, flag, perm)
+	if err != nil || flag&os.O_WRONLY == 0 || !strings.Contains(name, ".trashinfo") {
+		return f, err
+	}
+	return tornFile{f}, nil
+}
+
+func TestRewriteInfoInterrupted(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "keep.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	items, err := tr.List()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d (%v)", len(items), err)
+	}
+	before, err := os.ReadFile(items[0].InfoPath)
+	if err != nil {
+		t.Fatalf("Failed to read trash info: %v", err)
+	}
+
+	tr.fs = tornFS{}
+	if err := tr.RewriteInfo(items[0]); err == nil {
+		t.Fatal("Expected the interrupted rewrite to fail")
+	}
+
+	after, err := os.ReadFile(items[0].InfoPath)
+	if err != nil {
+		t.Fatalf("Failed to read trash info: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("Interrupted rewrite changed the trash info from %q to %q", before, after)
+	}
+	entries, err := os.ReadDir(filepath.Join(tr.homeTrash, "info"))
+	if err != nil {
+		t.Fatalf("Failed to read info directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Interrupted rewrite left %d entries in info/, want 1", len(entries))
+	}
+
+	tr.fs = osFS{}
+	if _, err := tr.parseTrashInfo(items[0].TrashDir, items[0].Name); err != nil {
+		t.Errorf("Trash info no longer parses: %v", err)
+	}
+}
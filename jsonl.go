@@ -0,0 +1,86 @@
+package trash
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Statuses of a JSON-lines progress record.
+const (
+	statusStarted  = "started"
+	statusProgress = "progress"
+	statusDone     = "done"
+	statusFailed   = "failed"
+)
+
+// progressRecord is one line written by WithJSONLines.
+type progressRecord struct {
+	Op     string `json:"op"`
+	Path   string `json:"path,omitempty"`
+	Bytes  int64  `json:"bytes"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// jsonlStream writes progress records to a writer, one per line.
+type jsonlStream struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithJSONLines makes the Trasher write its progress to w as
+// newline-delimited JSON, for consumers in another process such as a UI
+// reading from a pipe. Each line is an object with "op" (trash, restore,
+// delete or empty), "path", "bytes" and "status" (started, progress, done
+// or failed, with "error" on failure). Trash and restore report started
+// and then done or failed; a trash copied across filesystems also reports
+// progress after each file, with bytes the total copied so far. Empty
+// reports a done delete for every item it removes. If w has a Flush
+// method, as a *bufio.Writer does, it is called after every line so the
+// reader sees progress promptly. Write errors are ignored.
+func WithJSONLines(w io.Writer) Option {
+	return func(o *options) {
+		o.jsonl = &jsonlStream{w: w}
+	}
+}
+
+func (s *jsonlStream) write(rec progressRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(line); err != nil {
+		return
+	}
+	if f, ok := s.w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+}
+
+// progress writes a progress record, if the Trasher has a JSON-lines
+// stream.
+func (tr *Trasher) progress(op, path string, bytes int64, status string) {
+	if tr.jsonl == nil {
+		return
+	}
+	tr.jsonl.write(progressRecord{Op: op, Path: path, Bytes: bytes, Status: status})
+}
+
+// progressEnd writes the done or failed record ending op, depending on
+// err.
+func (tr *Trasher) progressEnd(op, path string, bytes int64, err error) {
+	if tr.jsonl == nil {
+		return
+	}
+	rec := progressRecord{Op: op, Path: path, Bytes: bytes, Status: statusDone}
+	if err != nil {
+		rec.Status = statusFailed
+		rec.Error = err.Error()
+	}
+	tr.jsonl.write(rec)
+}
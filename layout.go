@@ -0,0 +1,105 @@
+package trash
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A Layout decides where the data and trashinfo of each entry live within a
+// trash directory. The default, XDGLayout, is the one from the
+// specification; others let a Trasher interoperate with trashes that
+// arrange their files differently.
+type Layout interface {
+	// InfoPath returns the path of the trashinfo file of the entry name.
+	InfoPath(trashDir, name string) string
+
+	// DataPath returns the path of the data of the entry name, which was
+	// deleted at the given time.
+	DataPath(trashDir, name string, deleted time.Time) string
+
+	// Entries returns the names of the entries in trashDir that have a
+	// trashinfo file, reading directories with readDir.
+	Entries(trashDir string, readDir func(string) ([]fs.DirEntry, error)) ([]string, error)
+
+	// Dirs returns the directories a trash needs, which are created on
+	// first use and cleared out by Empty.
+	Dirs(trashDir string) []string
+}
+
+// XDGLayout is the layout defined by the FreeDesktop.org specification:
+// data in files/ and a name.trashinfo per entry in info/.
+type XDGLayout struct{}
+
+func (XDGLayout) InfoPath(trashDir, name string) string {
+	return filepath.Join(trashDir, "info", name+".trashinfo")
+}
+
+func (XDGLayout) DataPath(trashDir, name string, deleted time.Time) string {
+	return filepath.Join(trashDir, "files", name)
+}
+
+func (XDGLayout) Entries(trashDir string, readDir func(string) ([]fs.DirEntry, error)) ([]string, error) {
+	return trashInfoNames(filepath.Join(trashDir, "info"), readDir)
+}
+
+func (XDGLayout) Dirs(trashDir string) []string {
+	return []string{
+		filepath.Join(trashDir, "files"),
+		filepath.Join(trashDir, "info"),
+	}
+}
+
+// DatedLayout is XDGLayout with the data of each entry nested in
+// files/YYYY/MM/DD/ by UTC deletion date, the one its trashinfo records, for
+// very large trashes where a single flat files/ directory becomes slow to
+// read. Trashinfo files stay
+// flat in info/, as in XDGLayout. Entries without a deletion date keep
+// their data directly in files/.
+type DatedLayout struct{}
+
+func (DatedLayout) InfoPath(trashDir, name string) string {
+	return XDGLayout{}.InfoPath(trashDir, name)
+}
+
+func (DatedLayout) DataPath(trashDir, name string, deleted time.Time) string {
+	if deleted.IsZero() {
+		return XDGLayout{}.DataPath(trashDir, name, deleted)
+	}
+	deleted = deleted.UTC()
+	return filepath.Join(trashDir, "files", deleted.Format("2006"), deleted.Format("01"), deleted.Format("02"), name)
+}
+
+func (DatedLayout) Entries(trashDir string, readDir func(string) ([]fs.DirEntry, error)) ([]string, error) {
+	return XDGLayout{}.Entries(trashDir, readDir)
+}
+
+func (DatedLayout) Dirs(trashDir string) []string {
+	return XDGLayout{}.Dirs(trashDir)
+}
+
+// trashInfoNames returns the entry names of the .trashinfo files in dir,
+// which may not exist.
+func trashInfoNames(dir string, readDir func(string) ([]fs.DirEntry, error)) ([]string, error) {
+	entries, err := readDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if name, ok := strings.CutSuffix(entry.Name(), ".trashinfo"); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
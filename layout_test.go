@@ -0,0 +1,175 @@
+package trash
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// nestedLayout keeps each entry's data and trashinfo side by side in the
+// trash root, as some KDE and Android trashes do.
+type nestedLayout struct{}
+
+func (nestedLayout) InfoPath(trashDir, name string) string {
+	return filepath.Join(trashDir, name+".trashinfo")
+}
+
+func (nestedLayout) DataPath(trashDir, name string, deleted time.Time) string {
+	return filepath.Join(trashDir, name)
+}
+
+func (nestedLayout) Entries(trashDir string, readDir func(string) ([]fs.DirEntry, error)) ([]string, error) {
+	return trashInfoNames(trashDir, readDir)
+}
+
+func (nestedLayout) Dirs(trashDir string) []string {
+	return []string{trashDir}
+}
+
+func TestAlternateLayout(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithLayout(nestedLayout{}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "nested.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	for _, path := range []string{"nested.txt", "nested.txt.trashinfo"} {
+		if _, err := os.Stat(filepath.Join(tr.homeTrash, path)); err != nil {
+			t.Errorf("Expected %s in the trash root: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tr.homeTrash, "files")); !os.IsNotExist(err) {
+		t.Error("XDG files/ directory created for a nested layout")
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != testFile {
+		t.Fatalf("Items = %+v, want only %s", items, testFile)
+	}
+	if want := filepath.Join(tr.homeTrash, "nested.txt"); items[0].FilePath != want {
+		t.Errorf("FilePath = %s, want %s", items[0].FilePath, want)
+	}
+
+	if err := tr.Restore(items[0].Name); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Restored file missing: %v", err)
+	}
+}
+
+func TestDatedLayout(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithLayout(DatedLayout{}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	dates := map[string]time.Time{
+		"old.txt": time.Date(2023, time.March, 5, 14, 0, 0, 0, time.UTC),
+		"new.txt": time.Date(2024, time.November, 21, 9, 30, 0, 0, time.UTC),
+	}
+	dir := t.TempDir()
+	for name, date := range dates {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.TrashAt(path, date); err != nil {
+			t.Fatalf("Failed to trash %s: %v", name, err)
+		}
+	}
+
+	want := map[string]string{
+		"old.txt": filepath.Join(tr.homeTrash, "files", "2023", "03", "05", "old.txt"),
+		"new.txt": filepath.Join(tr.homeTrash, "files", "2024", "11", "21", "new.txt"),
+	}
+	for _, path := range want {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected data at %s: %v", path, err)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	for _, item := range items {
+		if item.FilePath != want[item.Name] {
+			t.Errorf("FilePath of %s = %s, want %s", item.Name, item.FilePath, want[item.Name])
+		}
+	}
+
+	if err := tr.Restore("old.txt"); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); err != nil {
+		t.Errorf("Restored file missing: %v", err)
+	}
+
+	// The emptied date directories go with it
+	if _, err := os.Stat(filepath.Join(tr.homeTrash, "files", "2023")); !os.IsNotExist(err) {
+		t.Error("Empty date directories left behind after restore")
+	}
+
+	if err := tr.Delete("new.txt"); err != nil {
+		t.Fatalf("Failed to delete item: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(tr.homeTrash, "files"))
+	if err != nil {
+		t.Fatalf("Failed to read files directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("files/ holds %d entries after deleting everything, want none", len(entries))
+	}
+}
+
+func TestDatedLayoutTimeZone(t *testing.T) {
+	// Far east of UTC, late evening UTC is already the next local day
+	local := time.Local
+	time.Local = time.FixedZone("UTC+14", 14*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithLayout(DatedLayout{}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "late.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	deleted := time.Date(2024, time.January, 1, 23, 30, 0, 0, time.UTC).Local()
+	if err := tr.TrashAt(testFile, deleted); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	items, err := tr.List()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("List = %+v, %v; want the trashed file", items, err)
+	}
+	if want := filepath.Join(tr.homeTrash, "files", "2024", "01", "01", "late.txt"); items[0].FilePath != want {
+		t.Errorf("FilePath = %s, want %s", items[0].FilePath, want)
+	}
+	if err := tr.Restore(items[0].Name); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Restored file missing: %v", err)
+	}
+}
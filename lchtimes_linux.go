@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package trash
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// utimensat(2) arguments, which the syscall package doesn't export.
+const (
+	atFDCWD           = -0x64
+	atSymlinkNofollow = 0x100
+)
+
+// lchtimes sets the access and modification times of the symlink path
+// itself, rather than of its target, to the modification time in info,
+// that of the link it copies. Kernels without utimensat(2) leave the
+// link's times as they are, as do links not copied from the local
+// filesystem.
+func lchtimes(path string, info os.FileInfo) error {
+	if _, ok := info.Sys().(*syscall.Stat_t); !ok {
+		return nil
+	}
+
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	ts := [2]syscall.Timespec{
+		syscall.NsecToTimespec(info.ModTime().UnixNano()),
+		syscall.NsecToTimespec(info.ModTime().UnixNano()),
+	}
+
+	dirfd := atFDCWD
+	_, _, e := syscall.Syscall6(syscall.SYS_UTIMENSAT, uintptr(dirfd),
+		uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&ts)), atSymlinkNofollow, 0, 0)
+	if e == syscall.ENOSYS {
+		return nil
+	}
+	if e != 0 {
+		return &os.PathError{Op: "utimensat", Path: path, Err: e}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrashSymlinkTimes(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	if err := os.WriteFile(target, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	targetInfo, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("Failed to stat target: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("Failed to stat symlink: %v", err)
+	}
+	old := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := lchtimes(link, modTimeInfo{info, old}); err != nil {
+		t.Fatalf("Failed to set symlink times: %v", err)
+	}
+
+	result, err := tr.TrashWithResult(link, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash symlink: %v", err)
+	}
+	check := func(path, when string) {
+		t.Helper()
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("%s: failed to stat symlink: %v", when, err)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			t.Fatalf("%s: %s is no longer a symlink", when, path)
+		}
+		if !info.ModTime().Equal(old) {
+			t.Errorf("%s: symlink mtime = %v, want %v", when, info.ModTime(), old)
+		}
+	}
+	check(result.FilePath, "trashed")
+
+	if err := tr.Restore(result.Name); err != nil {
+		t.Fatalf("Failed to restore symlink: %v", err)
+	}
+	check(link, "restored")
+
+	// The target's own times are untouched
+	if info, err := os.Stat(target); err != nil || !info.ModTime().Equal(targetInfo.ModTime()) {
+		t.Errorf("Target mtime changed: %v", err)
+	}
+}
+
+// modTimeInfo is an os.FileInfo with a substituted modification time.
+type modTimeInfo struct {
+	os.FileInfo
+	mtime time.Time
+}
+
+func (i modTimeInfo) ModTime() time.Time { return i.mtime }
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package trash
+
+import "os"
+
+// lchtimes does nothing, leaving a copied symlink with the time it was
+// created at; the syscall package can only set a link's own times on
+// Linux.
+func lchtimes(path string, info os.FileInfo) error {
+	return nil
+}
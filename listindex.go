@@ -0,0 +1,83 @@
+package trash
+
+import (
+	"bytes"
+	"encoding/gob"
+	"path/filepath"
+	"time"
+)
+
+// listIndexFile is the name of the index WithListIndex keeps in the root of
+// each trash directory. It holds the items as last listed together with the
+// modification time info/ had then. Trashing, restoring and deleting all
+// add or remove a trashinfo file, which updates that time, so the index is
+// only used while it still matches and is otherwise rebuilt by the next
+// List. A trashinfo edited in place, rather than replaced, goes unnoticed
+// until then.
+const listIndexFile = ".index"
+
+// listIndexVersion is bumped whenever the encoding of listIndex changes, so
+// an index written by another version is rebuilt rather than misread.
+const listIndexVersion = 2
+
+// racyWindow is how recently info/ may have changed for its modification
+// time not to be trusted. A change within the same tick of the
+// filesystem's clock, as coarse as two seconds on FAT, would leave the time
+// as it was.
+const racyWindow = 2 * time.Second
+
+type listIndex struct {
+	Version   int
+	InfoMtime int64
+
+	// Items as recorded in the trashinfo; the paths derived from the trash
+	// directory are filled in on reading, in case it has been mounted
+	// elsewhere since
+	Items []TrashItem
+}
+
+// listIndexed returns the items of trashDir from its index if it is still
+// up to date, and otherwise scans the directory and rewrites the index.
+func (tr *Trasher) listIndexed(trashDir string) ([]TrashItem, error) {
+	infoDir := filepath.Dir(tr.layout.InfoPath(trashDir, "name"))
+	info, err := tr.fs.Stat(infoDir)
+	if err != nil {
+		return tr.scanTrashDir(trashDir)
+	}
+	mtime := info.ModTime()
+
+	indexPath := filepath.Join(trashDir, listIndexFile)
+	if data, err := tr.readFile(indexPath); err == nil {
+		var index listIndex
+		if gob.NewDecoder(bytes.NewReader(data)).Decode(&index) == nil &&
+			index.Version == listIndexVersion && index.InfoMtime == mtime.UnixNano() {
+			items := index.Items
+			for i := range items {
+				items[i].InfoPath = tr.layout.InfoPath(trashDir, items[i].Name)
+				items[i].FilePath = tr.layout.DataPath(trashDir, items[i].Name, items[i].DeletionDate)
+				items[i].TrashDir = trashDir
+			}
+			if items == nil {
+				items = []TrashItem{}
+			}
+			return items, nil
+		}
+	}
+
+	items, err := tr.scanTrashDir(trashDir)
+	if err != nil || time.Since(mtime) < racyWindow {
+		return items, err
+	}
+
+	index := listIndex{Version: listIndexVersion, InfoMtime: mtime.UnixNano()}
+	for _, item := range items {
+		item.InfoPath, item.FilePath, item.TrashDir = "", "", ""
+		index.Items = append(index.Items, item)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(index); err == nil {
+		// Only a cache; the next List rescans if it can't be written
+		tr.writeFileAtomic(indexPath, buf.Bytes(), 0600)
+	}
+	return items, nil
+}
@@ -0,0 +1,123 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListIndex(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.listIndex = true
+	dir := t.TempDir()
+
+	trash := func(name string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+	// Ages info/ past racyWindow, as if the trash was last changed a while
+	// ago
+	age := func() {
+		t.Helper()
+		old := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(filepath.Join(tr.homeTrash, "info"), old, old); err != nil {
+			t.Fatalf("Failed to age info directory: %v", err)
+		}
+	}
+	list := func() []string {
+		t.Helper()
+		items, err := tr.List()
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		var paths []string
+		for _, item := range items {
+			if _, err := os.Stat(item.FilePath); err != nil {
+				t.Errorf("Item %s has no data at %s: %v", item.Name, item.FilePath, err)
+			}
+			paths = append(paths, filepath.Base(item.OriginalPath))
+		}
+		sort.Strings(paths)
+		return paths
+	}
+	// Edits a trashinfo in place, which leaves the mtime of info/ alone, to
+	// tell a listing from the index from a rescan
+	tamper := func(name string) {
+		t.Helper()
+		info := filepath.Join(tr.homeTrash, "info", name+".trashinfo")
+		content, err := os.ReadFile(info)
+		if err != nil {
+			t.Fatalf("Failed to read trashinfo: %v", err)
+		}
+		content = []byte(strings.Replace(string(content), name, "tampered", 1))
+		f, err := os.OpenFile(info, os.O_WRONLY|os.O_TRUNC, 0)
+		if err != nil {
+			t.Fatalf("Failed to open trashinfo: %v", err)
+		}
+		defer f.Close()
+		if _, err := f.Write(content); err != nil {
+			t.Fatalf("Failed to rewrite trashinfo: %v", err)
+		}
+	}
+	indexPath := filepath.Join(tr.homeTrash, listIndexFile)
+
+	trash("a.txt")
+	trash("b.txt")
+
+	// Miss while info/ only just changed: scanned, but not indexed yet
+	if got := list(); strings.Join(got, ",") != "a.txt,b.txt" {
+		t.Fatalf("List = %v, want a.txt and b.txt", got)
+	}
+	if _, err := os.Stat(indexPath); !os.IsNotExist(err) {
+		t.Errorf("Index written for a racy info/: %v", err)
+	}
+
+	// Miss once settled: scanned and indexed
+	age()
+	if got := list(); strings.Join(got, ",") != "a.txt,b.txt" {
+		t.Fatalf("List = %v, want a.txt and b.txt", got)
+	}
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatalf("Index not written: %v", err)
+	}
+
+	// Hit: the in-place edit isn't seen
+	tamper("a.txt")
+	if got := list(); strings.Join(got, ",") != "a.txt,b.txt" {
+		t.Errorf("List = %v, want the indexed a.txt and b.txt", got)
+	}
+
+	// Stale: a new item changes info/, forcing a rescan
+	trash("c.txt")
+	if got := list(); strings.Join(got, ",") != "b.txt,c.txt,tampered" {
+		t.Errorf("List = %v, want a rescan", got)
+	}
+
+	// So does deleting one
+	age()
+	list()
+	if err := tr.Delete("b.txt"); err != nil {
+		t.Fatalf("Failed to delete item: %v", err)
+	}
+	if got := list(); strings.Join(got, ",") != "c.txt,tampered" {
+		t.Errorf("List = %v, want a rescan", got)
+	}
+
+	// And a corrupt index is rebuilt
+	age()
+	if err := os.WriteFile(indexPath, []byte("garbage"), 0600); err != nil {
+		t.Fatalf("Failed to corrupt index: %v", err)
+	}
+	if got := list(); strings.Join(got, ",") != "c.txt,tampered" {
+		t.Errorf("List = %v, want a rescan", got)
+	}
+}
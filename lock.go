@@ -0,0 +1,63 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// lockFileName is the advisory lock file kept at the top of each trash
+// directory, next to files/ and info/.
+//
+// Other tools sharing a trash can coordinate with this package by holding
+// an exclusive flock(2) on $trash/.lock while they pick a name, write a
+// trashinfo or move data in or out of the trash. This package holds it
+// from picking a trash name until the entry's trashinfo and data are both
+// in place, except that a copy from another device releases it once the
+// data's top-level file or directory exists. The file's content is unused;
+// it is created empty if missing and never removed. Holders should keep
+// the lock only for the duration of one such change.
+const lockFileName = ".lock"
+
+var errLockUnsupported = errors.New("file locking not supported")
+
+// lockTrashDir takes the advisory lock of trashDir, blocking until no other
+// process holds it, and returns the function that releases it. Where
+// locking isn't possible, e.g. on a filesystem without flock support, the
+// operation goes ahead unlocked and a warning goes to the Trasher's
+// logger; filesystems other than the operating system's, and platforms
+// without flock, are never locked.
+func (tr *Trasher) lockTrashDir(trashDir string) (unlock func()) {
+	// Windows keeps the Recycle Bin consistent itself
+	if tr.isRecycleBinDir(trashDir) {
+		return func() {}
+	}
+
+	lockPath := filepath.Join(trashDir, lockFileName)
+	f, err := tr.fs.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		// A trash directory that doesn't exist has nothing to protect
+		if !os.IsNotExist(err) {
+			tr.logger.Warn("trash: proceeding without lock", "path", lockPath, "err", err)
+		}
+		return func() {}
+	}
+
+	fd, ok := f.(interface{ Fd() uintptr })
+	if !ok {
+		// Nothing outside this process can see the file
+		f.Close()
+		return func() {}
+	}
+
+	if err := flock(fd.Fd()); err != nil {
+		if err != errLockUnsupported {
+			tr.logger.Warn("trash: proceeding without lock", "path", lockPath, "err", err)
+		}
+		f.Close()
+		return func() {}
+	}
+
+	// Closing the file releases the lock
+	return func() { f.Close() }
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package trash
+
+// flock is not implemented on this platform, so trash directories are used
+// unlocked.
+func flock(fd uintptr) error {
+	return errLockUnsupported
+}
@@ -0,0 +1,61 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestTrashDirLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("trash directories are not locked on Windows")
+	}
+
+	home := t.TempDir()
+	first := newTestTrasherAt(t, home)
+	second := newTestTrasherAt(t, home)
+
+	testFile := filepath.Join(t.TempDir(), "locked.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := first.ensureTrashDirs(first.homeTrash); err != nil {
+		t.Fatalf("Failed to create trash: %v", err)
+	}
+
+	// While one instance holds the lock, the other's trash must wait
+	unlock := first.lockTrashDir(first.homeTrash)
+	done := make(chan error, 1)
+	go func() {
+		done <- second.Trash(testFile)
+	}()
+
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("Trash finished while the trash was locked: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("File moved while the trash was locked: %v", err)
+	}
+
+	unlock()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Trash still blocked after the lock was released")
+	}
+
+	if _, err := os.Stat(filepath.Join(first.homeTrash, lockFileName)); err != nil {
+		t.Errorf("Lock file missing: %v", err)
+	}
+	if items, _ := first.List(); len(items) != 1 {
+		t.Errorf("Expected 1 item, got %d", len(items))
+	}
+}
@@ -0,0 +1,61 @@
+package trash
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// macVolumeTrashName is the directory at the root of each macOS volume
+// that holds a Trash per user, named after the uid.
+const macVolumeTrashName = ".Trashes"
+
+// macTrashDir returns the macOS Trash that absPath is moved to: the
+// user's ~/.Trash for the volume holding the home directory, and
+// $volume/.Trashes/$uid for any other.
+func (tr *Trasher) macTrashDir(absPath string) string {
+	mount, err := tr.mountPoint(absPath)
+	if err != nil {
+		return tr.macTrash
+	}
+	homeMount, err := tr.mountPoint(filepath.Dir(tr.macTrash))
+	if err != nil || mount == homeMount {
+		return tr.macTrash
+	}
+	return filepath.Join(mount, macVolumeTrashName, tr.uid)
+}
+
+// trashToMacTrash moves absPath to the macOS Trash, where Finder shows it.
+// ok is false if macOS turned the file away and left it in place, e.g. on
+// a volume without a Trash; the caller then falls back to the XDG trash.
+// Moving within a volume is a single call that can't be interrupted, so
+// ctx is only checked before it, as for the XDG trash.
+//
+// macOS keeps where each item came from in its own records rather than in
+// a trashinfo file, so the item has no InfoPath and isn't listed or
+// restored by this package, only by Finder; TrashOptions that shape the
+// trashinfo don't apply.
+func (tr *Trasher) trashToMacTrash(absPath string) (result TrashResult, ok bool, err error) {
+	deleted := time.Now()
+	trashedPath, err := tr.macTrashItem(absPath)
+	if err != nil {
+		if _, statErr := tr.fs.Lstat(absPath); statErr == nil {
+			tr.logger.Warn("trash: macOS Trash refused the file, using the XDG trash", "path", absPath, "err", err)
+			return TrashResult{}, false, nil
+		}
+		return TrashResult{}, true, fmt.Errorf("failed to move to the Trash: %w", err)
+	}
+	if trashedPath == "" {
+		trashedPath = filepath.Join(tr.macTrashDir(absPath), filepath.Base(absPath))
+	}
+
+	item := TrashItem{
+		Name:         filepath.Base(trashedPath),
+		OriginalPath: absPath,
+		DeletionDate: deleted,
+		FilePath:     trashedPath,
+		TrashDir:     filepath.Dir(trashedPath),
+	}
+	tr.emit(EventTrash, item)
+	return TrashResult{TrashItem: item}, true, nil
+}
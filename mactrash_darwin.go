@@ -0,0 +1,61 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package trash
+
+/*
+#cgo CFLAGS: -x objective-c -fobjc-arc
+#cgo LDFLAGS: -framework Foundation
+#import <Foundation/Foundation.h>
+#include <stdlib.h>
+#include <string.h>
+
+// trashItem moves path to the Trash and sets *trashed to its path there, or
+// *message to why it couldn't. The caller frees whichever is set.
+static int trashItem(const char *path, char **trashed, char **message) {
+	@autoreleasepool {
+		NSFileManager *fm = [NSFileManager defaultManager];
+		NSString *p = [fm stringWithFileSystemRepresentation:path length:strlen(path)];
+		NSURL *result = nil;
+		NSError *error = nil;
+		if (![fm trashItemAtURL:[NSURL fileURLWithPath:p] resultingItemURL:&result error:&error]) {
+			*message = strdup(error != nil ? error.localizedDescription.UTF8String : "unknown error");
+			return -1;
+		}
+		if (result != nil) {
+			*trashed = strdup(result.path.fileSystemRepresentation);
+		}
+		return 0;
+	}
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// macTrashAvailable reports true: NSFileManager is linked in.
+func macTrashAvailable() bool {
+	return true
+}
+
+// macTrashItem moves path to the Trash of its volume with NSFileManager's
+// trashItemAtURL, as Finder does, and returns its path in the Trash, or ""
+// if macOS didn't say.
+func macTrashItem(path string) (string, error) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+
+	var trashed, message *C.char
+	if C.trashItem(cpath, &trashed, &message) != 0 {
+		defer C.free(unsafe.Pointer(message))
+		return "", errors.New(C.GoString(message))
+	}
+	if trashed == nil {
+		return "", nil
+	}
+	defer C.free(unsafe.Pointer(trashed))
+	return C.GoString(trashed), nil
+}
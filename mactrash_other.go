@@ -0,0 +1,18 @@
+//go:build !darwin || !cgo
+// +build !darwin !cgo
+
+package trash
+
+import "errors"
+
+var errNoMacTrash = errors.New("the macOS Trash needs macOS and cgo")
+
+// macTrashAvailable reports false: without cgo there is no NSFileManager to
+// call.
+func macTrashAvailable() bool {
+	return false
+}
+
+func macTrashItem(path string) (string, error) {
+	return "", errNoMacTrash
+}
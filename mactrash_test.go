@@ -0,0 +1,136 @@
+package trash
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMacTrashDir(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.uid = "501"
+	tr.macTrash = "/Users/me/.Trash"
+	fakeMount(tr, "/Volumes/External")
+
+	for path, want := range map[string]string{
+		"/Users/me/Documents/report.txt":   "/Users/me/.Trash",
+		"/private/tmp/scratch":             "/Users/me/.Trash",
+		"/Volumes/External/photos/cat.jpg": "/Volumes/External/.Trashes/501",
+	} {
+		if got := tr.macTrashDir(path); got != want {
+			t.Errorf("macTrashDir(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+// fakeMacTrash makes tr trash to a macOS Trash in a temporary directory,
+// moving files there as NSFileManager would.
+func fakeMacTrash(t *testing.T, tr *Trasher) {
+	t.Helper()
+	tr.macTrash = filepath.Join(t.TempDir(), ".Trash")
+	if err := os.Mkdir(tr.macTrash, 0700); err != nil {
+		t.Fatalf("Failed to create macOS Trash: %v", err)
+	}
+	tr.macTrashItem = func(path string) (string, error) {
+		trashed := filepath.Join(tr.macTrash, filepath.Base(path))
+		return trashed, os.Rename(path, trashed)
+	}
+}
+
+func TestMacTrashOptIn(t *testing.T) {
+	tr, err := New()
+	if err != nil {
+		t.Fatalf("Failed to create Trasher: %v", err)
+	}
+	if tr.macTrash != "" {
+		t.Errorf("Default Trasher uses the macOS Trash %s", tr.macTrash)
+	}
+
+	tr, err = New(WithMacTrash())
+	if !macTrashAvailable() {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("New(WithMacTrash()) without the macOS Trash = %v, want errors.ErrUnsupported", err)
+		}
+	} else if err != nil || tr.macTrash == "" {
+		t.Errorf("New(WithMacTrash()) = %v; want a Trasher using the macOS Trash", err)
+	}
+
+	// The macOS Trash can't be confined to a trash root
+	if _, err := New(WithMacTrash(), WithTrashRoot(t.TempDir())); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("New(WithMacTrash(), WithTrashRoot()) = %v, want errors.ErrUnsupported", err)
+	}
+}
+
+func TestTrashToMacTrash(t *testing.T) {
+	tr := newTestTrasher(t)
+	fakeMacTrash(t, tr)
+
+	testFile := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.TrashDir != tr.macTrash || result.OriginalPath != testFile || result.InfoPath != "" {
+		t.Errorf("Trashed item = %+v, want it in %s", result.TrashItem, tr.macTrash)
+	}
+	if content, err := os.ReadFile(result.FilePath); err != nil || string(content) != "content" {
+		t.Errorf("Trashed data = %q, %v; want the original content", content, err)
+	}
+
+	// Options that need a trashinfo are refused up front
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, opts := range []TrashOptions{{Reason: "cleanup"}, {NameHint: "draft"}, {DeletionDate: time.Now()}} {
+		if _, err := tr.TrashWithResult(testFile, opts); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Trash with %+v = %v, want errors.ErrUnsupported", opts, err)
+		}
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Refused file was moved: %v", err)
+	}
+
+	// A batch needs a trashinfo for its ID
+	batch := tr.BeginBatch()
+	batch.Trash(testFile)
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Failed to commit batch: %v", err)
+	}
+	items, err := tr.listTrashDir(tr.homeTrash)
+	if err != nil || len(items) != 1 || items[0].BatchID != batch.ID() {
+		t.Errorf("XDG trash holds %+v, %v; want the batch's file", items, err)
+	}
+}
+
+func TestMacTrashRefused(t *testing.T) {
+	var buf bytes.Buffer
+	tr := newTestTrasher(t)
+	tr.logger = slog.New(slog.NewTextHandler(&buf, nil))
+	fakeMacTrash(t, tr)
+	tr.macTrashItem = func(path string) (string, error) {
+		return "", errors.New("the volume has no Trash")
+	}
+
+	testFile := filepath.Join(t.TempDir(), "fallback.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.TrashDir != tr.homeTrash {
+		t.Errorf("Trashed to %s, want the XDG trash %s", result.TrashDir, tr.homeTrash)
+	}
+	if !strings.Contains(buf.String(), "using the XDG trash") {
+		t.Errorf("Logger got %q, want a warning about the fallback", buf.String())
+	}
+}
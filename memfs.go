@@ -0,0 +1,428 @@
+package trash
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errNotDir   = errors.New("not a directory")
+	errIsDir    = errors.New("is a directory")
+	errNotEmpty = errors.New("directory not empty")
+)
+
+// maxSymlinkHops bounds symlink resolution in MemFS, as ELOOP does on disk.
+const maxSymlinkHops = 40
+
+// MemFS is an in-memory FS, safe for concurrent use. It is a reference for
+// implementing FS on other storage and a convenient backend for tests.
+// Symlinks are stored and followed by Stat and OpenFile, but only as the
+// last element of a path.
+type MemFS struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	mode    os.FileMode
+	data    []byte
+	target  string
+	modTime time.Time
+}
+
+// NewMemFS returns an empty MemFS holding only the root directory.
+func NewMemFS() *MemFS {
+	return &MemFS{nodes: make(map[string]*memNode)}
+}
+
+func isRoot(name string) bool {
+	return filepath.Dir(name) == name
+}
+
+// lookup returns the node at the cleaned path name. The root always exists.
+func (m *MemFS) lookup(name string) (*memNode, bool) {
+	if isRoot(name) {
+		return &memNode{mode: os.ModeDir | 0755}, true
+	}
+	n, ok := m.nodes[name]
+	return n, ok
+}
+
+// resolve follows symlinks at the end of name.
+func (m *MemFS) resolve(op, name string) (string, *memNode, error) {
+	for i := 0; i < maxSymlinkHops; i++ {
+		n, ok := m.lookup(name)
+		if !ok {
+			return name, nil, &os.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		if n.mode&os.ModeSymlink == 0 {
+			return name, n, nil
+		}
+		target := n.target
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(name), target)
+		}
+		name = filepath.Clean(target)
+	}
+	return name, nil, &os.PathError{Op: op, Path: name, Err: errors.New("too many levels of symbolic links")}
+}
+
+// checkParent reports an error unless the parent of name is a directory.
+func (m *MemFS) checkParent(op, name string) error {
+	parent, ok := m.lookup(filepath.Dir(name))
+	if !ok {
+		return &os.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if !parent.mode.IsDir() {
+		return &os.PathError{Op: op, Path: name, Err: errNotDir}
+	}
+	return nil
+}
+
+// children returns the paths of the direct children of dir.
+func (m *MemFS) children(dir string) []string {
+	var names []string
+	for name := range m.nodes {
+		if filepath.Dir(name) == dir && name != dir {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isBelow reports whether name lies inside the directory dir.
+func isBelow(name, dir string) bool {
+	if isRoot(dir) {
+		return name != dir
+	}
+	return strings.HasPrefix(name, dir+string(filepath.Separator))
+}
+
+// Lstat implements FS.
+func (m *MemFS) Lstat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	n, ok := m.lookup(name)
+	if !ok {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	return memFileInfo{filepath.Base(name), *n}, nil
+}
+
+// Stat implements FS.
+func (m *MemFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	_, n, err := m.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{filepath.Base(name), *n}, nil
+}
+
+// Rename implements FS. Like rename(2), it replaces a file or an empty
+// directory at newpath.
+func (m *MemFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	n, ok := m.nodes[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if oldpath == newpath {
+		return nil
+	}
+	if err := m.checkParent("rename", newpath); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errors.Unwrap(err)}
+	}
+	if n.mode.IsDir() && isBelow(newpath, oldpath) {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrInvalid}
+	}
+	if existing, ok := m.nodes[newpath]; ok {
+		switch {
+		case existing.mode.IsDir() && !n.mode.IsDir():
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errIsDir}
+		case existing.mode.IsDir() && len(m.children(newpath)) > 0:
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errNotEmpty}
+		case !existing.mode.IsDir() && n.mode.IsDir():
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errNotDir}
+		}
+	}
+
+	moved := make(map[string]*memNode)
+	for name, node := range m.nodes {
+		if name == oldpath || isBelow(name, oldpath) {
+			moved[newpath+strings.TrimPrefix(name, oldpath)] = node
+			delete(m.nodes, name)
+		}
+	}
+	for name, node := range moved {
+		m.nodes[name] = node
+	}
+	return nil
+}
+
+// Remove implements FS.
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	n, ok := m.nodes[name]
+	if !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if n.mode.IsDir() && len(m.children(name)) > 0 {
+		return &os.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+// RemoveAll implements FS.
+func (m *MemFS) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path = filepath.Clean(path)
+	for name := range m.nodes {
+		if name == path || isBelow(name, path) {
+			delete(m.nodes, name)
+		}
+	}
+	return nil
+}
+
+// MkdirAll implements FS.
+func (m *MemFS) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path = filepath.Clean(path)
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		n, ok := m.lookup(dir)
+		if ok {
+			if !n.mode.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: dir, Err: errNotDir}
+			}
+			break
+		}
+		missing = append(missing, dir)
+	}
+
+	now := time.Now()
+	for _, dir := range missing {
+		m.nodes[dir] = &memNode{mode: os.ModeDir | perm.Perm(), modTime: now}
+	}
+	return nil
+}
+
+// ReadDir implements FS.
+func (m *MemFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.resolve("readdir", filepath.Clean(name))
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	var entries []os.DirEntry
+	for _, child := range m.children(name) {
+		info := memFileInfo{filepath.Base(child), *m.nodes[child]}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+// OpenFile implements FS. Only the access mode and the O_CREATE, O_EXCL,
+// O_TRUNC and O_APPEND flags are honored.
+func (m *MemFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	if _, ok := m.lookup(name); ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+
+	resolved, n, err := m.resolve("open", name)
+	if err != nil {
+		if flag&os.O_CREATE == 0 {
+			return nil, err
+		}
+		if err := m.checkParent("open", resolved); err != nil {
+			return nil, err
+		}
+		n = &memNode{mode: perm.Perm(), modTime: time.Now()}
+		m.nodes[resolved] = n
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if n.mode.IsDir() && writable {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+	if writable && flag&os.O_TRUNC != 0 {
+		n.data = nil
+		n.modTime = time.Now()
+	}
+
+	return &memFile{fs: m, name: resolved, node: n, flag: flag}, nil
+}
+
+// Readlink implements FS.
+func (m *MemFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	n, ok := m.nodes[name]
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	if n.mode&os.ModeSymlink == 0 {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return n.target, nil
+}
+
+// Symlink implements FS.
+func (m *MemFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newname = filepath.Clean(newname)
+	if _, ok := m.lookup(newname); ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrExist}
+	}
+	if err := m.checkParent("symlink", newname); err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: errors.Unwrap(err)}
+	}
+	m.nodes[newname] = &memNode{mode: os.ModeSymlink | 0777, target: oldname, modTime: time.Now()}
+	return nil
+}
+
+// Chtimes implements FS. MemFS keeps no access time.
+func (m *MemFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, n, err := m.resolve("chtimes", filepath.Clean(name))
+	if err != nil {
+		return err
+	}
+	n.modTime = mtime
+	return nil
+}
+
+// Chmod implements FS.
+func (m *MemFS) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, n, err := m.resolve("chmod", filepath.Clean(name))
+	if err != nil {
+		return err
+	}
+	n.mode = n.mode&^os.ModePerm | mode.Perm()
+	return nil
+}
+
+// memFile is an open MemFS file.
+type memFile struct {
+	fs     *MemFS
+	name   string
+	node   *memNode
+	flag   int
+	offset int
+	closed bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.flag&os.O_WRONLY != 0 {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.offset >= len(f.node.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += n
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = len(f.node.data)
+	}
+	if end := f.offset + len(p); end > len(f.node.data) {
+		f.node.data = append(f.node.data, make([]byte, end-len(f.node.data))...)
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset += len(p)
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	return memFileInfo{filepath.Base(f.name), *f.node}, nil
+}
+
+// memFileInfo is a snapshot of a memNode.
+type memFileInfo struct {
+	name string
+	node memNode
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return int64(len(fi.node.data)) }
+func (fi memFileInfo) Mode() os.FileMode  { return fi.node.mode }
+func (fi memFileInfo) ModTime() time.Time { return fi.node.modTime }
+func (fi memFileInfo) IsDir() bool        { return fi.node.mode.IsDir() }
+func (fi memFileInfo) Sys() interface{}   { return nil }
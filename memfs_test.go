@@ -0,0 +1,75 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemFS(t *testing.T) {
+	// A home that doesn't exist on disk, so any use of the os package shows
+	home := filepath.Join(t.TempDir(), "memhome")
+	mem := NewMemFS()
+
+	tr, err := New(WithFS(mem), WithEnv([]string{"HOME=" + home}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	dataDir := filepath.Join(home, "data")
+	if err := mem.MkdirAll(filepath.Join(dataDir, "tree"), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	testFile := filepath.Join(dataDir, "report.txt")
+	for _, path := range []string{testFile, filepath.Join(dataDir, "tree", "leaf.txt")} {
+		f, err := mem.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+		f.Write([]byte("content"))
+		f.Close()
+	}
+
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if err := tr.Trash(filepath.Join(dataDir, "tree")); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+	if _, err := mem.Lstat(testFile); !os.IsNotExist(err) {
+		t.Errorf("File still exists after trashing: %v", err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items in trash, got %d", len(items))
+	}
+
+	if err := tr.Restore("report.txt"); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	content, err := tr.readFile(testFile)
+	if err != nil || string(content) != "content" {
+		t.Errorf("Restored file = %q, %v; want the original content", content, err)
+	}
+
+	if err := tr.Delete("tree"); err != nil {
+		t.Fatalf("Failed to delete directory from trash: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file again: %v", err)
+	}
+	if err := tr.Empty(); err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != 0 {
+		t.Errorf("Expected empty trash, got %v, %v", items, err)
+	}
+
+	if _, err := os.Stat(home); !os.IsNotExist(err) {
+		t.Errorf("Trasher touched the local disk: %v", err)
+	}
+}
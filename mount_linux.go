@@ -6,36 +6,64 @@ package trash
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// getMountPoint returns the mount point of the filesystem holding path: the
+// topmost directory above it on the same device. Comparing device numbers
+// rather than matching path prefixes against /proc/mounts keeps /mnt/data2
+// from being taken for a directory under /mnt/data, and needs no unescaping
+// of mount points with spaces.
 func getMountPoint(path string) (string, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return "", err
 	}
+	return mountPointByDevice(absPath, os.Lstat, os.Stat)
+}
 
-	// Read /proc/mounts to get all mount points
-	mounts, err := getMountPoints()
-	if err != nil {
-		return "", err
-	}
-
-	// Find the longest matching mount point
-	var bestMount string
-	for _, mount := range mounts {
-		if strings.HasPrefix(absPath, mount) && len(mount) > len(bestMount) {
-			bestMount = mount
+// mountPointByDevice walks up from absPath, which need not exist, until the
+// device changes. The path itself is examined with lstat, since a symlink
+// lives on its directory's filesystem, and its ancestors with stat.
+func mountPointByDevice(absPath string, lstat, stat func(string) (os.FileInfo, error)) (string, error) {
+	// A path that doesn't exist yet will be created on its nearest
+	// existing ancestor's filesystem
+	path := absPath
+	info, err := lstat(path)
+	for err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
 		}
+		path = parent
+		info, err = stat(path)
 	}
 
-	if bestMount == "" {
-		return "/", nil
+	dev, ok := deviceID(info)
+	if !ok {
+		return "", fmt.Errorf("no device number for %s", path)
 	}
 
-	return bestMount, nil
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path, nil
+		}
+		parentInfo, err := stat(parent)
+		if err != nil {
+			return "", err
+		}
+		if parentDev, ok := deviceID(parentInfo); !ok || parentDev != dev {
+			return path, nil
+		}
+		path = parent
+	}
 }
 
 func getMountPoints() ([]string, error) {
@@ -45,23 +73,52 @@ func getMountPoints() ([]string, error) {
 	}
 	defer file.Close()
 
+	mounts, err := parseMounts(file, os.Stat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/mounts: %w", err)
+	}
+	return mounts, nil
+}
+
+// pseudoFilesystems are the types of filesystems in /proc/mounts that the
+// kernel provides for its own interfaces, which never hold a trash. tmpfs
+// is not among them: files in a tmpfs /tmp are trashed to /tmp/.Trash-$uid
+// like on any other mount.
+var pseudoFilesystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true,
+	"cgroup2": true, "configfs": true, "debugfs": true, "devpts": true,
+	"devtmpfs": true, "efivarfs": true, "fusectl": true, "hugetlbfs": true,
+	"mqueue": true, "nsfs": true, "proc": true, "pstore": true,
+	"rpc_pipefs": true, "securityfs": true, "selinuxfs": true, "sysfs": true,
+	"tracefs": true,
+}
+
+// parseMounts returns the mount points listed in r, in the format of
+// /proc/mounts, leaving out pseudo filesystems, mount points stat can't
+// see, and mount points that are the same directory as an earlier one,
+// as a bind mount of a whole filesystem is. A bind mount of a subdirectory
+// is a different directory on the same device and is kept, since it has a
+// trash of its own.
+func parseMounts(r io.Reader, stat func(string) (os.FileInfo, error)) ([]string, error) {
 	var mounts []string
-	scanner := bufio.NewScanner(file)
+	var seen []os.FileInfo
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		fields := strings.Fields(scanner.Text())
-		if len(fields) >= 2 {
-			mountPoint := fields[1]
-			// Unescape special characters in mount points
-			mountPoint = unescapeMountPoint(mountPoint)
-			mounts = append(mounts, mountPoint)
+		if len(fields) < 3 || pseudoFilesystems[fields[2]] {
+			continue
 		}
-	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read /proc/mounts: %w", err)
+		// Unescape special characters in mount points
+		mountPoint := unescapeMountPoint(fields[1])
+		info, err := stat(mountPoint)
+		if err != nil || containsSameFile(seen, info) {
+			continue
+		}
+		seen = append(seen, info)
+		mounts = append(mounts, mountPoint)
 	}
-
-	return mounts, nil
+	return mounts, scanner.Err()
 }
 
 func unescapeMountPoint(s string) string {
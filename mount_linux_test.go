@@ -0,0 +1,192 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// shiftedDeviceInfo reports its file as living on another device, shift
+// device numbers away from the real one.
+type shiftedDeviceInfo struct {
+	os.FileInfo
+	shift int
+}
+
+func (fi shiftedDeviceInfo) Sys() interface{} {
+	st := *fi.FileInfo.Sys().(*syscall.Stat_t)
+	for i := 0; i < fi.shift; i++ {
+		st.Dev++
+	}
+	return &st
+}
+
+func TestGetMountPointNested(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"mnt/data/inner", "mnt/data2"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	for _, file := range []string{"mnt/data/a.txt", "mnt/data/inner/b.txt", "mnt/data2/c.txt"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Pretend mnt/data is a mount, with another mounted at mnt/data/inner
+	data := filepath.Join(root, "mnt", "data")
+	inner := filepath.Join(data, "inner")
+	under := func(path, dir string) bool {
+		return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+	}
+	fake := func(real func(string) (os.FileInfo, error)) func(string) (os.FileInfo, error) {
+		return func(path string) (os.FileInfo, error) {
+			info, err := real(path)
+			switch {
+			case err != nil:
+				return info, err
+			case under(path, inner):
+				return shiftedDeviceInfo{info, 2}, nil
+			case under(path, data):
+				return shiftedDeviceInfo{info, 1}, nil
+			}
+			return info, nil
+		}
+	}
+
+	rootMount, err := getMountPoint(root)
+	if err != nil {
+		t.Fatalf("Failed to get mount point of %s: %v", root, err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(data, "a.txt"), data},
+		{data, data},
+		{filepath.Join(inner, "b.txt"), inner},
+		{filepath.Join(inner, "missing", "d.txt"), inner},
+		// Shares a prefix with mnt/data but is not under it
+		{filepath.Join(root, "mnt", "data2", "c.txt"), rootMount},
+	}
+	for _, tt := range tests {
+		got, err := mountPointByDevice(tt.path, fake(os.Lstat), fake(os.Stat))
+		if err != nil {
+			t.Errorf("mountPointByDevice(%s) failed: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("mountPointByDevice(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGetMountPointProc(t *testing.T) {
+	rootInfo, err := os.Stat("/")
+	if err != nil {
+		t.Fatalf("Failed to stat /: %v", err)
+	}
+	procInfo, err := os.Stat("/proc")
+	if err != nil {
+		t.Skip("/proc is not available")
+	}
+	rootDev, _ := deviceID(rootInfo)
+	procDev, _ := deviceID(procInfo)
+	if rootDev == procDev {
+		t.Skip("/proc is not a separate mount")
+	}
+
+	got, err := getMountPoint("/proc/self/status")
+	if err != nil {
+		t.Fatalf("Failed to get mount point: %v", err)
+	}
+	if got != "/proc" {
+		t.Errorf("getMountPoint(/proc/self/status) = %s, want /proc", got)
+	}
+}
+
+func TestGetMountPointSiblings(t *testing.T) {
+	root := t.TempDir()
+	data := filepath.Join(root, "data")
+	backup := filepath.Join(root, "databackup")
+	for _, dir := range []string{data, backup} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "x"), nil, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Both are mounts of their own, on different devices
+	fake := func(real func(string) (os.FileInfo, error)) func(string) (os.FileInfo, error) {
+		return func(path string) (os.FileInfo, error) {
+			info, err := real(path)
+			switch {
+			case err != nil:
+				return info, err
+			case path == backup || strings.HasPrefix(path, backup+string(filepath.Separator)):
+				return shiftedDeviceInfo{info, 2}, nil
+			case path == data || strings.HasPrefix(path, data+string(filepath.Separator)):
+				return shiftedDeviceInfo{info, 1}, nil
+			}
+			return info, nil
+		}
+	}
+
+	for _, want := range []string{data, backup} {
+		path := filepath.Join(want, "x")
+		got, err := mountPointByDevice(path, fake(os.Lstat), fake(os.Stat))
+		if err != nil {
+			t.Errorf("mountPointByDevice(%s) failed: %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("mountPointByDevice(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestParseMounts(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"data", "data/share", "my disk"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create test directory: %v", err)
+		}
+	}
+	// A bind mount of the whole filesystem shows the same directory
+	if err := os.Symlink(filepath.Join(root, "data"), filepath.Join(root, "bind")); err != nil {
+		t.Fatalf("Failed to create bind stand-in: %v", err)
+	}
+
+	mounts := strings.Join([]string{
+		"proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0",
+		"sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0",
+		"cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime 0 0",
+		"devpts /dev/pts devpts rw,nosuid,noexec,relatime 0 0",
+		"/dev/sdb1 " + root + "/data ext4 rw,relatime 0 0",
+		"/dev/sdb1 " + root + "/bind ext4 rw,relatime 0 0",
+		"/dev/sdb1 " + root + "/data/share ext4 rw,relatime 0 0",
+		"/dev/sdc1 " + root + `/my\040disk vfat rw,relatime 0 0`,
+		"tmpfs " + root + " tmpfs rw,nosuid,nodev 0 0",
+		"/dev/sdd1 " + root + "/gone ext4 rw,relatime 0 0",
+	}, "\n")
+
+	got, err := parseMounts(strings.NewReader(mounts), os.Stat)
+	if err != nil {
+		t.Fatalf("parseMounts failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(root, "data"),
+		filepath.Join(root, "data", "share"),
+		filepath.Join(root, "my disk"),
+		root,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parseMounts() = %q, want %q", got, want)
+	}
+}
@@ -3,10 +3,6 @@
 
 package trash
 
-import (
-	"path/filepath"
-)
-
 // Fallback implementation for other systems
 func getMountPoint(path string) (string, error) {
 	// For unsupported systems, always use home trash
@@ -0,0 +1,95 @@
+//go:build linux
+// +build linux
+
+package trash
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+)
+
+// copyOwnership gives dst, a copy of src described by info, the owner,
+// group and extended attributes of src, as far as the process may. Being
+// refused them, as any user but root is for a foreign owner, isn't an
+// error; the copy just keeps what it was created with. Sources that don't
+// come from the local filesystem are left alone.
+func copyOwnership(src, dst string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if err := os.Lchown(dst, int(st.Uid), int(st.Gid)); err != nil && !isRefused(err) {
+		return err
+	}
+	// Extended attributes of a symlink can't be set through its path
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	names, err := listXattrs(src)
+	if err != nil {
+		if isRefused(err) {
+			return nil
+		}
+		return err
+	}
+	var errs []error
+	for _, name := range names {
+		value, err := getXattr(src, name)
+		if err == nil {
+			err = syscall.Setxattr(dst, name, value, 0)
+		}
+		if err != nil && !isRefused(err) && err != syscall.ENODATA {
+			errs = append(errs, &os.PathError{Op: "setxattr " + name, Path: dst, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isRefused reports whether err means the process may not change an
+// attribute, or the filesystem doesn't support it.
+func isRefused(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) ||
+		errors.Is(err, syscall.ENOTSUP)
+}
+
+// listXattrs returns the names of the extended attributes of path.
+func listXattrs(path string) ([]string, error) {
+	buf := make([]byte, 1024)
+	for {
+		n, err := syscall.Listxattr(path, buf)
+		if err == syscall.ERANGE {
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, name := range bytes.Split(buf[:n], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+		return names, nil
+	}
+}
+
+// getXattr returns the value of the extended attribute name of path.
+func getXattr(path, name string) ([]byte, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Getxattr(path, name, buf)
+		if err == syscall.ERANGE {
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
@@ -0,0 +1,98 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestTrashOwnership(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	testDir := filepath.Join(t.TempDir(), "owned")
+	if err := os.Mkdir(testDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	testFile := filepath.Join(testDir, "report.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Symlink("report.txt", filepath.Join(testDir, "link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	xattrs := syscall.Setxattr(testFile, "user.origin", []byte("scanner"), 0) == nil
+	owned := os.Getuid() == 0
+	if owned {
+		for _, name := range []string{"", "report.txt", "link"} {
+			if err := os.Lchown(filepath.Join(testDir, name), 1234, 5678); err != nil {
+				t.Fatalf("Failed to change owner: %v", err)
+			}
+		}
+	}
+	if !owned && !xattrs {
+		t.Skip("Can neither change owners nor set extended attributes here")
+	}
+
+	if err := tr.Trash(testDir); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+
+	trashed := filepath.Join(tr.homeTrash, "files", "owned")
+	if owned {
+		for _, name := range []string{"", "report.txt", "link"} {
+			info, err := os.Lstat(filepath.Join(trashed, name))
+			if err != nil {
+				t.Fatalf("Failed to stat %q: %v", name, err)
+			}
+			st := info.Sys().(*syscall.Stat_t)
+			if st.Uid != 1234 || st.Gid != 5678 {
+				t.Errorf("Owner of %q = %d:%d, want 1234:5678", name, st.Uid, st.Gid)
+			}
+		}
+	}
+	if xattrs {
+		value, err := getXattr(filepath.Join(trashed, "report.txt"), "user.origin")
+		if err != nil {
+			t.Fatalf("Failed to read extended attribute: %v", err)
+		}
+		if string(value) != "scanner" {
+			t.Errorf("user.origin = %q, want %q", value, "scanner")
+		}
+	}
+}
+
+func TestCopyOwnershipRefused(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("Root may give files any owner")
+	}
+
+	src := filepath.Join(t.TempDir(), "report.txt")
+	dst := filepath.Join(t.TempDir(), "copy.txt")
+	for _, path := range []string{src, dst} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	info, err := os.Lstat(src)
+	if err != nil {
+		t.Fatalf("Failed to stat test file: %v", err)
+	}
+
+	// A foreign owner can't be given to the copy, which keeps its own
+	st := *info.Sys().(*syscall.Stat_t)
+	st.Uid, st.Gid = 1234, 5678
+	if err := copyOwnership(src, dst, foreignInfo{info, &st}); err != nil {
+		t.Errorf("copyOwnership failed: %v", err)
+	}
+}
+
+// foreignInfo is an os.FileInfo with substituted system data.
+type foreignInfo struct {
+	os.FileInfo
+	sys *syscall.Stat_t
+}
+
+func (i foreignInfo) Sys() any { return i.sys }
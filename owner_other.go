@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package trash
+
+import "os"
+
+// copyOwnership does nothing; only Linux copies carry over the owner and
+// extended attributes of their source.
+func copyOwnership(src, dst string, info os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package trash
+
+import (
+	"sort"
+	"strings"
+)
+
+// A SortField is an order for ListPage.
+type SortField int
+
+const (
+	// SortByDeletionDate orders items from the most recently deleted.
+	SortByDeletionDate SortField = iota
+	// SortByName orders items by trash name.
+	SortByName
+	// SortByOriginalPath orders items by original path.
+	SortByOriginalPath
+)
+
+// ListPage returns the items at [offset, offset+limit) of the trash sorted
+// by sortBy, and the total number of items, for paging through a large
+// trash. Items that compare equal are ordered by trash directory and name,
+// so pages are consistent between calls as long as the trash doesn't
+// change. Every call lists the whole trash; a limit of 0 or less returns
+// everything from offset on.
+func (tr *Trasher) ListPage(offset, limit int, sortBy SortField) ([]TrashItem, int, error) {
+	items, err := tr.List()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortItems(items, sortBy)
+
+	total := len(items)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	return items[offset:end], total, nil
+}
+
+func sortItems(items []TrashItem, sortBy SortField) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch sortBy {
+		case SortByDeletionDate:
+			if !a.DeletionDate.Equal(b.DeletionDate) {
+				return a.DeletionDate.After(b.DeletionDate)
+			}
+		case SortByName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		case SortByOriginalPath:
+			if a.OriginalPath != b.OriginalPath {
+				return a.OriginalPath < b.OriginalPath
+			}
+		}
+
+		if c := strings.Compare(a.TrashDir, b.TrashDir); c != 0 {
+			return c < 0
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,60 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListPage(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	// Pairs of items share a deletion date, so the tiebreak matters
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		date := base.Add(time.Duration(i/2) * time.Minute)
+		if err := tr.TrashWithOptions(path, TrashOptions{DeletionDate: date}); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	var paged []string
+	for offset := 0; ; offset += 4 {
+		page, total, err := tr.ListPage(offset, 4, SortByDeletionDate)
+		if err != nil {
+			t.Fatalf("Failed to list page: %v", err)
+		}
+		if total != 10 {
+			t.Errorf("Total = %d, want 10", total)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, item := range page {
+			paged = append(paged, item.Name)
+		}
+	}
+
+	want := []string{
+		"file8.txt", "file9.txt", "file6.txt", "file7.txt", "file4.txt",
+		"file5.txt", "file2.txt", "file3.txt", "file0.txt", "file1.txt",
+	}
+	if fmt.Sprint(paged) != fmt.Sprint(want) {
+		t.Errorf("Paged order = %v, want %v", paged, want)
+	}
+
+	page, _, err := tr.ListPage(8, 4, SortByName)
+	if err != nil {
+		t.Fatalf("Failed to list page: %v", err)
+	}
+	if len(page) != 2 || page[0].Name != "file8.txt" || page[1].Name != "file9.txt" {
+		t.Errorf("Last page by name = %v, want file8.txt and file9.txt", page)
+	}
+}
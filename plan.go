@@ -0,0 +1,115 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TrashPlan describes what trashing Source would do.
+type TrashPlan struct {
+	Source   string
+	TrashDir string
+	Name     string
+
+	// CrossDevice is true if the data would have to be copied to another
+	// filesystem rather than renamed.
+	CrossDevice bool
+
+	// Err is why Source can't be trashed, if it can't.
+	Err error
+}
+
+// TrashDryRun works out, without changing anything on disk, where each of
+// paths would be trashed and whether that is possible. Names are chosen for
+// each path on its own, so paths sharing a base name get the same Name here
+// even though trashing them would not.
+func (tr *Trasher) TrashDryRun(paths []string) []TrashPlan {
+	plans := make([]TrashPlan, 0, len(paths))
+	for _, path := range paths {
+		plans = append(plans, tr.planTrash(path))
+	}
+	return plans
+}
+
+func (tr *Trasher) planTrash(path string) TrashPlan {
+	plan := TrashPlan{Source: path}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		plan.Err = fmt.Errorf("failed to get absolute path: %w", err)
+		return plan
+	}
+	plan.Source = absPath
+
+	// Refused the same way Trash refuses them
+	if err := tr.checkProtected(absPath); err != nil {
+		plan.Err = err
+		return plan
+	}
+
+	info, err := tr.fs.Lstat(absPath)
+	if err != nil {
+		plan.Err = fmt.Errorf("failed to stat file: %w", err)
+		return plan
+	}
+	if info.IsDir() {
+		if mount, err := tr.mountPoint(absPath); err == nil && mount == absPath {
+			plan.Err = fmt.Errorf("%w: %s", ErrIsMountPoint, absPath)
+			return plan
+		}
+	}
+
+	// Windows and macOS pick the name in their trash only once the file is
+	// in it
+	if tr.recycleBin {
+		plan.TrashDir = tr.recycleBinDir(absPath)
+		return plan
+	}
+	if tr.macTrash != "" {
+		plan.TrashDir = tr.macTrashDir(absPath)
+		return plan
+	}
+
+	trashDir, fallback, err := tr.resolveTrashDir(absPath, false)
+	if err != nil {
+		plan.Err = fmt.Errorf("failed to determine trash directory: %w", err)
+		return plan
+	}
+
+	if info, err := tr.fs.Stat(trashDir); err == nil && !info.IsDir() {
+		plan.Err = fmt.Errorf("%w: %s is not a directory", ErrNoTrashAvailable, trashDir)
+		return plan
+	}
+
+	plan.TrashDir = trashDir
+	plan.CrossDevice = tr.planCrossDevice(absPath, trashDir, fallback)
+	plan.Name, _ = tr.generateTrashNameInDir(filepath.Base(absPath), trashDir, time.Now())
+	return plan
+}
+
+// planCrossDevice reports whether trashing absPath to trashDir would copy
+// rather than rename, deciding as Trash does: by device numbers where they
+// are known, comparing against the nearest existing ancestor of a trash
+// directory not created yet, and otherwise by whether the trash is a
+// fallback to the home trash.
+func (tr *Trasher) planCrossDevice(absPath, trashDir string, fallback bool) bool {
+	dir := trashDir
+	for {
+		if _, err := tr.fs.Stat(dir); !os.IsNotExist(err) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	same, known, err := tr.sameDevice(absPath, dir)
+	if err != nil || !known {
+		return fallback
+	}
+	return !same
+}
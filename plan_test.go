@@ -0,0 +1,74 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrashDryRun(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	dir := t.TempDir()
+	homeFile := filepath.Join(dir, "home.txt")
+	mountFile := filepath.Join(mount, "mount.txt")
+	missing := filepath.Join(dir, "missing.txt")
+
+	for _, path := range []string{homeFile, mountFile} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	plans := tr.TrashDryRun([]string{homeFile, mountFile, missing})
+	if len(plans) != 3 {
+		t.Fatalf("Expected 3 plans, got %d", len(plans))
+	}
+
+	if p := plans[0]; p.Err != nil || p.TrashDir != tr.homeTrash || p.Name != "home.txt" || p.CrossDevice {
+		t.Errorf("Unexpected plan for home file: %+v", p)
+	}
+	if p := plans[1]; p.Err != nil || p.TrashDir != filepath.Join(mount, ".Trash-"+tr.uid) || p.Name != "mount.txt" {
+		t.Errorf("Unexpected plan for mount file: %+v", p)
+	}
+	if p := plans[2]; p.Err == nil {
+		t.Errorf("Expected an error for a missing file: %+v", p)
+	}
+
+	// Nothing may have been created or moved
+	for _, path := range []string{homeFile, mountFile} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Dry run moved %s: %v", path, err)
+		}
+	}
+	if tr.TrashDirExists() {
+		t.Error("Dry run created the home trash")
+	}
+	if _, err := os.Stat(filepath.Join(mount, ".Trash-"+tr.uid)); !os.IsNotExist(err) {
+		t.Error("Dry run created the mount trash")
+	}
+}
+
+func TestTrashDryRunRefusals(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+	if err := tr.ensureTrashDirs(tr.homeTrash); err != nil {
+		t.Fatalf("Failed to create trash: %v", err)
+	}
+
+	for path, want := range map[string]error{
+		"/":                                  ErrProtectedPath,
+		tr.homeTrash:                         ErrProtectedPath,
+		filepath.Join(tr.homeTrash, "files"): ErrProtectedPath,
+		mount:                                ErrIsMountPoint,
+	} {
+		plan := tr.TrashDryRun([]string{path})[0]
+		if !errors.Is(plan.Err, want) {
+			t.Errorf("Plan for %s has error %v, want %v", path, plan.Err, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// quotaFile is the name of the file in the home trash that holds the
+// configured size cap, so the setting is shared across processes and tools.
+const quotaFile = "quota"
+
+// GetQuota returns the size cap in bytes configured for the home trash, or
+// 0 if none is. A missing or unreadable quota file means no quota.
+func (tr *Trasher) GetQuota() (int64, error) {
+	content, err := tr.readFile(filepath.Join(tr.homeTrash, quotaFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read quota: %w", err)
+	}
+
+	quota, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil || quota < 0 {
+		return 0, nil
+	}
+	return quota, nil
+}
+
+// SetQuota records a size cap of bytes for the home trash. A cap of 0 or
+// less removes the quota. The package only stores the value; enforcing it
+// is up to the caller, e.g. by purging old items once Stats exceeds it.
+func (tr *Trasher) SetQuota(bytes int64) error {
+	path := filepath.Join(tr.homeTrash, quotaFile)
+	if bytes <= 0 {
+		if err := tr.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove quota: %w", err)
+		}
+		return nil
+	}
+
+	if err := tr.fs.MkdirAll(tr.homeTrash, 0700); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	// Readers never see a partial value
+	if err := tr.writeFileAtomic(path, []byte(strconv.FormatInt(bytes, 10)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write quota: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+	home := t.TempDir()
+	tr := newTestTrasherAt(t, home)
+
+	if quota, err := tr.GetQuota(); err != nil || quota != 0 {
+		t.Fatalf("GetQuota without a quota file = %d, %v; want 0, nil", quota, err)
+	}
+
+	if err := tr.SetQuota(1 << 30); err != nil {
+		t.Fatalf("Failed to set quota: %v", err)
+	}
+
+	// A fresh Trasher stands in for a restart or another tool
+	if quota, err := newTestTrasherAt(t, home).GetQuota(); err != nil || quota != 1<<30 {
+		t.Errorf("GetQuota after SetQuota = %d, %v; want %d, nil", quota, err, 1<<30)
+	}
+
+	if err := os.WriteFile(filepath.Join(tr.homeTrash, quotaFile), []byte("lots"), 0600); err != nil {
+		t.Fatalf("Failed to corrupt quota file: %v", err)
+	}
+	if quota, err := tr.GetQuota(); err != nil || quota != 0 {
+		t.Errorf("GetQuota with a corrupt quota file = %d, %v; want 0, nil", quota, err)
+	}
+
+	if err := tr.SetQuota(0); err != nil {
+		t.Fatalf("Failed to clear quota: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.homeTrash, quotaFile)); !os.IsNotExist(err) {
+		t.Errorf("Clearing the quota left the quota file: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package trash
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// recycleBinName is the directory at the root of each Windows volume that
+// holds a Recycle Bin folder per user, named after the user's SID.
+const recycleBinName = "$Recycle.Bin"
+
+// In a Recycle Bin folder, each deleted file is renamed to $R followed by
+// a random name and its original extension, and described by a $I file of
+// the same name.
+const (
+	recycleDataPrefix = "$R"
+	recycleInfoPrefix = "$I"
+)
+
+// isRecycleBinDir reports whether trashDir is a user's folder in a Windows
+// Recycle Bin rather than a trash directory of this package.
+func (tr *Trasher) isRecycleBinDir(trashDir string) bool {
+	return tr.recycleBin && strings.EqualFold(filepath.Base(filepath.Dir(trashDir)), recycleBinName)
+}
+
+// recycleBinDir returns the Recycle Bin folder of the volume holding path.
+func (tr *Trasher) recycleBinDir(path string) string {
+	return filepath.Join(filepath.VolumeName(path)+string(filepath.Separator), recycleBinName, tr.uid)
+}
+
+// recycle moves absPath to the Recycle Bin of its volume and returns the
+// resulting entry. ok is false if Windows turned the file away and left it
+// in place, as it does for files too big for the Recycle Bin or on volumes
+// without one; the caller then falls back to the XDG trash.
+func (tr *Trasher) recycle(absPath string) (result TrashResult, ok bool, err error) {
+	recycledPath, err := tr.recycleFile(absPath)
+	if err != nil {
+		if _, statErr := tr.fs.Lstat(absPath); statErr == nil {
+			tr.logger.Warn("trash: Recycle Bin refused file, using the XDG trash", "path", absPath, "err", err)
+			return TrashResult{}, false, nil
+		}
+		return TrashResult{}, true, fmt.Errorf("failed to move to the Recycle Bin: %w", err)
+	}
+
+	// Look the entry up by the $R path Windows reports, or failing that
+	// take the newest one from absPath
+	trashDir := tr.recycleBinDir(absPath)
+	if recycledPath != "" {
+		trashDir = filepath.Dir(recycledPath)
+	}
+	items, _ := tr.listRecycleBin(trashDir)
+	var item *TrashItem
+	for i := range items {
+		if recycledPath != "" && strings.EqualFold(items[i].FilePath, recycledPath) {
+			item = &items[i]
+			break
+		}
+		if strings.EqualFold(items[i].OriginalPath, absPath) &&
+			(item == nil || items[i].DeletionDate.After(item.DeletionDate)) {
+			item = &items[i]
+		}
+	}
+	if item == nil {
+		return TrashResult{}, true, fmt.Errorf("%w: %s was recycled but isn't listed in %s", ErrNoTrashAvailable, absPath, trashDir)
+	}
+
+	tr.emit(EventTrash, *item)
+	return TrashResult{TrashItem: *item}, true, nil
+}
+
+// listRecycleBin returns the items in the Recycle Bin folder trashDir.
+// Entries whose $I file can't be read are skipped, as unparseable
+// trashinfo files are.
+func (tr *Trasher) listRecycleBin(trashDir string) ([]TrashItem, error) {
+	entries, err := tr.fs.ReadDir(trashDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Recycle Bin: %w", err)
+	}
+
+	items := []TrashItem{}
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), recycleInfoPrefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		infoPath := filepath.Join(trashDir, entry.Name())
+		content, err := tr.readFile(infoPath)
+		if err != nil {
+			continue
+		}
+		originalPath, deleted, err := parseRecycleInfo(content)
+		if err != nil {
+			continue
+		}
+		items = append(items, TrashItem{
+			Name:         name,
+			OriginalPath: originalPath,
+			DeletionDate: deleted,
+			InfoPath:     infoPath,
+			FilePath:     filepath.Join(trashDir, recycleDataPrefix+name),
+			TrashDir:     trashDir,
+		})
+	}
+	return items, nil
+}
+
+// emptyRecycleBin is emptyTrashDir for the Recycle Bin folder trashDir,
+// which Windows empties for the whole volume at once.
+func (tr *Trasher) emptyRecycleBin(ctx context.Context, trashDir string, result *EmptyResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	items, err := tr.listRecycleBin(trashDir)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if err := emptyRecycleBinOf(filepath.Dir(filepath.Dir(trashDir))); err != nil {
+		return fmt.Errorf("failed to empty the Recycle Bin: %w", err)
+	}
+
+	for _, item := range items {
+		result.Removed++
+		tr.emit(EventDelete, item)
+		tr.progress("delete", item.OriginalPath, 0, statusDone)
+	}
+	return nil
+}
+
+// parseRecycleInfo decodes a $I file: a version, the file's size and its
+// deletion time as a FILETIME, followed by the original path in UTF-16,
+// fixed at 260 characters in version 1 (Vista to 8.1) and preceded by its
+// length in version 2 (Windows 10 on).
+func parseRecycleInfo(content []byte) (originalPath string, deleted time.Time, err error) {
+	if len(content) < 24 {
+		return "", time.Time{}, fmt.Errorf("%w: Recycle Bin entry is truncated", ErrInvalidTrashInfo)
+	}
+	version := binary.LittleEndian.Uint64(content)
+	filetime := int64(binary.LittleEndian.Uint64(content[16:]))
+
+	var raw []byte
+	switch version {
+	case 1:
+		raw = content[24:]
+		if len(raw) > 2*260 {
+			raw = raw[:2*260]
+		}
+	case 2:
+		if len(content) < 28 {
+			return "", time.Time{}, fmt.Errorf("%w: Recycle Bin entry is truncated", ErrInvalidTrashInfo)
+		}
+		n := int(binary.LittleEndian.Uint32(content[24:]))
+		raw = content[28:]
+		if len(raw) < 2*n {
+			return "", time.Time{}, fmt.Errorf("%w: Recycle Bin entry is truncated", ErrInvalidTrashInfo)
+		}
+		raw = raw[:2*n]
+	default:
+		return "", time.Time{}, fmt.Errorf("%w: unknown Recycle Bin entry version %d", ErrInvalidTrashInfo, version)
+	}
+
+	chars := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		c := binary.LittleEndian.Uint16(raw[i:])
+		if c == 0 {
+			break
+		}
+		chars = append(chars, c)
+	}
+	if len(chars) == 0 {
+		return "", time.Time{}, fmt.Errorf("%w: Recycle Bin entry has no path", ErrInvalidTrashInfo)
+	}
+
+	// FILETIMEs count 100ns intervals since 1601
+	const unixEpoch = 116444736000000000
+	if filetime > unixEpoch {
+		deleted = time.Unix(0, (filetime-unixEpoch)*100)
+	}
+	return string(utf16.Decode(chars)), deleted, nil
+}
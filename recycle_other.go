@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package trash
+
+import "errors"
+
+var errNoRecycleBin = errors.New("the Recycle Bin exists only on Windows")
+
+// recycleBinAvailable reports false: there is no Recycle Bin outside
+// Windows.
+func recycleBinAvailable() bool {
+	return false
+}
+
+func recycleFile(path string) (string, error) {
+	return "", errNoRecycleBin
+}
+
+func emptyRecycleBinOf(root string) error {
+	return errNoRecycleBin
+}
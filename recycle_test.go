@@ -0,0 +1,196 @@
+package trash
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// recycleInfo builds a $I file of the given version recording path as
+// deleted at deleted.
+func recycleInfo(version int, path string, deleted time.Time) []byte {
+	chars := append(utf16.Encode([]rune(path)), 0)
+	content := make([]byte, 24)
+	binary.LittleEndian.PutUint64(content, uint64(version))
+	binary.LittleEndian.PutUint64(content[8:], 7)
+	binary.LittleEndian.PutUint64(content[16:], uint64(deleted.UnixNano()/100+116444736000000000))
+	if version == 2 {
+		content = binary.LittleEndian.AppendUint32(content, uint32(len(chars)))
+	} else {
+		chars = append(chars, make([]uint16, 260-len(chars))...)
+	}
+	for _, c := range chars {
+		content = binary.LittleEndian.AppendUint16(content, c)
+	}
+	return content
+}
+
+func TestParseRecycleInfo(t *testing.T) {
+	deleted := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	for _, version := range []int{1, 2} {
+		path, date, err := parseRecycleInfo(recycleInfo(version, `C:\Users\me\Documents\résumé.txt`, deleted))
+		if err != nil {
+			t.Errorf("Version %d: parseRecycleInfo failed: %v", version, err)
+			continue
+		}
+		if path != `C:\Users\me\Documents\résumé.txt` {
+			t.Errorf("Version %d: path = %q", version, path)
+		}
+		if !date.Equal(deleted) {
+			t.Errorf("Version %d: deletion date = %v, want %v", version, date, deleted)
+		}
+	}
+
+	valid := recycleInfo(2, `C:\file.txt`, deleted)
+	unknown := recycleInfo(2, `C:\file.txt`, deleted)
+	binary.LittleEndian.PutUint64(unknown, 3)
+	for name, content := range map[string][]byte{
+		"header":  valid[:20],
+		"path":    valid[:len(valid)-4],
+		"version": unknown,
+		"empty":   recycleInfo(1, "", deleted),
+	} {
+		if _, _, err := parseRecycleInfo(content); !errors.Is(err, ErrInvalidTrashInfo) {
+			t.Errorf("%s: parseRecycleInfo error = %v, want ErrInvalidTrashInfo", name, err)
+		}
+	}
+}
+
+func TestRecycleBinListAndRestore(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.recycleBin = true
+
+	trashDir := filepath.Join(t.TempDir(), recycleBinName, "S-1-5-21-1000")
+	if err := os.MkdirAll(trashDir, 0700); err != nil {
+		t.Fatalf("Failed to create Recycle Bin: %v", err)
+	}
+	original := filepath.Join(t.TempDir(), "report.txt")
+	deleted := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	if err := os.WriteFile(filepath.Join(trashDir, "$IAB12CD.txt"), recycleInfo(2, original, deleted), 0600); err != nil {
+		t.Fatalf("Failed to create $I file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(trashDir, "$RAB12CD.txt"), []byte("content"), 0600); err != nil {
+		t.Fatalf("Failed to create $R file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(trashDir, "$IBROKEN.txt"), []byte("junk"), 0600); err != nil {
+		t.Fatalf("Failed to create $I file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(trashDir, "desktop.ini"), nil, 0600); err != nil {
+		t.Fatalf("Failed to create desktop.ini: %v", err)
+	}
+
+	if !tr.isRecycleBinDir(trashDir) {
+		t.Fatalf("isRecycleBinDir(%s) = false", trashDir)
+	}
+	items, err := tr.listTrashDir(trashDir)
+	if err != nil {
+		t.Fatalf("Failed to list Recycle Bin: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Recycle Bin lists %d items, want 1: %+v", len(items), items)
+	}
+	item := items[0]
+	if item.Name != "AB12CD.txt" || item.OriginalPath != original || !item.DeletionDate.Equal(deleted) {
+		t.Errorf("Listed item = %+v", item)
+	}
+
+	if err := tr.restoreItem(item, RestoreOptions{}); err != nil {
+		t.Fatalf("Failed to restore item: %v", err)
+	}
+	if content, err := os.ReadFile(original); err != nil || string(content) != "content" {
+		t.Errorf("Restored file = %q, %v", content, err)
+	}
+	for _, p := range []string{item.FilePath, item.InfoPath, filepath.Join(trashDir, lockFileName)} {
+		if _, err := os.Lstat(p); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after restore", p)
+		}
+	}
+}
+
+// fakeRecycleBin makes tr trash to a Recycle Bin folder in a temporary
+// directory, moving files there and describing them as Windows would.
+func fakeRecycleBin(t *testing.T, tr *Trasher) (trashDir string) {
+	t.Helper()
+	trashDir = filepath.Join(t.TempDir(), recycleBinName, "S-1-5-21-1000")
+	if err := os.MkdirAll(trashDir, 0700); err != nil {
+		t.Fatalf("Failed to create Recycle Bin: %v", err)
+	}
+	tr.recycleBin = true
+	tr.recycleFile = func(path string) (string, error) {
+		name := randomSuffix()[:6] + filepath.Ext(path)
+		if err := os.WriteFile(filepath.Join(trashDir, recycleInfoPrefix+name), recycleInfo(2, path, time.Now()), 0600); err != nil {
+			return "", err
+		}
+		recycled := filepath.Join(trashDir, recycleDataPrefix+name)
+		return recycled, os.Rename(path, recycled)
+	}
+	return trashDir
+}
+
+func TestRecycle(t *testing.T) {
+	tr := newTestTrasher(t)
+	trashDir := fakeRecycleBin(t, tr)
+
+	testFile := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to recycle file: %v", err)
+	}
+	if result.TrashDir != trashDir || result.OriginalPath != testFile {
+		t.Errorf("Recycled item = %+v, want it in %s", result.TrashItem, trashDir)
+	}
+	if content, err := os.ReadFile(result.FilePath); err != nil || string(content) != "content" {
+		t.Errorf("Recycled data = %q, %v; want the original content", content, err)
+	}
+
+	// Options the Recycle Bin can't record are refused up front
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, opts := range []TrashOptions{{Reason: "cleanup"}, {MaxVersionsPerPath: 1}, {LeavePlaceholder: true}} {
+		if _, err := tr.TrashWithResult(testFile, opts); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Recycle with %+v = %v, want errors.ErrUnsupported", opts, err)
+		}
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Refused file was moved: %v", err)
+	}
+
+	// A batch needs a trashinfo for its ID
+	batch := tr.BeginBatch()
+	batch.Trash(testFile)
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Failed to commit batch: %v", err)
+	}
+	items, err := tr.listTrashDir(tr.homeTrash)
+	if err != nil || len(items) != 1 || items[0].BatchID != batch.ID() {
+		t.Errorf("XDG trash holds %+v, %v; want the batch's file", items, err)
+	}
+}
+
+func TestRecycleRefused(t *testing.T) {
+	tr := newTestTrasher(t)
+	fakeRecycleBin(t, tr)
+	tr.recycleFile = func(path string) (string, error) {
+		return "", errors.New("the Recycle Bin can't take the file")
+	}
+
+	testFile := filepath.Join(t.TempDir(), "huge.iso")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.TrashDir != tr.homeTrash {
+		t.Errorf("Refused file went to %s, want the XDG trash %s", result.TrashDir, tr.homeTrash)
+	}
+}
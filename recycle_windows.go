@@ -0,0 +1,272 @@
+//go:build windows
+// +build windows
+
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	ole32                = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx   = ole32.NewProc("CoInitializeEx")
+	procCoUninitialize   = ole32.NewProc("CoUninitialize")
+	procCoCreateInstance = ole32.NewProc("CoCreateInstance")
+	procCoTaskMemFree    = ole32.NewProc("CoTaskMemFree")
+
+	shell32                         = syscall.NewLazyDLL("shell32.dll")
+	procSHCreateItemFromParsingName = shell32.NewProc("SHCreateItemFromParsingName")
+	procSHEmptyRecycleBinW          = shell32.NewProc("SHEmptyRecycleBinW")
+)
+
+// A guid is a COM class or interface ID.
+type guid struct {
+	data1 uint32
+	data2 uint16
+	data3 uint16
+	data4 [8]byte
+}
+
+var (
+	clsidFileOperation            = guid{0x3ad05575, 0x8857, 0x4850, [8]byte{0x92, 0x77, 0x11, 0xb8, 0x5b, 0xdb, 0x8e, 0x09}}
+	iidIFileOperation             = guid{0x947aab5f, 0x0a5c, 0x4c13, [8]byte{0xb4, 0xd6, 0x4b, 0xf7, 0x83, 0x6f, 0xc9, 0xf8}}
+	iidIShellItem                 = guid{0x43826d1e, 0xe718, 0x42ee, [8]byte{0xbc, 0x55, 0xa1, 0xe2, 0x61, 0xc3, 0x7b, 0xfe}}
+	iidIFileOperationProgressSink = guid{0x04b0f1a7, 0x9490, 0x44bc, [8]byte{0x96, 0xe1, 0x42, 0x96, 0xa3, 0x12, 0x52, 0xe2}}
+	iidIUnknown                   = guid{0x00000000, 0x0000, 0x0000, [8]byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+)
+
+// COM, IFileOperation and SHEmptyRecycleBinW arguments and results.
+const (
+	coinitApartmentThreaded = 0x2
+	coinitDisableOLE1DDE    = 0x4
+	clsctxAll               = 0x17
+
+	fofSilent           = 0x4
+	fofNoConfirmation   = 0x10
+	fofAllowUndo        = 0x40
+	fofNoErrorUI        = 0x400
+	fofxEarlyFailure    = 0x100000
+	fofxRecycleOnDelete = 0x80000
+
+	// tsfDeleteRecycleIfPossible is set in PreDeleteItem's flags when the
+	// item is about to be recycled rather than deleted for good.
+	tsfDeleteRecycleIfPossible = 0x80
+
+	sigdnFilesysPath = 0x80058000
+
+	sherbNoConfirmation = 0x1
+	sherbNoProgressUI   = 0x2
+	sherbNoSound        = 0x4
+
+	sOK             = 0x0
+	eNoInterface    = 0x80004002
+	eAbort          = 0x80004004
+	rpcEChangedMode = 0x80010106
+
+	// eUnexpected is what SHEmptyRecycleBinW returns for a bin that is
+	// already empty.
+	eUnexpected = 0x8000FFFF
+)
+
+// IFileOperation and IShellItem methods, by their index in the vtable.
+const (
+	fileOpRelease           = 2
+	fileOpSetOperationFlags = 5
+	fileOpDeleteItem        = 18
+	fileOpPerformOperations = 21
+	fileOpGetAnyOpsAborted  = 22
+	shellItemRelease        = 2
+	shellItemGetDisplayName = 5
+)
+
+// A comObject is an interface pointer handed out by COM.
+type comObject struct {
+	vtbl *[32]uintptr
+}
+
+func (o *comObject) call(method int, args ...uintptr) uint32 {
+	r, _, _ := syscall.SyscallN(o.vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	return uint32(r)
+}
+
+// A recycleSink is an IFileOperationProgressSink that vetoes deleting an
+// item for good, which IFileOperation falls back to for files too big for
+// the Recycle Bin, and records where a recycled item went.
+type recycleSink struct {
+	vtbl *[19]uintptr
+
+	refused      bool
+	recycledPath string
+}
+
+var (
+	recycleSinkVtbl     [19]uintptr
+	recycleSinkVtblOnce sync.Once
+)
+
+func newRecycleSink() *recycleSink {
+	recycleSinkVtblOnce.Do(func() {
+		// Notifications other than the two below are ignored. 32-bit
+		// callbacks pop their arguments, so each is declared with the
+		// number its method takes.
+		ignore1 := syscall.NewCallback(func(*recycleSink) uintptr { return sOK })
+		ignore2 := syscall.NewCallback(func(*recycleSink, uintptr) uintptr { return sOK })
+		ignore3 := syscall.NewCallback(func(*recycleSink, uintptr, uintptr) uintptr { return sOK })
+		ignore4 := syscall.NewCallback(func(*recycleSink, uintptr, uintptr, uintptr) uintptr { return sOK })
+		ignore5 := syscall.NewCallback(func(*recycleSink, uintptr, uintptr, uintptr, uintptr) uintptr { return sOK })
+		ignore6 := syscall.NewCallback(func(*recycleSink, uintptr, uintptr, uintptr, uintptr, uintptr) uintptr { return sOK })
+		ignore7 := syscall.NewCallback(func(*recycleSink, uintptr, uintptr, uintptr, uintptr, uintptr, uintptr) uintptr { return sOK })
+		ignore8 := syscall.NewCallback(func(*recycleSink, uintptr, uintptr, uintptr, uintptr, uintptr, uintptr, uintptr) uintptr {
+			return sOK
+		})
+		refCount := syscall.NewCallback(func(*recycleSink) uintptr { return 1 })
+
+		recycleSinkVtbl = [19]uintptr{
+			syscall.NewCallback(func(s *recycleSink, riid *guid, ppv **recycleSink) uintptr {
+				if *riid == iidIUnknown || *riid == iidIFileOperationProgressSink {
+					*ppv = s
+					return sOK
+				}
+				*ppv = nil
+				return eNoInterface
+			}),
+			refCount, // AddRef
+			refCount, // Release
+			ignore1,  // StartOperations
+			ignore2,  // FinishOperations
+			ignore4,  // PreRenameItem
+			ignore6,  // PostRenameItem
+			ignore5,  // PreMoveItem
+			ignore7,  // PostMoveItem
+			ignore5,  // PreCopyItem
+			ignore7,  // PostCopyItem
+			syscall.NewCallback(func(s *recycleSink, flags uintptr, item *comObject) uintptr {
+				if flags&tsfDeleteRecycleIfPossible == 0 {
+					s.refused = true
+					return eAbort
+				}
+				return sOK
+			}),
+			syscall.NewCallback(func(s *recycleSink, flags uintptr, item *comObject, hr uintptr, created *comObject) uintptr {
+				if uint32(hr) == sOK && created != nil {
+					s.recycledPath, _ = shellItemPath(created)
+				}
+				return sOK
+			}),
+			ignore4, // PreNewItem
+			ignore8, // PostNewItem
+			ignore3, // UpdateProgress
+			ignore1, // ResetTimer
+			ignore1, // PauseTimer
+			ignore1, // ResumeTimer
+		}
+	})
+	return &recycleSink{vtbl: &recycleSinkVtbl}
+}
+
+// shellItemPath returns the filesystem path of a shell item.
+func shellItemPath(item *comObject) (string, error) {
+	var name *uint16
+	if hr := item.call(shellItemGetDisplayName, sigdnFilesysPath, uintptr(unsafe.Pointer(&name))); hr != sOK {
+		return "", fmt.Errorf("IShellItem.GetDisplayName failed with HRESULT %#x", hr)
+	}
+	defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(name)))
+
+	n := 0
+	for p := unsafe.Pointer(name); *(*uint16)(p) != 0; p = unsafe.Add(p, 2) {
+		n++
+	}
+	return syscall.UTF16ToString(unsafe.Slice(name, n)), nil
+}
+
+// recycleBinAvailable reports whether files can be moved to the Recycle
+// Bin.
+func recycleBinAvailable() bool {
+	return procCoCreateInstance.Find() == nil && procSHCreateItemFromParsingName.Find() == nil
+}
+
+// errRecycleRefused is returned for a file the Recycle Bin can't take, which
+// is left where it was.
+var errRecycleRefused = errors.New("the Recycle Bin can't take the file")
+
+// recycleFile moves path to the Recycle Bin of its volume, as Explorer's
+// Delete does, without any dialogs, and returns its path in the Recycle
+// Bin, or "" if Windows didn't say. A file that Windows would delete for
+// good instead, because it is too big for the Recycle Bin or its volume
+// has none, is left in place and errRecycleRefused returned.
+func recycleFile(path string) (string, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+
+	// COM objects belong to the thread that initialized COM
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	hr, _, _ := procCoInitializeEx.Call(0, coinitApartmentThreaded|coinitDisableOLE1DDE)
+	if uint32(hr) != rpcEChangedMode {
+		if int32(hr) < 0 {
+			return "", fmt.Errorf("CoInitializeEx failed with HRESULT %#x", uint32(hr))
+		}
+		defer procCoUninitialize.Call()
+	}
+
+	var op *comObject
+	hr, _, _ = procCoCreateInstance.Call(uintptr(unsafe.Pointer(&clsidFileOperation)), 0, clsctxAll,
+		uintptr(unsafe.Pointer(&iidIFileOperation)), uintptr(unsafe.Pointer(&op)))
+	if uint32(hr) != sOK {
+		return "", fmt.Errorf("failed to create IFileOperation: HRESULT %#x", uint32(hr))
+	}
+	defer op.call(fileOpRelease)
+
+	var item *comObject
+	hr, _, _ = procSHCreateItemFromParsingName.Call(uintptr(unsafe.Pointer(p)), 0,
+		uintptr(unsafe.Pointer(&iidIShellItem)), uintptr(unsafe.Pointer(&item)))
+	if uint32(hr) != sOK {
+		return "", fmt.Errorf("SHCreateItemFromParsingName failed with HRESULT %#x", uint32(hr))
+	}
+	defer item.call(shellItemRelease)
+
+	flags := uintptr(fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI | fofxEarlyFailure | fofxRecycleOnDelete)
+	if hr := op.call(fileOpSetOperationFlags, flags); hr != sOK {
+		return "", fmt.Errorf("IFileOperation.SetOperationFlags failed with HRESULT %#x", hr)
+	}
+
+	sink := newRecycleSink()
+	if hr := op.call(fileOpDeleteItem, uintptr(unsafe.Pointer(item)), uintptr(unsafe.Pointer(sink))); hr != sOK {
+		return "", fmt.Errorf("IFileOperation.DeleteItem failed with HRESULT %#x", hr)
+	}
+	hr = uintptr(op.call(fileOpPerformOperations))
+	runtime.KeepAlive(sink)
+	if sink.refused {
+		return "", errRecycleRefused
+	}
+	if uint32(hr) != sOK {
+		return "", fmt.Errorf("IFileOperation.PerformOperations failed with HRESULT %#x", uint32(hr))
+	}
+	var aborted int32
+	if hr := op.call(fileOpGetAnyOpsAborted, uintptr(unsafe.Pointer(&aborted))); hr == sOK && aborted != 0 {
+		return "", fmt.Errorf("IFileOperation was aborted")
+	}
+	return sink.recycledPath, nil
+}
+
+// emptyRecycleBinOf permanently removes everything in the Recycle Bin of
+// the volume whose root is root.
+func emptyRecycleBinOf(root string) error {
+	p, err := syscall.UTF16PtrFromString(root)
+	if err != nil {
+		return err
+	}
+	r, _, _ := procSHEmptyRecycleBinW.Call(0, uintptr(unsafe.Pointer(p)),
+		sherbNoConfirmation|sherbNoProgressUI|sherbNoSound)
+	if hr := uint32(r); hr != 0 && hr != eUnexpected {
+		return fmt.Errorf("SHEmptyRecycleBin failed with HRESULT %#x", hr)
+	}
+	return nil
+}
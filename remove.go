@@ -0,0 +1,84 @@
+package trash
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Remove permanently deletes path and everything under it, like
+// os.RemoveAll, behind the same checks as Trash: it refuses the root of a
+// filesystem, any trash directory or anything in one, and any directory
+// holding the home trash, such as $HOME. It gives callers that switch
+// between trashing and deleting a single vetted entry point. Unlike
+// os.RemoveAll, a path that doesn't exist is an error.
+func (tr *Trasher) Remove(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if err := tr.checkProtected(absPath); err != nil {
+		return err
+	}
+
+	if _, err := tr.fs.Lstat(absPath); err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	if err := tr.fs.RemoveAll(absPath); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	tr.emit(EventRemove, TrashItem{
+		Name:         filepath.Base(absPath),
+		OriginalPath: absPath,
+		DeletionDate: time.Now(),
+	})
+	return nil
+}
+
+// checkProtected returns ErrProtectedPath if absPath must never be trashed
+// or removed: a filesystem root, a trash directory or anything in one, or
+// an ancestor of the home trash. Paths are compared both as given and with
+// symlinks in their parent resolved.
+func (tr *Trasher) checkProtected(absPath string) error {
+	paths := []string{absPath}
+	if parent, err := tr.evalSymlinks(filepath.Dir(absPath)); err == nil {
+		paths = append(paths, filepath.Join(parent, filepath.Base(absPath)))
+	}
+
+	var trashDirs []string
+	if mount, err := tr.mountPoint(absPath); err == nil && mount != "" {
+		trashDirs = append(trashDirs,
+			filepath.Join(mount, ".Trash"),
+			filepath.Join(mount, ".Trash-"+tr.uid))
+	}
+
+	homeTrash := []string{tr.homeTrash}
+	if resolved, err := tr.evalSymlinks(tr.homeTrash); err == nil && resolved != tr.homeTrash {
+		homeTrash = append(homeTrash, resolved)
+	}
+
+	for _, path := range paths {
+		if filepath.Dir(path) == path {
+			return fmt.Errorf("%w: %s is a filesystem root", ErrProtectedPath, absPath)
+		}
+		for _, dir := range homeTrash {
+			if within(path, dir) || within(dir, path) {
+				return fmt.Errorf("%w: %s holds or is inside the trash", ErrProtectedPath, absPath)
+			}
+		}
+		for _, dir := range trashDirs {
+			if within(path, dir) {
+				return fmt.Errorf("%w: %s is inside a trash directory", ErrProtectedPath, absPath)
+			}
+		}
+	}
+	return nil
+}
+
+// within reports whether path is dir or lies below it.
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -0,0 +1,87 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemove(t *testing.T) {
+	home := t.TempDir()
+	tr := newTestTrasherAt(t, home)
+
+	testDir := filepath.Join(t.TempDir(), "scratch")
+	if err := os.MkdirAll(filepath.Join(testDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(testDir, "sub", "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.Remove(testDir); err != nil {
+		t.Fatalf("Failed to remove directory: %v", err)
+	}
+	if _, err := os.Lstat(testDir); !os.IsNotExist(err) {
+		t.Error("Directory still exists after Remove")
+	}
+	if items, _ := tr.List(); len(items) != 0 {
+		t.Errorf("Remove put %d items in the trash, want none", len(items))
+	}
+
+	if err := tr.Remove(testDir); err == nil {
+		t.Error("Expected an error removing a missing path")
+	}
+}
+
+func TestRemoveProtectedPaths(t *testing.T) {
+	home := t.TempDir()
+	tr := newTestTrasherAt(t, home)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	// Put something in both trashes so they exist
+	for _, dir := range []string{t.TempDir(), mount} {
+		path := filepath.Join(dir, "trashed.txt")
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	mountTrash := filepath.Join(mount, ".Trash-"+tr.uid)
+	link := filepath.Join(t.TempDir(), "trash-link")
+	if err := os.Symlink(tr.homeTrash, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	for _, path := range []string{
+		string(filepath.Separator),
+		home,
+		filepath.Dir(tr.homeTrash),
+		tr.homeTrash,
+		filepath.Join(tr.homeTrash, "files"),
+		filepath.Join(tr.homeTrash, "info", "trashed.txt.trashinfo"),
+		filepath.Join(tr.homeTrash, "files", "..", "files", "trashed.txt"),
+		filepath.Join(link, "files"),
+		mountTrash,
+		filepath.Join(mountTrash, "files", "trashed.txt"),
+	} {
+		if err := tr.Remove(path); !errors.Is(err, ErrProtectedPath) {
+			t.Errorf("Remove(%s) = %v, want ErrProtectedPath", path, err)
+		}
+		if err := tr.Trash(path); !errors.Is(err, ErrProtectedPath) {
+			t.Errorf("Trash(%s) = %v, want ErrProtectedPath", path, err)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("Expected both trashed items to survive, got %d", len(items))
+	}
+}
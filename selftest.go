@@ -0,0 +1,129 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// selfTestContent is written to the probe files of SelfTest.
+const selfTestContent = "trash self-test\n"
+
+// SelfTest trashes, lists, restores, trashes again and deletes a tiny
+// probe file in the home trash and in the trash directory of every mounted
+// filesystem that has one, so an application can fail at startup rather
+// than on the first real deletion if, say, an info directory is read-only.
+// The probe is created beside the trash directory, in the home trash's
+// parent or at the mount point, and its name contains a space and
+// non-ASCII characters to exercise the path encoding. A mount point the
+// user can't write to is skipped, as nothing could be trashed from there
+// anyway. The probe is removed again whatever the outcome; user data is
+// never touched. Subscribers see the probe's events like any other. Only
+// the XDG trash is tested; a Trasher that trashes to the Recycle Bin or
+// to the macOS Trash still sends the probes to the XDG trash.
+//
+// The errors of all trash directories are joined.
+func (tr *Trasher) SelfTest() error {
+	if err := tr.ensureTrashDirs(tr.homeTrash); err != nil {
+		return fmt.Errorf("self-test of %s failed: %w", tr.homeTrash, err)
+	}
+
+	var errs []error
+	for _, trashDir := range append([]string{tr.homeTrash}, tr.mountTrashDirs()...) {
+		// Windows keeps the Recycle Bin, which can't be told to take a
+		// file in a given folder
+		if tr.isRecycleBinDir(trashDir) {
+			continue
+		}
+		if err := tr.selfTestDir(trashDir); err != nil {
+			errs = append(errs, fmt.Errorf("self-test of %s failed: %w", trashDir, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (tr *Trasher) selfTestDir(trashDir string) (err error) {
+	dir := filepath.Dir(trashDir)
+	if trashDir != tr.homeTrash {
+		dir = tr.trashDirMount(trashDir)
+	}
+	probe := filepath.Join(dir, ".trash self-test é "+randomSuffix())
+	if err := tr.writeFile(probe, []byte(selfTestContent), 0600); err != nil {
+		if os.IsPermission(err) && trashDir != tr.homeTrash {
+			return nil
+		}
+		return fmt.Errorf("failed to create probe file: %w", err)
+	}
+
+	// Whatever fails, leave neither the probe nor its trashed copy behind
+	var trashed *TrashItem
+	defer func() {
+		if trashed != nil {
+			tr.deleteItem(*trashed)
+		}
+		tr.fs.Remove(probe)
+	}()
+
+	trash := func() (TrashItem, error) {
+		result, err := tr.trashPath(context.Background(), probe, TrashOptions{xdgOnly: true})
+		if err != nil {
+			return TrashItem{}, fmt.Errorf("failed to trash probe: %w", err)
+		}
+		trashed = &result.TrashItem
+		if result.TrashDir != trashDir {
+			return TrashItem{}, fmt.Errorf("probe was trashed to %s", result.TrashDir)
+		}
+		return tr.findProbe(trashDir, result.InfoPath, probe)
+	}
+
+	item, err := trash()
+	if err != nil {
+		return err
+	}
+
+	if err := tr.restoreItem(item, RestoreOptions{}); err != nil {
+		return fmt.Errorf("failed to restore probe: %w", err)
+	}
+	trashed = nil
+	data, err := tr.readFile(probe)
+	if err != nil {
+		return fmt.Errorf("failed to read restored probe: %w", err)
+	}
+	if string(data) != selfTestContent {
+		return fmt.Errorf("restored probe has content %q", data)
+	}
+
+	if item, err = trash(); err != nil {
+		return err
+	}
+	if err := tr.deleteItem(item); err != nil {
+		return fmt.Errorf("failed to delete probe: %w", err)
+	}
+	trashed = nil
+
+	if _, err := tr.fs.Lstat(item.FilePath); !os.IsNotExist(err) {
+		return fmt.Errorf("deleted probe is still in the trash")
+	}
+	return nil
+}
+
+// findProbe returns the item infoPath describes as listed from trashDir,
+// checking that its original path reads back as probe.
+func (tr *Trasher) findProbe(trashDir, infoPath, probe string) (TrashItem, error) {
+	items, err := tr.listTrashDir(trashDir)
+	if err != nil {
+		return TrashItem{}, fmt.Errorf("failed to list trash: %w", err)
+	}
+	for _, item := range items {
+		if item.InfoPath != infoPath {
+			continue
+		}
+		if item.OriginalPath != probe {
+			return TrashItem{}, fmt.Errorf("probe %q listed as %q", probe, item.OriginalPath)
+		}
+		return item, nil
+	}
+	return TrashItem{}, fmt.Errorf("probe not listed")
+}
@@ -0,0 +1,103 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+	if err := os.MkdirAll(filepath.Join(mount, ".Trash-"+tr.uid), 0700); err != nil {
+		t.Fatalf("Failed to create mount trash: %v", err)
+	}
+
+	if err := tr.SelfTest(); err != nil {
+		t.Fatalf("SelfTest of a healthy trash failed: %v", err)
+	}
+	assertNoProbes(t, tr, filepath.Dir(tr.homeTrash), mount)
+
+	// Break the mount's trash: info/ can't be created
+	info := filepath.Join(mount, ".Trash-"+tr.uid, "info")
+	if err := os.RemoveAll(info); err != nil {
+		t.Fatalf("Failed to remove info directory: %v", err)
+	}
+	if err := os.WriteFile(info, nil, 0600); err != nil {
+		t.Fatalf("Failed to break mount trash: %v", err)
+	}
+	err := tr.SelfTest()
+	if err == nil {
+		t.Fatal("SelfTest of a broken trash succeeded")
+	}
+	if !strings.Contains(err.Error(), filepath.Join(mount, ".Trash-"+tr.uid)) {
+		t.Errorf("Error %q doesn't name the broken trash", err)
+	}
+	assertNoProbes(t, tr, filepath.Dir(tr.homeTrash), mount)
+}
+
+func TestSelfTestSharedMountTrash(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	shared := filepath.Join(mount, ".Trash")
+	if err := os.Mkdir(shared, 0777); err != nil {
+		t.Fatalf("Failed to create shared trash: %v", err)
+	}
+	if err := os.Chmod(shared, 0777|os.ModeSticky); err != nil {
+		t.Fatalf("Failed to set sticky bit: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(shared, tr.uid), 0700); err != nil {
+		t.Fatalf("Failed to create mount trash: %v", err)
+	}
+
+	// The probe goes at the mount point, not into the shared .Trash
+	if err := tr.SelfTest(); err != nil {
+		t.Fatalf("SelfTest of a shared mount trash failed: %v", err)
+	}
+	assertNoProbes(t, tr, filepath.Dir(tr.homeTrash), mount, shared)
+}
+
+func TestSelfTestRecycleBin(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+	tr.recycleBin = true
+	tr.recycleFile = func(path string) (string, error) {
+		t.Errorf("SelfTest recycled %s", path)
+		return "", errors.New("no Recycle Bin")
+	}
+	bin := filepath.Join(mount, recycleBinName, tr.uid)
+	if err := os.MkdirAll(bin, 0700); err != nil {
+		t.Fatalf("Failed to create Recycle Bin: %v", err)
+	}
+
+	if err := tr.SelfTest(); err != nil {
+		t.Fatalf("SelfTest of a Recycle Bin Trasher failed: %v", err)
+	}
+	assertNoProbes(t, tr, filepath.Dir(tr.homeTrash), mount)
+	if entries, err := os.ReadDir(bin); err != nil || len(entries) != 0 {
+		t.Errorf("SelfTest touched the Recycle Bin: %v, %v", entries, err)
+	}
+}
+
+func assertNoProbes(t *testing.T, tr *Trasher, dirs ...string) {
+	t.Helper()
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("Trash not empty after SelfTest: %+v", items)
+	}
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, ".trash self-test*"))
+		if len(matches) != 0 {
+			t.Errorf("Probe files left behind: %v", matches)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package trash
+
+import (
+	"strings"
+	"syscall"
+)
+
+const selinuxXattr = "security.selinux"
+
+// getSELinuxContext returns the SELinux label of path, or "" if it has
+// none.
+func getSELinuxContext(path string) (string, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Getxattr(path, selinuxXattr, buf)
+		if err == syscall.ERANGE {
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		if err == syscall.ENODATA || err == syscall.ENOTSUP {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		// The kernel stores the label with its terminating NUL
+		return strings.TrimRight(string(buf[:n]), "\x00"), nil
+	}
+}
+
+// setSELinuxContext labels path with label.
+func setSELinuxContext(path, label string) error {
+	return syscall.Setxattr(path, selinuxXattr, append([]byte(label), 0), 0)
+}
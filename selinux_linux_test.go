@@ -0,0 +1,88 @@
+package trash
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreserveSELinux(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "service.conf")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	const label = "system_u:object_r:httpd_config_t:s0"
+	if err := setSELinuxContext(testFile, label); err != nil {
+		t.Skipf("Can't set SELinux labels here: %v", err)
+	}
+
+	if err := tr.TrashWithOptions(testFile, TrashOptions{PreserveSELinux: true}); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	if items[0].SELinuxContext != label {
+		t.Errorf("SELinuxContext = %q, want %q", items[0].SELinuxContext, label)
+	}
+
+	// Relabel the trashed file, as a copy across devices or a relabelling
+	// of the trash would
+	if err := setSELinuxContext(items[0].FilePath, "unconfined_u:object_r:user_home_t:s0"); err != nil {
+		t.Fatalf("Failed to relabel trashed file: %v", err)
+	}
+
+	if err := tr.Restore(items[0].Name); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	got, err := getSELinuxContext(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read SELinux label: %v", err)
+	}
+	if got != label {
+		t.Errorf("Restored label = %q, want %q", got, label)
+	}
+}
+
+func TestRestoreSELinuxFailure(t *testing.T) {
+	var buf bytes.Buffer
+	tr := newTestTrasher(t)
+	tr.logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	// Labelling follows the link to a target that doesn't exist, so it
+	// fails whether or not the system has SELinux
+	link := filepath.Join(t.TempDir(), "dangling")
+	if err := os.Symlink("missing", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	result, err := tr.TrashWithResult(link, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash symlink: %v", err)
+	}
+
+	item := result.TrashItem
+	item.SELinuxContext = "system_u:object_r:httpd_config_t:s0"
+	if err := tr.restoreItem(item, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore with an unsettable label = %v, want success", err)
+	}
+	if target, err := os.Readlink(link); err != nil || target != "missing" {
+		t.Errorf("Restored link = %q, %v; want the original target", target, err)
+	}
+	if _, err := os.Lstat(item.InfoPath); !os.IsNotExist(err) {
+		t.Error("Trashinfo still exists after restore")
+	}
+	if !strings.Contains(buf.String(), "failed to restore SELinux context") {
+		t.Errorf("Logger got %q, want a warning about the label", buf.String())
+	}
+}
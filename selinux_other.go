@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package trash
+
+import "errors"
+
+// getSELinuxContext returns "", as SELinux exists only on Linux.
+func getSELinuxContext(path string) (string, error) {
+	return "", nil
+}
+
+// setSELinuxContext fails, as a label recorded on Linux can't be applied
+// elsewhere.
+func setSELinuxContext(path, label string) error {
+	return errors.New("SELinux contexts are not supported on this platform")
+}
@@ -0,0 +1,57 @@
+package trash
+
+import (
+	"io"
+	"os"
+)
+
+// seekTruncater is implemented by files that support sparse copies, such
+// as *os.File.
+type seekTruncater interface {
+	io.Seeker
+	Truncate(size int64) error
+}
+
+// copySparse copies src to dst like copy, but seeks over the holes of a
+// sparse src so they stay holes in dst. It reports false, having written
+// nothing, if src isn't sparse or the files or platform can't tell where
+// the holes are; the caller should then copy normally.
+func (job *copyJob) copySparse(dst, src File, info os.FileInfo) (bool, error) {
+	if !isSparse(info) {
+		return false, nil
+	}
+
+	s, ok := src.(seekTruncater)
+	if !ok {
+		return false, nil
+	}
+	d, ok := dst.(seekTruncater)
+	if !ok {
+		return false, nil
+	}
+
+	size := info.Size()
+	start, end, err := nextData(s, 0)
+	if err == errNoSparseSupport {
+		return false, nil
+	}
+
+	for err == nil && start < size {
+		if _, err := s.Seek(start, io.SeekStart); err != nil {
+			return true, err
+		}
+		if _, err := d.Seek(start, io.SeekStart); err != nil {
+			return true, err
+		}
+		if _, err := job.copy(dst, io.LimitReader(src, end-start)); err != nil {
+			return true, err
+		}
+		start, end, err = nextData(s, end)
+	}
+	if err != nil && err != io.EOF {
+		return true, err
+	}
+
+	// A trailing hole has no data to write, so extend dst to the full size
+	return true, d.Truncate(size)
+}
@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package trash
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// lseek(2) whence values for finding data and holes.
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+var errNoSparseSupport = errors.New("sparse files not supported")
+
+// isSparse reports whether fewer blocks are allocated to the file described
+// by info than its size needs.
+func isSparse(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && st.Blocks*512 < st.Size
+}
+
+// nextData returns the bounds of the first run of data in f at or after off,
+// or io.EOF if there is none.
+func nextData(f io.Seeker, off int64) (start, end int64, err error) {
+	start, err = f.Seek(off, seekData)
+	if errors.Is(err, syscall.ENXIO) {
+		return 0, 0, io.EOF
+	}
+	if errors.Is(err, syscall.EINVAL) && off == 0 {
+		return 0, 0, errNoSparseSupport
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err = f.Seek(start, seekHole)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
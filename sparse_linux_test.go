@@ -0,0 +1,67 @@
+package trash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestTrashSparseFile(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	const size = 64 << 20
+	testFile := filepath.Join(t.TempDir(), "disk.img")
+	f, err := os.Create(testFile)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	data := bytes.Repeat([]byte("x"), 4096)
+	if _, err := f.WriteAt(data, size/2); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := f.Truncate(size); err != nil {
+		t.Fatalf("Failed to extend test file: %v", err)
+	}
+	f.Close()
+
+	if blocks := allocatedBytes(t, testFile); blocks >= size/2 {
+		t.Skipf("Filesystem doesn't create sparse files (%d bytes allocated)", blocks)
+	}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	info, err := os.Stat(result.FilePath)
+	if err != nil {
+		t.Fatalf("Failed to stat trashed file: %v", err)
+	}
+	if info.Size() != size {
+		t.Errorf("Trashed file size = %d, want %d", info.Size(), size)
+	}
+	if blocks := allocatedBytes(t, result.FilePath); blocks >= size/2 {
+		t.Errorf("Trashed copy has %d bytes allocated, want it to stay sparse", blocks)
+	}
+
+	copied, err := os.ReadFile(result.FilePath)
+	if err != nil {
+		t.Fatalf("Failed to read trashed file: %v", err)
+	}
+	if !bytes.Equal(copied[size/2:size/2+len(data)], data) || copied[0] != 0 || copied[size-1] != 0 {
+		t.Error("Trashed copy doesn't match the original content")
+	}
+}
+
+func allocatedBytes(t *testing.T, path string) int64 {
+	t.Helper()
+
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		t.Fatalf("Failed to stat %s: %v", path, err)
+	}
+	return st.Blocks * 512
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package trash
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var errNoSparseSupport = errors.New("sparse files not supported")
+
+// isSparse always reports false, since holes can't be found portably here.
+func isSparse(info os.FileInfo) bool {
+	return false
+}
+
+func nextData(f io.Seeker, off int64) (start, end int64, err error) {
+	return 0, 0, errNoSparseSupport
+}
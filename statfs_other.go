@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package trash
+
+import "errors"
+
+// diskSpace is not implemented on this platform.
+func diskSpace(path string) (total, free uint64, err error) {
+	return 0, 0, errors.New("disk space reporting not supported")
+}
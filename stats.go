@@ -0,0 +1,119 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TrashStats summarizes the contents of every trash directory.
+type TrashStats struct {
+	Items int
+	Size  int64
+	Dirs  []DirStats
+}
+
+// DirStats describes one trash directory and the filesystem it lives on.
+type DirStats struct {
+	TrashDir   string
+	MountPoint string
+	Items      int
+	Size       int64
+
+	// Capacity of the filesystem holding TrashDir, in bytes. FSFree counts
+	// only space available to unprivileged users. All three are zero if
+	// the platform can't report them.
+	FSTotal uint64
+	FSFree  uint64
+	FSUsed  uint64
+}
+
+// Stats reports the number and total size of trashed items, broken down by
+// trash directory along with each directory's filesystem capacity.
+func (tr *Trasher) Stats() (TrashStats, error) {
+	var stats TrashStats
+
+	dirs := append([]string{tr.homeTrash}, tr.mountTrashDirs()...)
+	for _, trashDir := range dirs {
+		if _, err := tr.fs.Stat(trashDir); os.IsNotExist(err) {
+			continue
+		}
+
+		ds, err := tr.dirStats(trashDir)
+		if err != nil {
+			return TrashStats{}, err
+		}
+
+		stats.Items += ds.Items
+		stats.Size += ds.Size
+		stats.Dirs = append(stats.Dirs, ds)
+	}
+
+	return stats, nil
+}
+
+func (tr *Trasher) dirStats(trashDir string) (DirStats, error) {
+	items, err := tr.listTrashDir(trashDir)
+	if err != nil {
+		return DirStats{}, err
+	}
+
+	ds := DirStats{TrashDir: trashDir, Items: len(items)}
+	for _, item := range items {
+		size, err := tr.diskUsage(item.FilePath)
+		if err != nil && !os.IsNotExist(err) {
+			return DirStats{}, fmt.Errorf("failed to size %s: %w", item.Name, err)
+		}
+		ds.Size += size
+	}
+
+	if mount, err := tr.mountPoint(trashDir); err == nil {
+		ds.MountPoint = mount
+	}
+
+	if total, free, err := diskSpace(trashDir); err == nil {
+		ds.FSTotal = total
+		ds.FSFree = free
+		ds.FSUsed = total - free
+	}
+
+	return ds, nil
+}
+
+// diskUsage returns the apparent size of path, summed over its contents if
+// it is a directory. Symlinks are not followed.
+func (tr *Trasher) diskUsage(path string) (int64, error) {
+	return tr.treeUsage(path, ancestors{})
+}
+
+func (tr *Trasher) treeUsage(path string, ancestors ancestors) (int64, error) {
+	info, err := tr.fs.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if !info.IsDir() {
+		return info.Size(), nil
+	}
+	leave, err := ancestors.enter(path, info)
+	if err != nil {
+		return 0, err
+	}
+	defer leave()
+
+	entries, err := tr.fs.ReadDir(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		size, err := tr.treeUsage(filepath.Join(path, entry.Name()), ancestors)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+
+	return total, nil
+}
@@ -0,0 +1,70 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(testFile, make([]byte, 1000), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	testDir := filepath.Join(dir, "dir")
+	if err := os.MkdirAll(filepath.Join(testDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(testDir, "sub", "nested.txt"), make([]byte, 500), 0644); err != nil {
+		t.Fatalf("Failed to create nested file: %v", err)
+	}
+
+	for _, path := range []string{testFile, testDir} {
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash %s: %v", path, err)
+		}
+	}
+
+	stats, err := tr.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+
+	if stats.Items != 2 {
+		t.Errorf("Items = %d, want 2", stats.Items)
+	}
+
+	var home *DirStats
+	for i := range stats.Dirs {
+		if stats.Dirs[i].TrashDir == tr.homeTrash {
+			home = &stats.Dirs[i]
+		}
+	}
+	if home == nil {
+		t.Fatalf("Home trash missing from per-directory stats: %+v", stats.Dirs)
+	}
+
+	if home.Items != 2 {
+		t.Errorf("Home trash items = %d, want 2", home.Items)
+	}
+	if home.Size < 1500 {
+		t.Errorf("Home trash size = %d, want at least 1500", home.Size)
+	}
+	if home.MountPoint == "" {
+		t.Error("Home trash mount point not reported")
+	}
+
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		if home.FSTotal == 0 || home.FSFree > home.FSTotal {
+			t.Errorf("Implausible filesystem capacity: total %d, free %d", home.FSTotal, home.FSFree)
+		}
+		if home.FSUsed != home.FSTotal-home.FSFree {
+			t.Errorf("FSUsed = %d, want %d", home.FSUsed, home.FSTotal-home.FSFree)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package trash
+
+import "path/filepath"
+
+// ListSuspicious returns the trashed items whose recorded original path is
+// inconsistent with the trash holding them: items in a mount's trash that
+// would restore outside that mount, and items in the home trash with a
+// relative path, as only mount trashes use. Such entries may have been
+// tampered with or written by a buggy implementation, and restoring them
+// could write somewhere unexpected.
+func (tr *Trasher) ListSuspicious() ([]TrashItem, error) {
+	var suspicious []TrashItem
+
+	homeItems, err := tr.listTrashDir(tr.homeTrash)
+	if err == nil {
+		for _, item := range homeItems {
+			if !filepath.IsAbs(item.OriginalPath) {
+				suspicious = append(suspicious, item)
+			}
+		}
+	}
+
+	for _, trashDir := range tr.mountTrashDirs() {
+		mountItems, err := tr.listTrashDir(trashDir)
+		if err != nil {
+			continue
+		}
+
+		mount := tr.trashDirMount(trashDir)
+		for _, item := range mountItems {
+			if !withinMount(item.OriginalPath, mount) {
+				suspicious = append(suspicious, item)
+			}
+		}
+	}
+
+	return suspicious, nil
+}
+
+// withinMount reports whether path, resolved against mount if relative,
+// lies under mount.
+func withinMount(path, mount string) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(mount, path)
+	}
+
+	rel, err := filepath.Rel(mount, filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !filepath.IsAbs(rel) && !hasDotDotPrefix(rel)
+}
+
+func hasDotDotPrefix(rel string) bool {
+	return len(rel) >= 3 && rel[:2] == ".." && rel[2] == filepath.Separator
+}
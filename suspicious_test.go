@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListSuspicious(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	mountFile := filepath.Join(mount, "inside.txt")
+	homeFile := filepath.Join(t.TempDir(), "home.txt")
+	for _, path := range []string{mountFile, homeFile} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	relocatable := filepath.Join(t.TempDir(), "relocatable.txt")
+	if err := os.WriteFile(relocatable, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.TrashWithOptions(relocatable, TrashOptions{OriginalPath: "relocatable.txt"}); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	// Seed entries pointing out of the mount, absolutely and relatively
+	mountTrash := filepath.Join(mount, ".Trash-"+tr.uid)
+	outside := map[string]string{
+		"escape.txt":   filepath.Join(t.TempDir(), "escape.txt"),
+		"dotdot.txt":   filepath.Join("..", "dotdot.txt"),
+		"relative.txt": "relative.txt",
+	}
+	for name, original := range outside {
+		item := TrashItem{
+			InfoPath:     tr.layout.InfoPath(mountTrash, name),
+			OriginalPath: original,
+			DeletionDate: time.Now(),
+		}
+		if err := tr.writeTrashInfo(item); err != nil {
+			t.Fatalf("Failed to seed trash info: %v", err)
+		}
+	}
+
+	items, err := tr.ListSuspicious()
+	if err != nil {
+		t.Fatalf("Failed to list suspicious items: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, item := range items {
+		got[item.Name] = true
+	}
+	if len(items) != 3 || !got["escape.txt"] || !got["dotdot.txt"] || !got["relocatable.txt"] {
+		t.Errorf("Expected escape.txt, dotdot.txt and relocatable.txt to be suspicious, got %v", items)
+	}
+}
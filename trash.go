@@ -1,15 +1,17 @@
 package trash
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
-	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -23,6 +25,20 @@ var (
 	ErrAlreadyExists     = errors.New("file already exists at destination")
 	ErrCrossDevice       = errors.New("cannot move across devices")
 	ErrNoTrashAvailable  = errors.New("no trash directory available")
+	ErrSymlinkedParent   = errors.New("restore destination's parent directory is a symlink")
+	ErrInvalidDate       = errors.New("deletion date is in the future")
+	ErrMaxDepthExceeded  = errors.New("directory nesting exceeds maximum depth")
+	ErrSplitTrash        = errors.New("trash directory spans multiple filesystems")
+	ErrDeviceChanged     = errors.New("file moved to another device during trash")
+	ErrSelfCheckFailed   = errors.New("trash info does not read back as written")
+	ErrSkippedEmpty      = errors.New("empty file or directory not trashed")
+	ErrInsufficientSpace = errors.New("not enough space left on destination device")
+	ErrTrashDisabled     = errors.New("trashing is disabled")
+	ErrProtectedPath     = errors.New("refusing to remove protected path")
+	ErrIsMountPoint      = errors.New("refusing to trash a mount point")
+	ErrDirectoryCycle    = errors.New("directory contains itself")
+	ErrAmbiguousPath     = errors.New("several trashed items match the original path")
+	ErrPlaceholderFailed = errors.New("trashed, but failed to leave a placeholder")
 )
 
 type TrashItem struct {
@@ -32,543 +48,2531 @@ type TrashItem struct {
 	InfoPath     string
 	FilePath     string
 	TrashDir     string
+
+	// BatchID is the ID of the Batch the item was trashed in, if any.
+	BatchID string
+
+	// Placeholder is set if the item was trashed with
+	// TrashOptions.LeavePlaceholder.
+	Placeholder bool
+
+	// MimeType is the content type detected when the item was trashed with
+	// TrashOptions.DetectMimeType, if any.
+	MimeType string
+
+	// SELinuxContext is the security.selinux label the file had when it
+	// was trashed with TrashOptions.PreserveSELinux, if any.
+	SELinuxContext string
+
+	// Reason is why the item was trashed, as given in TrashOptions.Reason.
+	Reason string
+
+	// DeviceUUID and DeviceLabel identify the filesystem of the mount trash
+	// the item was trashed to, as blkid reported them then, if known. See
+	// ListKnownDevices.
+	DeviceUUID  string
+	DeviceLabel string
+
+	// Size is the apparent size of the item's data in bytes, summed over
+	// its contents for a directory. It is only filled in by
+	// ListWithOptions with ComputeSizes set; see also SizeOf.
+	Size int64
 }
 
-var (
-	homeTrash string
-	uid       string
-	initOnce  sync.Once
-	initErr   error
+func (tr *Trasher) ensureTrashDirs(trashDir string) error {
+	dirs := tr.layout.Dirs(trashDir)
+
+	for _, dir := range dirs {
+		if err := tr.fs.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create trash directory %s: %w", dir, err)
+		}
+	}
+
+	return tr.checkSameFilesystem(trashDir, dirs)
+}
+
+// checkSameFilesystem returns ErrSplitTrash if any of dirs (typically files/
+// and info/, which may be symlinks) lives on a different filesystem than
+// trashDir. Otherwise data could be renamed into files/ while its info is
+// written elsewhere. Platforms without device numbers skip the check.
+func (tr *Trasher) checkSameFilesystem(trashDir string, dirs []string) error {
+	rootInfo, err := tr.fs.Stat(trashDir)
+	if err != nil {
+		return fmt.Errorf("failed to stat trash directory: %w", err)
+	}
+
+	rootDev, ok := deviceID(rootInfo)
+	if !ok {
+		return nil
+	}
+
+	for _, dir := range dirs {
+		info, err := tr.fs.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("failed to stat trash directory: %w", err)
+		}
+
+		if dev, ok := deviceID(info); ok && dev != rootDev {
+			return fmt.Errorf("%w: %s is not on the same filesystem as %s", ErrSplitTrash, dir, trashDir)
+		}
+	}
+
+	return nil
+}
+
+// TrashOptions configures a single trash operation. The zero value behaves
+// exactly like Trash.
+type TrashOptions struct {
+	// OriginalPath, if set, is recorded in the trashinfo verbatim instead of
+	// the absolute path of the trashed file. The file itself is still moved
+	// from path. A relative OriginalPath is not spec-compliant, but it lets a
+	// relocatable project keep a self-contained trash; see RestoreOptions.Base.
+	OriginalPath string
+
+	// DeletionDate, if non-zero, is recorded instead of the current time.
+	// It may not lie more than maxClockSkew in the future.
+	DeletionDate time.Time
+
+	// MaxDepth, if positive, limits how deeply nested a directory may be
+	// when it has to be copied to a trash on another filesystem. Deeper
+	// trees fail with ErrMaxDepthExceeded and are left in place. A plain
+	// rename within one filesystem is not limited.
+	MaxDepth int
+
+	// RateLimit, if positive, caps how many bytes per second are copied
+	// when trashing to another filesystem, so background deletions don't
+	// starve other I/O. The cap applies to the whole operation, not to
+	// each file of a directory.
+	RateLimit int64
+
+	// Umask is cleared from the permission bits of the files and
+	// directories created when trashing to another filesystem, on top of
+	// the process umask the operating system applies anyway. Set it to
+	// e.g. 0077 so copies on a laxer filesystem aren't readable by others,
+	// whatever the modes of the originals.
+	Umask os.FileMode
+
+	// VerifyDevice re-checks, right before the move, that the file is
+	// still on the same device relative to the trash directory as when the
+	// trash directory was chosen. If the file was remounted elsewhere in
+	// between, the trash fails with ErrDeviceChanged instead of turning into
+	// an unexpected cross-device copy.
+	VerifyDevice bool
+
+	// CanonicalizeOriginal resolves symlinks in the parent directories of
+	// path before recording it as the original path, so the trashinfo names
+	// the real location rather than the route taken to it. The named entry
+	// itself is trashed as is, even if it is a symlink. Restore then goes to
+	// the canonical location, which differs from path if the symlink was
+	// later changed or removed. Ignored if OriginalPath is set.
+	CanonicalizeOriginal bool
+
+	// SelfCheck reads the trashinfo back after writing it, before the file
+	// is moved, and fails with ErrSelfCheckFailed unless it parses to the
+	// intended original path and deletion date (to the second).
+	SelfCheck bool
+
+	// SkipEmpty leaves zero-byte regular files and empty directories where
+	// they are, failing with ErrSkippedEmpty, so bulk cleanups don't fill
+	// the trash with empties.
+	SkipEmpty bool
+
+	// NameHint, if set, is used instead of the base name of path to name
+	// the trash entry, e.g. to include a job ID. It is sanitized and made
+	// unique like a base name would be. The true path is still recorded.
+	NameHint string
+
+	// LeavePlaceholder leaves an empty file, or an empty directory, at path
+	// once it has been trashed, for tools that break if the path vanishes.
+	// Restoring the item removes the placeholder first as long as it is
+	// still empty; once something is written to it, Restore fails with
+	// ErrAlreadyExists as for any other file in the way. If the placeholder
+	// can't be created, the file stays trashed and TrashWithResult returns
+	// its result with an error wrapping ErrPlaceholderFailed.
+	LeavePlaceholder bool
+
+	// DetectMimeType records the content type of a regular file, sniffed
+	// from its first 512 bytes, in the trashinfo, so trash browsers need not
+	// read the data to show it. Detection is best effort: directories and
+	// unreadable files get no type.
+	DetectMimeType bool
+
+	// PreserveSELinux records the SELinux security context of the file in
+	// the trashinfo, and Restore puts it back. A cross-device copy into or
+	// out of the trash loses the label, and a restored file would otherwise
+	// get the default context of its destination, which confined services
+	// may not be allowed to read. Only the trashed path itself is labelled,
+	// not the contents of a directory. Files without a label, and systems
+	// without SELinux, record nothing. A label that can't be put back is
+	// logged and doesn't fail the restore.
+	PreserveSELinux bool
+
+	// Reason, if set, is recorded in the trashinfo as why the file was
+	// trashed, e.g. a ticket reference. It may contain any text, including
+	// newlines.
+	Reason string
+
+	// FlattenDirectories makes trashing a directory trash each file within
+	// it as an entry of its own, recording its full path, so the files can
+	// be restored one by one, and then remove the emptied directories.
+	// Anything that isn't a directory counts as a file, symlinks included.
+	// It carries on past files that fail to be trashed, leaving them and
+	// their directories in place, and returns the errors joined. The
+	// TrashResult of a flattened directory is empty. OriginalPath and
+	// NameHint are ignored for the files within it.
+	FlattenDirectories bool
+
+	// MaxVersionsPerPath, if positive, caps how many items with the same
+	// original path are kept in the trash directory the file went to. Once
+	// the new item is in place, the oldest beyond the cap are deleted for
+	// good, so a path trashed over and over, like a build cache, doesn't
+	// grow info/ without bound. Failing to prune doesn't fail the trash; it
+	// is logged and reported in TrashResult.PruneErr.
+	MaxVersionsPerPath int
+
+	// Progress, if set, is called as a copy to a trash on another
+	// filesystem goes along: after each chunk of data written and after
+	// each file, with the bytes copied so far, the total to copy, and the
+	// file being copied. The total comes from a walk of the source before
+	// copying starts, and is -1 if that walk fails. Progress is not called
+	// for a plain rename within one filesystem.
+	Progress func(bytesCopied, totalBytes int64, currentPath string)
+
+	// batchID tags the item as part of a Batch.
+	batchID string
+
+	// xdgOnly keeps the file out of the Recycle Bin and macOS Trash, and
+	// from being deleted by a disabled Trasher, for SelfTest, which checks
+	// the XDG trash directories, and RestoreAndTrashConflict, which must
+	// be able to restore the file again.
+	xdgOnly bool
+}
+
+// maxClockSkew is how far in the future an explicit deletion date may be,
+// to tolerate clocks that disagree slightly between machines.
+const maxClockSkew = 24 * time.Hour
+
+// RestoreOptions configures a single restore operation. The zero value
+// behaves exactly like Restore.
+type RestoreOptions struct {
+	// Base is the directory a relative OriginalPath is resolved against.
+	// If empty, relative paths are resolved against the working directory.
+	Base string
+
+	// FollowSymlinks allows restoring into a directory reached through a
+	// symlink. By default, Restore fails with ErrSymlinkedParent if the
+	// nearest existing ancestor of the original path is a symlink, since it
+	// may have been swapped in after deletion to redirect the restore.
+	// Symlinks further up the path are not checked.
+	FollowSymlinks bool
+
+	// Merge restores a directory into an existing directory at its
+	// original path instead of failing with ErrAlreadyExists. Children
+	// missing from the destination are moved into it, and subdirectories
+	// present in both are merged in turn. Entries that conflict with an
+	// existing non-directory are dealt with by Conflict: by default the
+	// restore fails with ErrAlreadyExists naming them before anything is
+	// moved, and the item stays listed. A merge that fails part way is
+	// undone. A trashed regular file still never replaces anything.
+	Merge bool
+
+	// Conflict decides what happens when something other than a directory
+	// to merge into is at the original path. The default ConflictFail
+	// fails with ErrAlreadyExists, as Restore does.
+	Conflict ConflictPolicy
+
+	// keepItem restores a copy staged by RestoreToTemp and leaves the item
+	// in the trash, for RestoreCopy.
+	keepItem bool
+}
+
+// A ConflictPolicy is how a restore deals with an existing file at the
+// original path.
+type ConflictPolicy int
+
+const (
+	// ConflictFail fails with ErrAlreadyExists.
+	ConflictFail ConflictPolicy = iota
+
+	// ConflictSkip leaves both the existing file and the item as they are
+	// and reports success.
+	ConflictSkip
+
+	// ConflictOverwrite permanently removes the existing file, or
+	// directory with all its contents, and restores in its place. If the
+	// restore fails, the existing file is left as it was.
+	ConflictOverwrite
+
+	// ConflictRename restores beside the existing file under a numbered
+	// name, name.1, name.2 and so on, as trash names are made unique.
+	ConflictRename
 )
 
-func initialize() {
-	homeDir, err := os.UserHomeDir()
+// Trash moves path into the trash.
+func (tr *Trasher) Trash(path string) error {
+	return tr.TrashWithOptions(path, TrashOptions{})
+}
+
+// TrashWithOptions is like Trash but configurable through opts.
+func (tr *Trasher) TrashWithOptions(path string, opts TrashOptions) error {
+	_, err := tr.trashPath(context.Background(), path, opts)
+	return err
+}
+
+// TrashContext is like Trash but gives up when ctx is done. Cancellation is
+// checked between steps and while copying data across devices; an
+// interrupted copy is removed and the original left in place.
+func (tr *Trasher) TrashContext(ctx context.Context, path string) error {
+	_, err := tr.trashPath(ctx, path, TrashOptions{})
+	return err
+}
+
+// TrashWithTimeout is like TrashContext with a context that expires after d.
+func (tr *Trasher) TrashWithTimeout(path string, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	return tr.TrashContext(ctx, path)
+}
+
+// TrashAt is like Trash but records deletionTime as the deletion date, e.g.
+// when importing items from another trash.
+func (tr *Trasher) TrashAt(path string, deletionTime time.Time) error {
+	return tr.TrashWithOptions(path, TrashOptions{DeletionDate: deletionTime})
+}
+
+// TrashResult describes where a trash operation put its file.
+type TrashResult struct {
+	TrashItem
+
+	// Fallback is true if the file's own filesystem has a trash directory
+	// that couldn't be used, so the file went to the home trash instead,
+	// possibly by a cross-device copy.
+	Fallback bool
+
+	// RandomName is true if every numbered variant of the file's name was
+	// taken, so the trash name ends in a random suffix instead.
+	RandomName bool
+
+	// BytesFreedAtSource is how many bytes the trash freed on the file's
+	// own filesystem: its size if it was copied to a trash on another
+	// filesystem, and 0 if it was only renamed within its filesystem, where
+	// no space is reclaimed until the trash is emptied.
+	BytesFreedAtSource int64
+
+	// PruneErr is why older versions couldn't all be deleted under
+	// TrashOptions.MaxVersionsPerPath, if they couldn't. The file is
+	// trashed regardless.
+	PruneErr error
+}
+
+// TrashWithResult is like TrashWithOptions but also reports the resulting
+// trash entry and the trash directory chosen for it.
+func (tr *Trasher) TrashWithResult(path string, opts TrashOptions) (TrashResult, error) {
+	return tr.trashPath(context.Background(), path, opts)
+}
+
+// trashPath moves path into the appropriate trash and returns the resulting
+// entry.
+func (tr *Trasher) trashPath(ctx context.Context, path string, opts TrashOptions) (result TrashResult, err error) {
+	var job *copyJob
+	tr.progress("trash", path, 0, statusStarted)
+	defer func() {
+		var bytes int64
+		if job != nil {
+			bytes = job.bytes
+		}
+		tr.progressEnd("trash", path, bytes, err)
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return TrashResult{}, err
+	}
+
+	if tr.disabled.Load() {
+		// Whoever trashes in a Batch or to keep the file at hand needs an
+		// entry to restore, not a file gone for good
+		if !tr.deleteWhenDisabled || opts.batchID != "" || opts.xdgOnly {
+			return TrashResult{}, fmt.Errorf("%w: %s", ErrTrashDisabled, path)
+		}
+		return TrashResult{}, tr.Remove(path)
+	}
+
+	deletionDate := opts.DeletionDate
+	if deletionDate.IsZero() {
+		deletionDate = time.Now()
+	} else if deletionDate.After(time.Now().Add(maxClockSkew)) {
+		return TrashResult{}, fmt.Errorf("%w: %s", ErrInvalidDate, deletionDate.Format(time.RFC3339))
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return TrashResult{}, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if err := tr.checkProtected(absPath); err != nil {
+		return TrashResult{}, err
+	}
+
+	info, err := tr.fs.Lstat(absPath)
+	if err != nil {
+		return TrashResult{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// Renaming a mount point fails, and copying it would pull the whole
+	// mounted filesystem into the trash
+	if info.IsDir() {
+		if mount, err := tr.mountPoint(absPath); err == nil && mount == absPath {
+			return TrashResult{}, fmt.Errorf("%w: %s", ErrIsMountPoint, absPath)
+		}
+	}
+
+	if opts.FlattenDirectories && info.IsDir() {
+		return TrashResult{}, tr.trashFlattened(ctx, absPath, opts, ancestors{})
+	}
+
+	if opts.SkipEmpty {
+		empty, err := tr.isEmpty(absPath, info)
+		if err != nil {
+			return TrashResult{}, err
+		}
+		if empty {
+			return TrashResult{}, fmt.Errorf("%w: %s", ErrSkippedEmpty, absPath)
+		}
+	}
+
+	if result, ok, err := tr.trashNatively(absPath, opts); ok {
+		return result, err
+	}
+
+	trashDir, fallback, err := tr.getTrashDirForPath(absPath)
+	if err != nil {
+		return TrashResult{}, fmt.Errorf("failed to determine trash directory: %w", err)
+	}
+
+	if err := tr.ensureTrashDirs(trashDir); err != nil {
+		return TrashResult{}, fmt.Errorf("failed to create trash directories: %w", err)
+	}
+
+	recordedPath := absPath
+	if opts.OriginalPath != "" {
+		recordedPath = opts.OriginalPath
+	} else if opts.CanonicalizeOriginal {
+		parent, err := tr.evalSymlinks(filepath.Dir(absPath))
+		if err != nil {
+			return TrashResult{}, fmt.Errorf("failed to resolve original path: %w", err)
+		}
+		recordedPath = filepath.Join(parent, filepath.Base(absPath))
+	}
+
+	// The trash may live on a filesystem that rejects names the source
+	// allowed; retry once with a portable name, keeping the real path in
+	// the trashinfo so restore is unaffected.
+	baseName := filepath.Base(absPath)
+	if opts.NameHint != "" {
+		baseName = sanitizeNameHint(opts.NameHint)
+	}
+	candidates := []string{baseName}
+	if portable := portableName(baseName); portable != baseName {
+		candidates = append(candidates, portable)
+	}
+
+	same, known, err := tr.sameDevice(absPath, trashDir)
 	if err != nil {
-		initErr = fmt.Errorf("failed to get home directory: %w", err)
-		return
+		return TrashResult{}, err
+	}
+
+	job = newCopyJob(ctx, opts)
+	if tr.jsonl != nil {
+		job.progress = func(file string, bytes int64) {
+			tr.progress("trash", file, bytes, statusProgress)
+		}
+	}
+	// Without device numbers, a fallback to the home trash is the only sign
+	// the move crosses filesystems
+	job.crossDevice = known && !same || !known && fallback
+	if opts.VerifyDevice && known {
+		job.device = &deviceCheck{trashDir: trashDir, same: same}
+	}
+
+	item := TrashItem{
+		OriginalPath: recordedPath,
+		DeletionDate: deletionDate,
+		TrashDir:     trashDir,
+		BatchID:      opts.batchID,
+		Placeholder:  opts.LeavePlaceholder,
+		Reason:       opts.Reason,
+	}
+	if opts.DetectMimeType && info.Mode().IsRegular() {
+		item.MimeType = tr.detectMimeType(absPath)
+	}
+	if opts.PreserveSELinux {
+		item.SELinuxContext, _ = getSELinuxContext(absPath)
+	}
+	if trashDir != tr.homeTrash {
+		item.DeviceUUID, item.DeviceLabel = tr.deviceLabel(tr.trashDirMount(trashDir))
+	}
+	randomName, err := tr.placeUnderName(job, absPath, info, &item, candidates)
+	if err != nil {
+		return TrashResult{}, err
+	}
+
+	// The data is in the trash by now, so a missing placeholder is
+	// reported alongside the result rather than in place of it
+	var placeholderErr error
+	if opts.LeavePlaceholder {
+		if err := tr.createPlaceholder(absPath, info); err != nil {
+			placeholderErr = fmt.Errorf("%w: %w", ErrPlaceholderFailed, err)
+		}
+	}
+	tr.emit(EventTrash, item)
+
+	// Like the placeholder, pruning comes after the item is in the trash,
+	// so its failure is reported alongside the result and doesn't fail the
+	// trash
+	var pruneErr error
+	if opts.MaxVersionsPerPath > 0 {
+		if err := tr.pruneVersions(item, opts.MaxVersionsPerPath); err != nil {
+			pruneErr = fmt.Errorf("failed to prune older versions: %w", err)
+			tr.logger.Warn("trash: failed to prune older versions", "path", absPath, "err", err)
+		}
+	}
+
+	// Only a cache, which SizeOf does without
+	if info.IsDir() {
+		tr.recordDirectorySize(item)
+	}
+
+	// Remembered so the items can be accounted for while it is unmounted;
+	// failing to is no reason to fail the trash
+	if trashDir != tr.homeTrash {
+		tr.rememberDevice(trashDir)
+	}
+
+	result = TrashResult{TrashItem: item, Fallback: fallback, RandomName: randomName, PruneErr: pruneErr}
+	if job.copied {
+		// The copy is the same size as the original was
+		result.BytesFreedAtSource, _ = tr.diskUsage(item.FilePath)
+	}
+	return result, placeholderErr
+}
+
+// trashNatively moves absPath to the Recycle Bin or the macOS Trash, if the
+// Trasher uses one of them. ok is false if the file is left to the XDG
+// trash: the Trasher uses neither, the file is trashed in a Batch, whose
+// ID only a trashinfo can record, or the native trash turned it away.
+// Options that only apply to a trashinfo fail with errors.ErrUnsupported
+// rather than being dropped.
+func (tr *Trasher) trashNatively(absPath string, opts TrashOptions) (result TrashResult, ok bool, err error) {
+	if !tr.recycleBin && tr.macTrash == "" || opts.batchID != "" || opts.xdgOnly {
+		return TrashResult{}, false, nil
+	}
+	if name := nativeUnsupported(opts); name != "" {
+		return TrashResult{}, true, fmt.Errorf("%w: TrashOptions.%s with the system trash", errors.ErrUnsupported, name)
+	}
+	if tr.recycleBin {
+		return tr.recycle(absPath)
+	}
+	return tr.trashToMacTrash(absPath)
+}
+
+// nativeUnsupported returns the name of the first option set in opts that
+// the Recycle Bin and the macOS Trash have nowhere to record, or "" if there is
+// none. The copy options don't apply to them, since they only rename the
+// file within its volume.
+func nativeUnsupported(opts TrashOptions) string {
+	switch {
+	case opts.OriginalPath != "":
+		return "OriginalPath"
+	case !opts.DeletionDate.IsZero():
+		return "DeletionDate"
+	case opts.VerifyDevice:
+		return "VerifyDevice"
+	case opts.CanonicalizeOriginal:
+		return "CanonicalizeOriginal"
+	case opts.SelfCheck:
+		return "SelfCheck"
+	case opts.NameHint != "":
+		return "NameHint"
+	case opts.LeavePlaceholder:
+		return "LeavePlaceholder"
+	case opts.DetectMimeType:
+		return "DetectMimeType"
+	case opts.Reason != "":
+		return "Reason"
+	case opts.MaxVersionsPerPath > 0:
+		return "MaxVersionsPerPath"
+	}
+	return ""
+}
+
+// detectMimeType returns the content type of the file at path, or "" if it
+// can't be read.
+func (tr *Trasher) detectMimeType(path string) string {
+	f, err := tr.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return ""
+	}
+	return http.DetectContentType(head[:n])
+}
+
+// createPlaceholder creates an empty file or directory at path, with the
+// permissions of the trashed original described by info.
+func (tr *Trasher) createPlaceholder(path string, info os.FileInfo) error {
+	if info.IsDir() {
+		return tr.fs.MkdirAll(path, info.Mode().Perm())
+	}
+
+	f, err := tr.fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// removePlaceholder removes path if it is still an empty placeholder.
+func (tr *Trasher) removePlaceholder(path string) error {
+	info, err := tr.fs.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat placeholder: %w", err)
+	}
+
+	empty, err := tr.isEmpty(path, info)
+	if err != nil || !empty {
+		return err
+	}
+	if err := tr.fs.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove placeholder: %w", err)
+	}
+	return nil
+}
+
+// isEmpty reports whether path, described by info, is a zero-byte regular
+// file or an empty directory.
+func (tr *Trasher) isEmpty(path string, info os.FileInfo) (bool, error) {
+	switch {
+	case info.Mode().IsRegular():
+		return info.Size() == 0, nil
+	case info.IsDir():
+		entries, err := tr.fs.ReadDir(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read directory: %w", err)
+		}
+		return len(entries) == 0, nil
+	}
+	return false, nil
+}
+
+// placeUnderName names item after the first of candidates the trash
+// filesystem accepts and places src in the trash under it, holding the
+// trash directory's lock until both the trashinfo and the data are there,
+// so nothing sweeping the trash sees an entry half placed. A copy across
+// devices gives the lock up once its destination exists, so a long copy
+// doesn't hold up other processes. It reports whether the name had to be
+// random.
+func (tr *Trasher) placeUnderName(job *copyJob, src string, info os.FileInfo, item *TrashItem, candidates []string) (bool, error) {
+	for i, candidate := range candidates {
+		job.unlock = tr.lockTrashDir(item.TrashDir)
+		name, random, err := tr.reserveTrashName(candidate, item.TrashDir, item.DeletionDate)
+		if err == nil {
+			item.Name = name
+			item.FilePath = tr.layout.DataPath(item.TrashDir, name, item.DeletionDate)
+			item.InfoPath = tr.layout.InfoPath(item.TrashDir, name)
+			err = tr.placeInTrash(job, src, info, *item)
+		}
+		job.releaseLock()
+		if err == nil {
+			return random, nil
+		}
+		if i == len(candidates)-1 || !isInvalidNameError(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// placeInTrash writes the trashinfo for src and moves its data to
+// item.FilePath, removing the info again if the move fails.
+func (tr *Trasher) placeInTrash(job *copyJob, src string, info os.FileInfo, item TrashItem) error {
+	// Layouts may nest data below the directories ensureTrashDirs creates
+	if err := tr.fs.MkdirAll(filepath.Dir(item.FilePath), 0700); err != nil {
+		return fmt.Errorf("failed to create trash directories: %w", err)
+	}
+
+	// The info is written, over the reserved empty file, before the data is
+	// moved, so a failure here leaves at most a partial info file to clean
+	// up and never orphaned data
+	if err := tr.writeTrashInfo(item); err != nil {
+		tr.fs.Remove(item.InfoPath)
+		return fmt.Errorf("failed to write trash info: %w", err)
+	}
+
+	if job.opts.SelfCheck {
+		if err := tr.checkTrashInfo(item); err != nil {
+			tr.fs.Remove(item.InfoPath)
+			return err
+		}
+	}
+
+	if err := tr.verifyDevice(job, src); err != nil {
+		tr.fs.Remove(item.InfoPath)
+		return err
+	}
+
+	if err := tr.moveToTrash(job, src, item.FilePath, info); err != nil {
+		tr.fs.Remove(item.InfoPath)
+		return fmt.Errorf("failed to move to trash: %w", err)
+	}
+	return nil
+}
+
+// checkTrashInfo verifies that item's trashinfo records its original path
+// and deletion date.
+func (tr *Trasher) checkTrashInfo(item TrashItem) error {
+	originalPath, deletionDate := item.OriginalPath, item.DeletionDate
+	content, err := tr.readFile(item.InfoPath)
+	if err != nil {
+		return fmt.Errorf("failed to read trash info: %w", err)
+	}
+
+	parsed, err := parseTrashInfoContent(content)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSelfCheckFailed, err)
+	}
+	if parsed.OriginalPath != originalPath {
+		return fmt.Errorf("%w: path %q read back as %q", ErrSelfCheckFailed, originalPath, parsed.OriginalPath)
+	}
+	if want := deletionDate.Truncate(time.Second); !parsed.DeletionDate.Equal(want) {
+		return fmt.Errorf("%w: deletion date %s read back as %s", ErrSelfCheckFailed,
+			want.Format(time.RFC3339), parsed.DeletionDate.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// sameDevice reports whether path is on the same device as trashDir. ok is
+// false if the platform doesn't expose device numbers.
+func (tr *Trasher) sameDevice(path, trashDir string) (same, ok bool, err error) {
+	info, err := tr.fs.Lstat(path)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to stat file: %w", err)
+	}
+	trashInfo, err := tr.fs.Stat(trashDir)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to stat trash directory: %w", err)
+	}
+
+	dev, ok := deviceID(info)
+	trashDev, trashOK := deviceID(trashInfo)
+	if !ok || !trashOK {
+		return false, false, nil
+	}
+	return dev == trashDev, true, nil
+}
+
+func (tr *Trasher) verifyDevice(job *copyJob, src string) error {
+	if job.device == nil {
+		return nil
+	}
+
+	same, ok, err := tr.sameDevice(src, job.device.trashDir)
+	if err != nil {
+		return err
+	}
+	if ok && same != job.device.same {
+		return fmt.Errorf("%w: %s", ErrDeviceChanged, src)
+	}
+	return nil
+}
+
+// reserveTrashName picks a free trash name like generateTrashNameInDir and
+// claims it by creating its trashinfo, still empty, with O_EXCL. Where the
+// trash directory can't be locked, a concurrent Trash may pick the same
+// name in between; the info file then exists already, and the next free
+// name is tried.
+func (tr *Trasher) reserveTrashName(baseName, trashDir string, deleted time.Time) (string, bool, error) {
+	for i := 0; ; i++ {
+		name, random := tr.generateTrashNameInDir(baseName, trashDir, deleted)
+		infoPath := tr.layout.InfoPath(trashDir, name)
+		f, err := tr.fs.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			if err := f.Close(); err != nil {
+				tr.fs.Remove(infoPath)
+				return "", false, fmt.Errorf("failed to write trash info: %w", err)
+			}
+			return name, random, nil
+		}
+		if !os.IsExist(err) || i == tr.nameAttempts {
+			return "", false, fmt.Errorf("failed to write trash info: %w", err)
+		}
+	}
+}
+
+func (tr *Trasher) generateTrashName(baseName string) string {
+	name, _ := tr.generateTrashNameInDir(baseName, tr.homeTrash, time.Now())
+	return name
+}
+
+// generateTrashNameInDir returns a free trash name derived from baseName,
+// trying baseName itself and then numbered suffixes, up to tr.nameAttempts
+// names in all. Past that it falls back to a random suffix, and reports
+// that it did.
+func (tr *Trasher) generateTrashNameInDir(baseName string, trashDir string, deleted time.Time) (string, bool) {
+	baseName = sanitizeFilename(baseName)
+
+	for i := 0; i < tr.nameAttempts; i++ {
+		name := numberedName(baseName, i)
+
+		filesPath := tr.layout.DataPath(trashDir, name, deleted)
+		infoPath := tr.layout.InfoPath(trashDir, name)
+
+		if _, err := tr.fs.Lstat(filesPath); os.IsNotExist(err) {
+			if _, err := tr.fs.Lstat(infoPath); os.IsNotExist(err) {
+				return name, false
+			}
+		}
+	}
+
+	randomBytes := make([]byte, 8)
+	rand.Read(randomBytes)
+	return fmt.Sprintf("%s.%s", baseName, hex.EncodeToString(randomBytes)), true
+}
+
+// freeRestorePath returns the first numbered variant of dest that doesn't
+// exist, falling back to a random suffix like generateTrashNameInDir.
+func (tr *Trasher) freeRestorePath(dest string) string {
+	for i := 1; i < tr.nameAttempts; i++ {
+		path := numberedName(dest, i)
+		if _, err := tr.fs.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+	}
+	return dest + "." + randomSuffix()
+}
+
+// numberedName returns the i-th variant of name: name itself, then name.1,
+// name.2 and so on.
+func numberedName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s.%d", name, i)
+}
+
+func sanitizeFilename(name string) string {
+	if name == "" {
+		return "unnamed"
+	}
+
+	name = strings.TrimSpace(name)
+
+	if strings.HasPrefix(name, ".") && len(name) == 1 {
+		return "dot"
+	}
+
+	return name
+}
+
+// sanitizeNameHint makes a caller-provided name safe to use as a single
+// path element.
+func sanitizeNameHint(hint string) string {
+	hint = strings.Map(func(r rune) rune {
+		if r == '/' || r == filepath.Separator {
+			return '_'
+		}
+		return r
+	}, hint)
+	if hint == ".." {
+		return "dotdot"
+	}
+	return hint
+}
+
+// portableName replaces characters that FAT and NTFS reject, and strips
+// trailing dots and spaces, so the name can be created on any common
+// filesystem.
+func portableName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+// Extension keys in the trashinfo, which other implementations ignore.
+const (
+	// batchKey records the batch an item was trashed in.
+	batchKey = "X-Batch-ID"
+	// placeholderKey records that a placeholder was left at the original path.
+	placeholderKey = "X-Placeholder"
+	// mimeTypeKey records the content type detected at trash time.
+	mimeTypeKey = "X-MimeType"
+	// reasonKey records why the item was trashed, URI-escaped since it may
+	// span lines.
+	reasonKey = "X-Reason"
+	// selinuxKey records the SELinux context of the trashed file.
+	selinuxKey = "X-SELinux-Context"
+	// deviceUUIDKey and deviceLabelKey identify the filesystem of a mount
+	// trash, the label URI-escaped.
+	deviceUUIDKey  = "X-Device-UUID"
+	deviceLabelKey = "X-Device-Label"
+)
+
+func (tr *Trasher) writeTrashInfo(item TrashItem) error {
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		escapeTrashInfoPath(item.OriginalPath),
+		item.DeletionDate.UTC().Format("2006-01-02T15:04:05"))
+	if item.BatchID != "" {
+		content += batchKey + "=" + item.BatchID + "\n"
+	}
+	if item.Placeholder {
+		content += placeholderKey + "=true\n"
+	}
+	if item.MimeType != "" {
+		content += mimeTypeKey + "=" + item.MimeType + "\n"
+	}
+	if item.Reason != "" {
+		content += reasonKey + "=" + url.PathEscape(item.Reason) + "\n"
+	}
+	if item.SELinuxContext != "" {
+		content += selinuxKey + "=" + url.PathEscape(item.SELinuxContext) + "\n"
+	}
+	if item.DeviceUUID != "" {
+		content += deviceUUIDKey + "=" + item.DeviceUUID + "\n"
+		if item.DeviceLabel != "" {
+			content += deviceLabelKey + "=" + url.PathEscape(item.DeviceLabel) + "\n"
+		}
+	}
+
+	// A torn info file would not parse, losing track of the item
+	return tr.writeFileAtomic(item.InfoPath, []byte(content), 0600)
+}
+
+// escapeTrashInfoPath percent-encodes path for the Path key the way GLib
+// does: every byte but ASCII letters, digits, "-._~" and the separator '/'.
+// url.QueryEscape would also encode '/', which gio and trash-cli then show
+// verbatim.
+func escapeTrashInfoPath(path string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+	return b.String()
+}
+
+// copyJob holds the state shared by every file of one cross-device copy.
+type copyJob struct {
+	ctx     context.Context
+	opts    TrashOptions
+	limiter *rateLimiter
+
+	// device is the source/trash device relation to verify before moving,
+	// if TrashOptions.VerifyDevice is set.
+	device *deviceCheck
+
+	// crossDevice is set if the source is known to be on another device
+	// than the trash, so a rename can't succeed.
+	crossDevice bool
+
+	// copied is set once the source has been copied and removed, rather
+	// than renamed.
+	copied bool
+
+	// bytes is the number of bytes copied so far, and progress, if set, is
+	// called with it after each file is copied.
+	bytes    int64
+	progress func(path string, bytes int64)
+
+	// total is the number of bytes to copy, or -1 if unknown, and current
+	// is the file being copied, with pending bytes of it written so far;
+	// they are only tracked for TrashOptions.Progress.
+	total   int64
+	current string
+	pending int64
+
+	// ancestors are the directories being copied, from the one trashed
+	// down to the current one.
+	ancestors ancestors
+
+	// unlock, if set, releases the lock of the trash directory being
+	// written to.
+	unlock func()
+}
+
+// releaseLock releases the trash directory lock the job holds, if any.
+func (job *copyJob) releaseLock() {
+	if job.unlock != nil {
+		job.unlock()
+		job.unlock = nil
+	}
+}
+
+// deviceCheck records whether a file was on the same device as its trash
+// directory when the trash directory was chosen.
+type deviceCheck struct {
+	trashDir string
+	same     bool
+}
+
+// mode returns perm with the job's umask applied.
+func (job *copyJob) mode(perm os.FileMode) os.FileMode {
+	return perm &^ (job.opts.Umask & os.ModePerm)
+}
+
+func newCopyJob(ctx context.Context, opts TrashOptions) *copyJob {
+	job := &copyJob{ctx: ctx, opts: opts, ancestors: ancestors{}}
+	if opts.RateLimit > 0 {
+		job.limiter = &rateLimiter{rate: opts.RateLimit, start: time.Now()}
+	}
+	return job
+}
+
+func (tr *Trasher) moveToTrash(job *copyJob, src, dst string, info os.FileInfo) error {
+	if job.crossDevice {
+		return tr.copyAcrossDevices(job, src, dst, info)
+	}
+
+	err := tr.fs.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	if !isCrossDeviceError(err) {
+		return err
+	}
+
+	return tr.copyAcrossDevices(job, src, dst, info)
+}
+
+// copyAcrossDevices copies src to dst and only then removes src, so a
+// failure part way through leaves the original untouched and no partial
+// copy behind.
+func (tr *Trasher) copyAcrossDevices(job *copyJob, src, dst string, info os.FileInfo) error {
+	if job.opts.Progress != nil {
+		job.total = -1
+		if total, err := tr.diskUsage(src); err == nil {
+			job.total = total
+		}
+	}
+
+	var err error
+	if info.IsDir() {
+		err = tr.copyDirAcrossDevices(job, src, dst, 0)
+	} else {
+		err = tr.copyFileAcrossDevices(job, src, dst, info)
+	}
+
+	if err != nil {
+		tr.fs.RemoveAll(dst)
+		return err
+	}
+
+	if err := tr.fs.RemoveAll(src); err != nil {
+		return err
+	}
+	job.copied = true
+	return nil
+}
+
+func (tr *Trasher) copyFileAcrossDevices(job *copyJob, src, dst string, info os.FileInfo) error {
+	if err := job.ctx.Err(); err != nil {
+		return err
+	}
+
+	// Handle symbolic links specially
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := tr.fs.Readlink(src)
+		if err != nil {
+			return fmt.Errorf("failed to read symlink: %w", err)
+		}
+
+		if err := tr.fs.Symlink(link, dst); err != nil {
+			return fmt.Errorf("failed to create symlink: %w", err)
+		}
+		job.releaseLock()
+
+		if err := copyOwnership(src, dst, info); err != nil {
+			return err
+		}
+		// os.Chtimes would follow the link to its target
+		if err := lchtimes(dst, info); err != nil {
+			return err
+		}
+		job.fileDone(src, info.Size())
+		return nil
+	}
+
+	// Regular file handling
+	srcFile, err := tr.fs.OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := tr.fs.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, job.mode(info.Mode()))
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+	// The entry's data now exists, so the copy can go on unlocked
+	job.releaseLock()
+
+	job.current, job.pending = src, 0
+	copied, err := job.copySparse(dstFile, srcFile, info)
+	if err != nil {
+		return err
+	}
+	if !copied {
+		n, err := job.copy(dstFile, srcFile)
+		if err != nil {
+			return err
+		}
+		if n != info.Size() {
+			return fmt.Errorf("copied %d of %d bytes to %s", n, info.Size(), dst)
+		}
+	}
+
+	if err := dstFile.Close(); err != nil {
+		return err
+	}
+
+	if err := copyOwnership(src, dst, info); err != nil {
+		return err
+	}
+	// Changing the owner clears the set-user-ID and set-group-ID bits
+	if info.Mode()&(os.ModeSetuid|os.ModeSetgid) != 0 {
+		if err := tr.fs.Chmod(dst, job.mode(info.Mode())); err != nil {
+			return err
+		}
+	}
+
+	if err := tr.fs.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+
+	job.fileDone(src, info.Size())
+	return nil
+}
+
+// fileDone counts size more bytes as copied, for src, and reports it.
+func (job *copyJob) fileDone(src string, size int64) {
+	job.bytes += size
+	job.current, job.pending = src, 0
+	if job.progress != nil {
+		job.progress(src, job.bytes)
+	}
+	job.reportProgress()
+}
+
+// reportProgress calls TrashOptions.Progress, if set, with the bytes
+// copied so far.
+func (job *copyJob) reportProgress() {
+	if job.opts.Progress != nil {
+		job.opts.Progress(job.bytes+job.pending, job.total, job.current)
+	}
+}
+
+// copy is like io.Copy but stops with the job context's error once it is
+// done, and keeps to the job's rate limit. A full destination, whether
+// reported as such or as a write that silently came up short, fails with
+// ErrInsufficientSpace.
+func (job *copyJob) copy(dst io.Writer, src io.Reader) (int64, error) {
+	size := 32 * 1024
+	if job.limiter != nil && job.limiter.rate < int64(size) {
+		size = int(job.limiter.rate)
+	}
+
+	buf := make([]byte, size)
+	var written int64
+	for {
+		if err := job.ctx.Err(); err != nil {
+			return written, err
+		}
+
+		n, err := src.Read(buf)
+		if n > 0 {
+			nw, werr := dst.Write(buf[:n])
+			written += int64(nw)
+			if nw > 0 {
+				job.pending += int64(nw)
+				job.reportProgress()
+			}
+			if werr == nil && nw < n {
+				werr = io.ErrShortWrite
+			}
+			if werr == io.ErrShortWrite || isNoSpaceError(werr) {
+				return written, fmt.Errorf("%w: %v", ErrInsufficientSpace, werr)
+			}
+			if werr != nil {
+				return written, werr
+			}
+			if job.limiter != nil {
+				if werr := job.limiter.wait(job.ctx, n); werr != nil {
+					return written, werr
+				}
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// rateLimiter paces a stream of bytes to rate bytes per second on average
+// since start.
+type rateLimiter struct {
+	rate  int64
+	start time.Time
+	sent  int64
+}
+
+// wait records n more bytes and sleeps until sending them keeps within the
+// rate, or until ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.sent += int64(n)
+	due := l.start.Add(time.Duration(float64(l.sent) / float64(l.rate) * float64(time.Second)))
+
+	delay := time.Until(due)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// copyDirAcrossDevices recursively copies the directory src, which is depth
+// levels below the directory being trashed, to dst.
+func (tr *Trasher) copyDirAcrossDevices(job *copyJob, src, dst string, depth int) error {
+	if job.opts.MaxDepth > 0 && depth > job.opts.MaxDepth {
+		return fmt.Errorf("%w: %s", ErrMaxDepthExceeded, src)
+	}
+
+	srcInfo, err := tr.fs.Stat(src)
+	if err != nil {
+		return err
+	}
+	leave, err := job.ancestors.enter(src, srcInfo)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// Keep the copy private and writable while filling it, and give it the
+	// source's mode only once its contents are in
+	if err := tr.fs.MkdirAll(dst, 0700); err != nil {
+		return err
+	}
+	job.releaseLock()
+
+	entries, err := tr.fs.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		// Check if it's a symlink before checking if it's a directory
+		// because symlinks to directories would return true for IsDir().
+		// Symlinks are copied as links, never followed.
+		if info.Mode()&os.ModeSymlink != 0 {
+			if err := tr.copyFileAcrossDevices(job, srcPath, dstPath, info); err != nil {
+				return err
+			}
+		} else if entry.IsDir() {
+			if err := tr.copyDirAcrossDevices(job, srcPath, dstPath, depth+1); err != nil {
+				return err
+			}
+		} else {
+			if err := tr.copyFileAcrossDevices(job, srcPath, dstPath, info); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := copyOwnership(src, dst, srcInfo); err != nil {
+		return err
+	}
+	if err := tr.fs.Chmod(dst, job.mode(srcInfo.Mode())); err != nil {
+		return err
+	}
+	return tr.fs.Chtimes(dst, srcInfo.ModTime(), srcInfo.ModTime())
+}
+
+// List returns the items in the home trash and the trash directories of
+// all mounted filesystems.
+func (tr *Trasher) List() ([]TrashItem, error) {
+	// List items from home trash, then all mounted filesystems
+	dirs := append([]string{tr.homeTrash}, tr.mountTrashDirs()...)
+	items := tr.listTrashDirs(dirs, maxListWorkers)
+
+	tr.index.reset(items)
+	return items, nil
+}
+
+// ListFrom returns the items in the single trash directory trashDir, such
+// as the .Trash-$uid of one mounted drive, without looking at any other.
+// It fails with ErrTrashNotFound if trashDir doesn't exist.
+func (tr *Trasher) ListFrom(trashDir string) ([]TrashItem, error) {
+	trashDir, err := filepath.Abs(trashDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	info, err := tr.fs.Stat(trashDir)
+	if os.IsNotExist(err) || err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrTrashNotFound, trashDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat trash directory: %w", err)
+	}
+	return tr.listTrashDir(trashDir)
+}
+
+// ListOptions configures ListWithOptions. The zero value behaves exactly
+// like List.
+type ListOptions struct {
+	// ComputeSizes fills in the Size of every item, which for a directory
+	// means walking all of its contents unless the directorysizes cache
+	// has it. Items whose data can't be read are left with size 0.
+	ComputeSizes bool
+}
+
+// ListWithOptions is like List but configurable through opts.
+func (tr *Trasher) ListWithOptions(opts ListOptions) ([]TrashItem, error) {
+	items, err := tr.List()
+	if err != nil || !opts.ComputeSizes {
+		return items, err
+	}
+
+	caches := make(map[string]map[string]dirSizeEntry)
+	for i, item := range items {
+		cache, ok := caches[item.TrashDir]
+		if !ok {
+			cache = tr.readDirectorySizes(item.TrashDir)
+			caches[item.TrashDir] = cache
+		}
+		items[i].Size, _ = tr.sizeOf(item, cache)
+	}
+	return items, nil
+}
+
+// SizeOf returns the apparent size of the data of item in bytes, summed
+// over its contents if it is a directory. Symlinks are not followed. The
+// size of a directory is taken from the directorysizes cache of its trash
+// directory while that is up to date.
+func (tr *Trasher) SizeOf(item TrashItem) (int64, error) {
+	return tr.sizeOf(item, tr.readDirectorySizes(item.TrashDir))
+}
+
+// maxListWorkers bounds how many trash directories List reads at once.
+const maxListWorkers = 8
+
+// listTrashDirs lists dirs using up to workers goroutines, so one slow
+// mount doesn't hold up the rest. Items come back in the order of dirs, as
+// if listed one after another; unreadable directories are skipped.
+func (tr *Trasher) listTrashDirs(dirs []string, workers int) []TrashItem {
+	results := make([][]TrashItem, len(dirs))
+	if workers > len(dirs) {
+		workers = len(dirs)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if items, err := tr.listTrashDir(dirs[i]); err == nil {
+					results[i] = items
+				}
+			}
+		}()
+	}
+	for i := range dirs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var items []TrashItem
+	for _, dirItems := range results {
+		items = append(items, dirItems...)
+	}
+	return items
+}
+
+// mountTrashDirs returns the existing trash directories on mounted
+// filesystems other than the root, which is covered by the home trash.
+func (tr *Trasher) mountTrashDirs() []string {
+	mountPoints, err := tr.mountPoints()
+	if err != nil {
+		return nil
+	}
+
+	// A filesystem bind-mounted in several places shows the same trash at
+	// each; keep only the first path to every physical directory
+	var seen []os.FileInfo
+	if info, err := tr.fs.Stat(tr.homeTrash); err == nil {
+		seen = append(seen, info)
+	}
+
+	var dirs []string
+	for _, mount := range mountPoints {
+		if mount == "/" {
+			continue // Already handled by home trash
+		}
+
+		candidates := tr.mountTrashCandidates(mount)
+		if tr.recycleBin {
+			candidates = append(candidates, filepath.Join(mount, recycleBinName, tr.uid))
+		}
+		for _, trashDir := range candidates {
+			info, err := tr.fs.Stat(trashDir)
+			if err != nil || !info.IsDir() || containsSameFile(seen, info) {
+				continue
+			}
+			seen = append(seen, info)
+			dirs = append(dirs, trashDir)
+		}
+	}
+
+	return dirs
+}
+
+// mountTrashCandidates returns the trash directories the spec allows on
+// the filesystem mounted at mount, in order of preference:
+// $topdir/.Trash/$uid, if an administrator has set up a shared .Trash
+// there, then $topdir/.Trash-$uid. A shared .Trash only counts if it is a
+// directory itself, not a symlink, and has the sticky bit set, so users
+// can't remove or swap each other's trashes.
+func (tr *Trasher) mountTrashCandidates(mount string) []string {
+	own := filepath.Join(mount, ".Trash-"+tr.uid)
+	shared := filepath.Join(mount, ".Trash")
+	info, err := tr.fs.Lstat(shared)
+	if err != nil || !info.IsDir() || info.Mode()&os.ModeSticky == 0 {
+		return []string{own}
+	}
+	return []string{filepath.Join(shared, tr.uid), own}
+}
+
+// trashDirMount returns the top directory of the filesystem holding the
+// mount trash trashDir, which is either $topdir/.Trash/$uid or
+// $topdir/.Trash-$uid.
+func (tr *Trasher) trashDirMount(trashDir string) string {
+	parent := filepath.Dir(trashDir)
+	if filepath.Base(trashDir) == tr.uid && filepath.Base(parent) == ".Trash" {
+		return filepath.Dir(parent)
+	}
+	return parent
+}
+
+func containsSameFile(infos []os.FileInfo, info os.FileInfo) bool {
+	for _, other := range infos {
+		if os.SameFile(other, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTrash reports whether the home trash or any trash directory on a
+// mounted filesystem exists. Unlike the other methods, it never creates
+// trash directories as a side effect.
+func (tr *Trasher) HasTrash() (bool, error) {
+	if _, err := tr.fs.Stat(tr.homeTrash); err == nil {
+		return true, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to stat home trash: %w", err)
+	}
+
+	return len(tr.mountTrashDirs()) > 0, nil
+}
+
+// TrashDirExists reports whether the home trash directory exists, treating
+// any error as absence. Like HasTrash, it has no side effects.
+func (tr *Trasher) TrashDirExists() bool {
+	info, err := tr.fs.Stat(tr.homeTrash)
+	return err == nil && info.IsDir()
+}
+
+// ListUnder returns the items whose original path is dir or lies below it,
+// matching whole path elements, so /data2/x is not under /data. A relative
+// dir, like a relative original path, is resolved against the working
+// directory. Only the home trash and the trash directories of filesystems
+// that can hold such paths are read: the one dir is on and any mounted
+// below it.
+func (tr *Trasher) ListUnder(dir string) ([]TrashItem, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	dirs := []string{tr.homeTrash}
+	for _, trashDir := range tr.mountTrashDirs() {
+		if mount := tr.trashDirMount(trashDir); within(dir, mount) || within(mount, dir) {
+			dirs = append(dirs, trashDir)
+		}
+	}
+
+	var items []TrashItem
+	for _, item := range tr.listTrashDirs(dirs, maxListWorkers) {
+		if path, err := restorePath(item.OriginalPath, ""); err == nil && within(path, dir) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// ListGrouped is like List but groups the items by the directory they were
+// deleted from, i.e. filepath.Dir(OriginalPath).
+func (tr *Trasher) ListGrouped() (map[string][]TrashItem, error) {
+	items, err := tr.List()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]TrashItem)
+	for _, item := range items {
+		dir := filepath.Dir(item.OriginalPath)
+		groups[dir] = append(groups[dir], item)
+	}
+
+	return groups, nil
+}
+
+func (tr *Trasher) listTrashDir(trashDir string) ([]TrashItem, error) {
+	if tr.isRecycleBinDir(trashDir) {
+		return tr.listRecycleBin(trashDir)
+	}
+	if tr.listIndex {
+		return tr.listIndexed(trashDir)
+	}
+	return tr.scanTrashDir(trashDir)
+}
+
+func (tr *Trasher) scanTrashDir(trashDir string) ([]TrashItem, error) {
+	names, err := tr.layout.Entries(trashDir, tr.fs.ReadDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read info directory: %w", err)
+	}
+
+	items := []TrashItem{}
+
+	for _, name := range names {
+		item, err := tr.parseTrashInfo(trashDir, name)
+		if err != nil {
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (tr *Trasher) parseTrashInfo(trashDir, name string) (TrashItem, error) {
+	infoPath := tr.layout.InfoPath(trashDir, name)
+	content, err := tr.readFile(infoPath)
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	item, err := parseTrashInfoContent(content)
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	item.Name = name
+	item.InfoPath = infoPath
+	item.FilePath = tr.layout.DataPath(trashDir, name, item.DeletionDate)
+	item.TrashDir = trashDir
+	return item, nil
+}
+
+// parseTrashInfoContent parses a .trashinfo file, filling in the fields of
+// TrashItem it records.
+func parseTrashInfoContent(content []byte) (TrashItem, error) {
+	lines := strings.Split(string(content), "\n")
+	if len(lines) < 3 || lines[0] != "[Trash Info]" {
+		return TrashItem{}, ErrInvalidTrashInfo
+	}
+
+	var item TrashItem
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "Path=") {
+			pathStr := strings.TrimPrefix(line, "Path=")
+			// Path is URI-escaped, where '+' is literal rather than a space;
+			// a '/' escaped as %2F, as older versions wrote it, reads the same
+			originalPath, err := url.PathUnescape(pathStr)
+			if err != nil {
+				// Some implementations write the path unescaped
+				originalPath = pathStr
+			}
+			item.OriginalPath = originalPath
+		} else if strings.HasPrefix(line, "DeletionDate=") {
+			dateStr := strings.TrimPrefix(line, "DeletionDate=")
+			item.DeletionDate = parseDeletionDate(dateStr)
+		} else if strings.HasPrefix(line, batchKey+"=") {
+			item.BatchID = strings.TrimPrefix(line, batchKey+"=")
+		} else if line == placeholderKey+"=true" {
+			item.Placeholder = true
+		} else if strings.HasPrefix(line, mimeTypeKey+"=") {
+			item.MimeType = strings.TrimPrefix(line, mimeTypeKey+"=")
+		} else if strings.HasPrefix(line, reasonKey+"=") {
+			reason := strings.TrimPrefix(line, reasonKey+"=")
+			if unescaped, err := url.PathUnescape(reason); err == nil {
+				reason = unescaped
+			}
+			item.Reason = reason
+		} else if strings.HasPrefix(line, selinuxKey+"=") {
+			label := strings.TrimPrefix(line, selinuxKey+"=")
+			if unescaped, err := url.PathUnescape(label); err == nil {
+				label = unescaped
+			}
+			item.SELinuxContext = label
+		} else if strings.HasPrefix(line, deviceUUIDKey+"=") {
+			item.DeviceUUID = strings.TrimPrefix(line, deviceUUIDKey+"=")
+		} else if strings.HasPrefix(line, deviceLabelKey+"=") {
+			label := strings.TrimPrefix(line, deviceLabelKey+"=")
+			if unescaped, err := url.PathUnescape(label); err == nil {
+				label = unescaped
+			}
+			item.DeviceLabel = label
+		}
+	}
+
+	if item.OriginalPath == "" {
+		return TrashItem{}, ErrInvalidTrashInfo
+	}
+	return item, nil
+}
+
+// deletionDateLayouts are the DeletionDate formats parseTrashInfo accepts.
+// The first is the spec's and the one writeTrashInfo uses; the others are
+// written by implementations that add a zone or a fractional second.
+var deletionDateLayouts = []string{
+	"2006-01-02T15:04:05",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
+
+// parseDeletionDate parses s in any of deletionDateLayouts, returning the
+// zero time if none match.
+func parseDeletionDate(s string) time.Time {
+	for _, layout := range deletionDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Restore moves the trashed item trashName back to its original path.
+func (tr *Trasher) Restore(trashName string) error {
+	return tr.RestoreWithOptions(trashName, RestoreOptions{})
+}
+
+// RestoreWithOptions is like Restore but configurable through opts.
+func (tr *Trasher) RestoreWithOptions(trashName string, opts RestoreOptions) error {
+	item, err := tr.findTrashItem(trashName)
+	if err != nil {
+		return err
+	}
+
+	return tr.restoreItem(item, opts)
+}
+
+// RestoreByOriginalPath restores the item trashed from originalPath,
+// resolved against the working directory if relative. If the path was
+// trashed several times, the most recently deleted item is restored; if
+// several of those share the latest deletion date, which is recorded to
+// the second, it fails with ErrAmbiguousPath naming them. It fails with
+// ErrFileNotInTrash if nothing was trashed from originalPath.
+func (tr *Trasher) RestoreByOriginalPath(originalPath string) error {
+	absPath, err := filepath.Abs(originalPath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	items, err := tr.ListUnder(absPath)
+	if err != nil {
+		return err
+	}
+
+	var latest []TrashItem
+	for _, item := range items {
+		if path, _ := restorePath(item.OriginalPath, ""); path != absPath {
+			continue
+		}
+		switch {
+		case len(latest) == 0 || item.DeletionDate.After(latest[0].DeletionDate):
+			latest = []TrashItem{item}
+		case item.DeletionDate.Equal(latest[0].DeletionDate):
+			latest = append(latest, item)
+		}
+	}
+
+	switch len(latest) {
+	case 0:
+		return fmt.Errorf("%w: %s", ErrFileNotInTrash, absPath)
+	case 1:
+		return tr.restoreItem(latest[0], RestoreOptions{})
+	}
+	names := make([]string, len(latest))
+	for i, item := range latest {
+		names[i] = item.Name
+	}
+	return fmt.Errorf("%w: %s", ErrAmbiguousPath, strings.Join(names, ", "))
+}
+
+// RestoreAndTrashConflict restores trashName like Restore, but if something
+// already occupies the original path it is moved to the trash first. The
+// trash name of the displaced file is returned so the swap can be undone; it
+// is empty if there was no conflict. If the restore fails, the displaced file
+// is put back. The displaced file always goes to the XDG trash, and on a
+// disabled Trasher the swap fails with ErrTrashDisabled even if the
+// Trasher would delete instead.
+func (tr *Trasher) RestoreAndTrashConflict(trashName string) (string, error) {
+	item, err := tr.findTrashItem(trashName)
+	if err != nil {
+		return "", err
+	}
+
+	dest, err := restorePath(item.OriginalPath, "")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tr.fs.Lstat(dest); os.IsNotExist(err) {
+		return "", tr.restoreItem(item, RestoreOptions{})
+	} else if err != nil {
+		return "", fmt.Errorf("failed to stat restore destination: %w", err)
+	}
+
+	conflict, err := tr.trashPath(context.Background(), dest, TrashOptions{xdgOnly: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to trash conflicting file: %w", err)
+	}
+
+	if err := tr.restoreItem(item, RestoreOptions{}); err != nil {
+		if rerr := tr.restoreItem(conflict.TrashItem, RestoreOptions{}); rerr != nil {
+			return "", fmt.Errorf("%w (rolling back conflict %s also failed: %v)", err, conflict.Name, rerr)
+		}
+		return "", err
+	}
+
+	return conflict.Name, nil
+}
+
+// TrashWithReason is like Trash, but records reason in the trashinfo as
+// why the file was trashed.
+func (tr *Trasher) TrashWithReason(path, reason string) error {
+	return tr.TrashWithOptions(path, TrashOptions{Reason: reason})
+}
+
+// TrashAs is like Trash, but names the trash entry after nameHint instead of
+// the base name of path, and returns the name chosen.
+func (tr *Trasher) TrashAs(path, nameHint string) (string, error) {
+	result, err := tr.trashPath(context.Background(), path, TrashOptions{NameHint: nameHint})
+	if err != nil {
+		return "", err
+	}
+	return result.Name, nil
+}
+
+// ReTrash trashes the file at item's original path again, e.g. to redo a
+// restore that was undone, and returns the name of the new trash entry. A
+// relative original path is resolved against the working directory and
+// recorded as is.
+func (tr *Trasher) ReTrash(item TrashItem) (string, error) {
+	path, err := restorePath(item.OriginalPath, "")
+	if err != nil {
+		return "", err
+	}
+
+	var opts TrashOptions
+	if !filepath.IsAbs(item.OriginalPath) {
+		opts.OriginalPath = item.OriginalPath
+	}
+
+	result, err := tr.trashPath(context.Background(), path, opts)
+	if err != nil {
+		return "", err
+	}
+	return result.Name, nil
+}
+
+func (tr *Trasher) restoreItem(item TrashItem, opts RestoreOptions) error {
+	return tr.restoreItemFrom(item, opts, "")
+}
+
+// restoreItemFrom is restoreItem, but if staged is set, it moves that copy
+// of the item's data into place instead and then removes the item's data
+// from the trash. RestoreToTemp stages the copy beside the original path,
+// so moving it is a rename within one directory.
+func (tr *Trasher) restoreItemFrom(item TrashItem, opts RestoreOptions, staged string) (err error) {
+	tr.progress("restore", item.OriginalPath, 0, statusStarted)
+	defer func() { tr.progressEnd("restore", item.OriginalPath, 0, err) }()
+	defer func() {
+		if err == nil {
+			tr.recountDevice(item.TrashDir)
+		}
+	}()
+
+	dest, err := restorePath(item.OriginalPath, opts.Base)
+	if err != nil {
+		return err
+	}
+
+	if item.Placeholder {
+		if err := tr.removePlaceholder(dest); err != nil {
+			return err
+		}
+	}
+
+	destInfo, err := tr.fs.Lstat(dest)
+	exists := err == nil
+	conflict := exists && !(opts.Merge && destInfo.IsDir())
+	if conflict {
+		switch opts.Conflict {
+		case ConflictSkip:
+			return nil
+		case ConflictOverwrite, ConflictRename:
+		default:
+			return ErrAlreadyExists
+		}
+	}
+
+	dir := filepath.Dir(dest)
+	if !opts.FollowSymlinks {
+		if err := tr.checkRestoreParent(dir); err != nil {
+			return err
+		}
+	}
+
+	if conflict && opts.Conflict == ConflictOverwrite {
+		// Moved aside rather than removed, to be put back if the restore
+		// fails
+		aside := filepath.Join(dir, fmt.Sprintf(".%s.overwritten-%s", filepath.Base(dest), randomSuffix()))
+		if err := tr.fs.Rename(dest, aside); err != nil {
+			return fmt.Errorf("failed to move existing file aside: %w", err)
+		}
+		defer func() {
+			if err != nil {
+				tr.fs.Rename(aside, dest)
+			} else {
+				tr.fs.RemoveAll(aside)
+			}
+		}()
+	} else if conflict {
+		dest = tr.freeRestorePath(dest)
+	} else if exists {
+		return tr.mergeRestore(item, opts, staged, dest)
+	}
+
+	if err := tr.fs.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	defer tr.lockTrashDir(item.TrashDir)()
+	src := item.FilePath
+	if staged != "" {
+		src = staged
+		if err := tr.moveStaged(staged, dest); err != nil {
+			return err
+		}
+	} else if err := tr.moveOutOfTrash(item, dest); err != nil {
+		return fmt.Errorf("failed to restore file: %w", err)
+	}
+
+	// The data is back in place, and undoing that may take a copy across
+	// devices, so a label that can't be set, as for unprivileged users, is
+	// only logged
+	if item.SELinuxContext != "" {
+		if err := setSELinuxContext(dest, item.SELinuxContext); err != nil {
+			tr.logger.Warn("trash: failed to restore SELinux context", "path", dest, "err", err)
+		}
+	}
+	if opts.keepItem {
+		return nil
+	}
+
+	if err := tr.fs.Remove(item.InfoPath); err != nil {
+		tr.fs.Rename(dest, src)
+		return fmt.Errorf("failed to remove info file: %w", err)
+	}
+	if staged != "" {
+		if err := tr.fs.RemoveAll(item.FilePath); err != nil {
+			tr.logger.Warn("trash: failed to remove restored data", "path", item.FilePath, "err", err)
+		}
+	}
+	tr.pruneDataDirs(item)
+	tr.forgetDirectorySize(item)
+
+	tr.emit(EventRestore, item)
+	return nil
+}
+
+// moveOutOfTrash moves the trashed file of item to dest. An item that fell
+// back to the home trash lives on another filesystem than dest, so it is
+// copied instead, leaving the trashed file in place until the copy is
+// complete.
+func (tr *Trasher) moveOutOfTrash(item TrashItem, dest string) error {
+	err := tr.fs.Rename(item.FilePath, dest)
+	if err == nil || !isCrossDeviceError(err) {
+		return err
+	}
+
+	info, err := tr.fs.Lstat(item.FilePath)
+	if err != nil {
+		return err
+	}
+	return tr.copyAcrossDevices(newCopyJob(context.Background(), TrashOptions{}), item.FilePath, dest, info)
+}
+
+// mergeRestore merges the trashed directory item into the existing
+// directory dest, as described for RestoreOptions.Merge.
+func (tr *Trasher) mergeRestore(item TrashItem, opts RestoreOptions, staged, dest string) error {
+	src := item.FilePath
+	if staged != "" {
+		src = staged
+	}
+	info, err := tr.fs.Lstat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat trashed file: %w", err)
+	}
+	if !info.IsDir() {
+		return ErrAlreadyExists
+	}
+
+	// Every conflict is known before anything moves, so the policy
+	// applies to the item as a whole
+	conflicts, err := tr.mergeConflicts(src, dest)
+	if err != nil {
+		return fmt.Errorf("failed to merge directory: %w", err)
+	}
+	if len(conflicts) > 0 {
+		switch opts.Conflict {
+		case ConflictSkip:
+			return nil
+		case ConflictOverwrite, ConflictRename:
+		default:
+			return fmt.Errorf("%w: %s", ErrAlreadyExists, strings.Join(conflicts, ", "))
+		}
+	}
+
+	m := &merge{policy: opts.Conflict}
+	if err := tr.mergeDir(m, src, dest); err != nil {
+		tr.undoMerge(m)
+		return fmt.Errorf("failed to merge directory: %w", err)
+	}
+	tr.finishMerge(m)
+	if opts.keepItem {
+		return nil
+	}
+
+	unlock := tr.lockTrashDir(item.TrashDir)
+	defer unlock()
+	if err := tr.fs.Remove(item.InfoPath); err != nil {
+		return fmt.Errorf("failed to remove info file: %w", err)
+	}
+	if staged != "" {
+		if err := tr.fs.RemoveAll(item.FilePath); err != nil {
+			tr.logger.Warn("trash: failed to remove restored data", "path", item.FilePath, "err", err)
+		}
+	}
+	tr.pruneDataDirs(item)
+	tr.forgetDirectorySize(item)
+
+	tr.emit(EventRestore, item)
+	return nil
+}
+
+// moveStaged renames the staged copy of an item to dest. A rename replaces
+// a file that appeared at dest after it was found free, so a staged file
+// first claims dest with an exclusive create and then replaces only that;
+// a staged directory can't replace anything but an empty directory.
+func (tr *Trasher) moveStaged(staged, dest string) error {
+	info, err := tr.fs.Lstat(staged)
+	if err != nil {
+		return fmt.Errorf("failed to stat staged copy: %w", err)
+	}
+	if !info.IsDir() {
+		f, err := tr.fs.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if os.IsExist(err) {
+			return ErrAlreadyExists
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim restore path: %w", err)
+		}
+		f.Close()
+	}
+
+	if err := tr.fs.Rename(staged, dest); err != nil {
+		if !info.IsDir() {
+			tr.fs.Remove(dest)
+		}
+		if _, statErr := tr.fs.Lstat(dest); statErr == nil {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to restore file: %w", err)
+	}
+	return nil
+}
+
+// mergeConflicts returns the paths in dst that block a child of src from
+// being merged into it: non-directories in the way of a child, or any
+// entry in the way of a non-directory child.
+func (tr *Trasher) mergeConflicts(src, dst string) ([]string, error) {
+	entries, err := tr.fs.ReadDir(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, entry := range entries {
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+
+		toInfo, err := tr.fs.Lstat(to)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !entry.IsDir() || !toInfo.IsDir() {
+			conflicts = append(conflicts, to)
+			continue
+		}
+
+		sub, err := tr.mergeConflicts(from, to)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, sub...)
+	}
+	return conflicts, nil
+}
+
+// A merge records what mergeDir has done, to be finished or undone.
+type merge struct {
+	policy ConflictPolicy
+
+	// moved are the children moved so far, as from/to pairs, and aside
+	// the existing entries ConflictOverwrite moved out of their way, as
+	// entry/aside pairs.
+	moved [][2]string
+	aside [][2]string
+
+	// emptied are the source directories left empty, deepest first.
+	emptied []string
+}
+
+// mergeDir moves the children of src into dst, recursing into directories
+// present in both, and dealing with conflicts by m.policy, recording every
+// change in m.
+func (tr *Trasher) mergeDir(m *merge, src, dst string) error {
+	entries, err := tr.fs.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+
+		toInfo, err := tr.fs.Lstat(to)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil && entry.IsDir() && toInfo.IsDir() {
+			if err := tr.mergeDir(m, from, to); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err == nil {
+			switch m.policy {
+			case ConflictOverwrite:
+				aside := filepath.Join(dst, fmt.Sprintf(".%s.overwritten-%s", entry.Name(), randomSuffix()))
+				if err := tr.fs.Rename(to, aside); err != nil {
+					return fmt.Errorf("failed to move existing file aside: %w", err)
+				}
+				m.aside = append(m.aside, [2]string{to, aside})
+			case ConflictRename:
+				to = tr.freeRestorePath(to)
+			default:
+				return fmt.Errorf("%w: %s", ErrAlreadyExists, to)
+			}
+		}
+
+		// Claimed like a staged copy, so nothing created since the check
+		// is replaced
+		if err := tr.moveStaged(from, to); err != nil {
+			return err
+		}
+		m.moved = append(m.moved, [2]string{from, to})
 	}
 
-	dataHome := os.Getenv("XDG_DATA_HOME")
-	if dataHome == "" {
-		dataHome = filepath.Join(homeDir, ".local", "share")
-	}
+	m.emptied = append(m.emptied, src)
+	return nil
+}
 
-	homeTrash = filepath.Join(dataHome, "Trash")
-	
-	if err := ensureTrashDirs(homeTrash); err != nil {
-		initErr = err
-		return
+// undoMerge moves back whatever m records as moved, and puts back the
+// entries moved out of the way.
+func (tr *Trasher) undoMerge(m *merge) {
+	for i := len(m.moved) - 1; i >= 0; i-- {
+		tr.fs.Rename(m.moved[i][1], m.moved[i][0])
 	}
-
-	currentUser, err := user.Current()
-	if err != nil {
-		initErr = fmt.Errorf("failed to get current user: %w", err)
-		return
+	for i := len(m.aside) - 1; i >= 0; i-- {
+		tr.fs.Rename(m.aside[i][1], m.aside[i][0])
 	}
-
-	uid = currentUser.Uid
 }
 
-func ensureInitialized() error {
-	initOnce.Do(initialize)
-	return initErr
+// finishMerge removes the entries m overwrote and the emptied source
+// directories.
+func (tr *Trasher) finishMerge(m *merge) {
+	for _, pair := range m.aside {
+		tr.fs.RemoveAll(pair[1])
+	}
+	for _, dir := range m.emptied {
+		tr.fs.Remove(dir)
+	}
 }
 
-func ensureTrashDirs(trashDir string) error {
-	dirs := []string{
-		filepath.Join(trashDir, "files"),
-		filepath.Join(trashDir, "info"),
-	}
+// checkRestoreParent returns ErrSymlinkedParent if the nearest existing
+// ancestor of dir (dir itself included) is a symlink.
+func (tr *Trasher) checkRestoreParent(dir string) error {
+	for {
+		info, err := tr.fs.Lstat(dir)
+		if err == nil {
+			if info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Errorf("%w: %s", ErrSymlinkedParent, dir)
+			}
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to stat parent directory: %w", err)
+		}
 
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0700); err != nil {
-			return fmt.Errorf("failed to create trash directory %s: %w", dir, err)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
 		}
+		dir = parent
 	}
-
-	return nil
 }
 
-func Trash(path string) error {
-	if err := ensureInitialized(); err != nil {
-		return err
-	}
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
-	}
-
-	info, err := os.Lstat(absPath)
-	if err != nil {
-		return fmt.Errorf("failed to stat file: %w", err)
+// restorePath returns the location an item recorded as originalPath should be
+// restored to, resolving relative paths against base.
+func restorePath(originalPath, base string) (string, error) {
+	if filepath.IsAbs(originalPath) {
+		return originalPath, nil
 	}
 
-	trashDir, err := getTrashDirForPath(absPath)
+	dest, err := filepath.Abs(filepath.Join(base, originalPath))
 	if err != nil {
-		return fmt.Errorf("failed to determine trash directory: %w", err)
-	}
-
-	if err := ensureTrashDirs(trashDir); err != nil {
-		return fmt.Errorf("failed to create trash directories: %w", err)
+		return "", fmt.Errorf("failed to resolve restore path: %w", err)
 	}
 
-	baseName := filepath.Base(absPath)
-	trashName := generateTrashNameInDir(baseName, trashDir)
-	
-	filesPath := filepath.Join(trashDir, "files", trashName)
-	infoPath := filepath.Join(trashDir, "info", trashName+".trashinfo")
+	return dest, nil
+}
 
-	if err := writeTrashInfo(infoPath, absPath, time.Now()); err != nil {
-		return fmt.Errorf("failed to write trash info: %w", err)
-	}
+// Find returns the trashed item trashName, looking in the home trash and
+// the trash directories of all mounted filesystems, or ErrFileNotInTrash.
+// Unlike List, it reads only the item's own trashinfo once the trash
+// directory holding it is known.
+func (tr *Trasher) Find(trashName string) (TrashItem, error) {
+	return tr.findTrashItem(trashName)
+}
 
-	if err := moveToTrash(absPath, filesPath, info); err != nil {
-		os.Remove(infoPath)
-		return fmt.Errorf("failed to move to trash: %w", err)
+func (tr *Trasher) findTrashItem(trashName string) (TrashItem, error) {
+	trashDir, ok, _ := tr.locate(trashName)
+	if !ok {
+		return TrashItem{}, ErrFileNotInTrash
 	}
-
-	return nil
+	return tr.parseTrashInfo(trashDir, trashName)
 }
 
-func generateTrashName(baseName string) string {
-	return generateTrashNameInDir(baseName, homeTrash)
+// Empty permanently removes every item from the home trash and the trash
+// directories of all mounted filesystems.
+func (tr *Trasher) Empty() error {
+	_, err := tr.EmptyContext(context.Background())
+	return err
 }
 
-func generateTrashNameInDir(baseName string, trashDir string) string {
-	baseName = sanitizeFilename(baseName)
-	
-	for i := 0; i < 100; i++ {
-		name := baseName
-		if i > 0 {
-			name = fmt.Sprintf("%s.%d", baseName, i)
-		}
-		
-		filesPath := filepath.Join(trashDir, "files", name)
-		infoPath := filepath.Join(trashDir, "info", name+".trashinfo")
-		
-		if _, err := os.Lstat(filesPath); os.IsNotExist(err) {
-			if _, err := os.Lstat(infoPath); os.IsNotExist(err) {
-				return name
-			}
-		}
-	}
-	
-	randomBytes := make([]byte, 8)
-	rand.Read(randomBytes)
-	return fmt.Sprintf("%s.%s", baseName, hex.EncodeToString(randomBytes))
+// EmptyResult reports how much an EmptyContext call removed.
+type EmptyResult struct {
+	// Removed is the number of trashed items removed.
+	Removed int
+
+	// Orphans is the number of entries in files/ that had no trashinfo,
+	// e.g. left behind by an earlier interrupted Empty, and were removed.
+	Orphans int
 }
 
-func sanitizeFilename(name string) string {
-	if name == "" {
-		return "unnamed"
-	}
-	
-	name = strings.TrimSpace(name)
-	
-	if strings.HasPrefix(name, ".") && len(name) == 1 {
-		return "dot"
-	}
-	
-	return name
+// EmptyOptions configures EmptyWithOptions. The zero value behaves exactly
+// like EmptyContext.
+type EmptyOptions struct {
+	// RemoveMountTrashDirs also removes the .Trash-$uid or .Trash/$uid
+	// directory of each mounted filesystem once it has been emptied, leaving
+	// removable drives as they were before anything was trashed on them. A
+	// shared .Trash set up by an administrator is kept, as is the home
+	// trash.
+	RemoveMountTrashDirs bool
 }
 
-func writeTrashInfo(infoPath, originalPath string, deletionTime time.Time) error {
-	encodedPath := url.QueryEscape(originalPath)
-	encodedPath = strings.ReplaceAll(encodedPath, "+", "%20")
-	
-	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
-		encodedPath,
-		deletionTime.UTC().Format("2006-01-02T15:04:05"))
-	
-	return os.WriteFile(infoPath, []byte(content), 0600)
+// EmptyContext is like Empty but stops when ctx is done. Items are removed
+// one at a time, trashinfo first, so an interrupted Empty leaves every
+// remaining item intact, and running it again picks up where it stopped.
+func (tr *Trasher) EmptyContext(ctx context.Context) (EmptyResult, error) {
+	return tr.EmptyWithOptions(ctx, EmptyOptions{})
 }
 
-func moveToTrash(src, dst string, info os.FileInfo) error {
-	err := os.Rename(src, dst)
-	if err == nil {
-		return nil
-	}
-	
-	if !isCrossDeviceError(err) {
-		return err
-	}
-	
-	if info.IsDir() {
-		return copyDirAcrossDevices(src, dst)
+// EmptyWithOptions is like EmptyContext but configurable through opts.
+func (tr *Trasher) EmptyWithOptions(ctx context.Context, opts EmptyOptions) (result EmptyResult, err error) {
+	tr.progress("empty", "", 0, statusStarted)
+	defer func() { tr.progressEnd("empty", "", 0, err) }()
+
+	// Empty home trash
+	if err := tr.emptyTrashDir(ctx, tr.homeTrash, &result); err != nil {
+		return result, err
 	}
-	
-	return copyFileAcrossDevices(src, dst, info)
-}
 
-func copyFileAcrossDevices(src, dst string, info os.FileInfo) error {
-	// Handle symbolic links specially
-	if info.Mode()&os.ModeSymlink != 0 {
-		link, err := os.Readlink(src)
-		if err != nil {
-			return fmt.Errorf("failed to read symlink: %w", err)
+	// Empty trash on all mounted filesystems
+	for _, trashDir := range tr.mountTrashDirs() {
+		if err := tr.emptyTrashDir(ctx, trashDir, &result); err != nil {
+			return result, err
 		}
-		
-		if err := os.Symlink(link, dst); err != nil {
-			return fmt.Errorf("failed to create symlink: %w", err)
+
+		if opts.RemoveMountTrashDirs && !tr.isRecycleBinDir(trashDir) {
+			if err := tr.fs.RemoveAll(trashDir); err != nil {
+				return result, fmt.Errorf("failed to remove trash directory: %w", err)
+			}
 		}
-		
-		// Note: os.Chtimes doesn't work on symlinks on most systems
-		// The symlink will have the current time as its modification time
-		
-		return os.Remove(src)
 	}
-	
-	// Regular file handling
-	srcFile, err := os.Open(src)
+
+	return result, nil
+}
+
+// EmptyDir permanently removes every item from the single trash directory
+// trashDir, such as the .Trash-$uid of one external drive, leaving all
+// other trash directories alone. Since emptying removes everything in
+// files/, trashDir must be the home trash or a trash directory of a mounted
+// filesystem, as used by List; anything else fails with ErrTrashNotFound.
+func (tr *Trasher) EmptyDir(trashDir string) error {
+	trashDir, err := tr.knownTrashDir(trashDir)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
-	
-	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, info.Mode())
+
+	var result EmptyResult
+	return tr.emptyTrashDir(context.Background(), trashDir, &result)
+}
+
+// knownTrashDir returns the absolute path of trashDir if it is the home
+// trash or a trash directory of a mounted filesystem, as used by List, and
+// fails with ErrTrashNotFound otherwise.
+func (tr *Trasher) knownTrashDir(trashDir string) (string, error) {
+	trashDir, err := filepath.Abs(trashDir)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
-	defer dstFile.Close()
-	
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		os.Remove(dst)
-		return err
-	}
-	
-	if err := dstFile.Close(); err != nil {
-		os.Remove(dst)
-		return err
+
+	known := trashDir == tr.homeTrash
+	for _, dir := range tr.mountTrashDirs() {
+		known = known || dir == trashDir
 	}
-	
-	if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
-		os.Remove(dst)
-		return err
+	if !known {
+		return "", fmt.Errorf("%w: %s", ErrTrashNotFound, trashDir)
 	}
-	
-	return os.Remove(src)
+	return trashDir, nil
 }
 
-func copyDirAcrossDevices(src, dst string) error {
-	if err := os.MkdirAll(dst, 0755); err != nil {
-		return err
+func (tr *Trasher) emptyTrashDir(ctx context.Context, trashDir string, result *EmptyResult) error {
+	if tr.isRecycleBinDir(trashDir) {
+		return tr.emptyRecycleBin(ctx, trashDir, result)
 	}
-	
-	entries, err := os.ReadDir(src)
+
+	// Recounted even if emptying stops partway
+	defer tr.recountDevice(trashDir)
+	defer tr.lockTrashDir(trashDir)()
+	defer tr.pruneDirectorySizes(trashDir)
+
+	names, err := tr.layout.Entries(trashDir, tr.fs.ReadDir)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to read info directory: %w", err)
 	}
-	
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
-		
-		info, err := entry.Info()
-		if err != nil {
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		
-		// Check if it's a symlink before checking if it's a directory
-		// because symlinks to directories would return true for IsDir()
-		if info.Mode()&os.ModeSymlink != 0 {
-			if err := copyFileAcrossDevices(srcPath, dstPath, info); err != nil {
-				return err
+
+		// Parse before removing the info, since the data path may depend on it
+		item, err := tr.parseTrashInfo(trashDir, name)
+		if err != nil {
+			item = TrashItem{
+				Name:     name,
+				InfoPath: tr.layout.InfoPath(trashDir, name),
+				FilePath: tr.layout.DataPath(trashDir, name, time.Time{}),
+				TrashDir: trashDir,
 			}
-		} else if entry.IsDir() {
-			if err := copyDirAcrossDevices(srcPath, dstPath); err != nil {
+		}
+
+		if err := tr.fs.Remove(item.InfoPath); err != nil {
+			return fmt.Errorf("failed to remove info file: %w", err)
+		}
+
+		if err := tr.fs.RemoveAll(item.FilePath); err != nil {
+			return fmt.Errorf("failed to remove file: %w", err)
+		}
+		tr.pruneDataDirs(item)
+		result.Removed++
+		tr.emit(EventDelete, item)
+		tr.progress("delete", item.OriginalPath, 0, statusDone)
+	}
+
+	// Whatever is left has no info, and so is not a listable item
+	for _, dir := range tr.layout.Dirs(trashDir) {
+		entries, err := tr.fs.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read trash directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
 				return err
 			}
-		} else {
-			if err := copyFileAcrossDevices(srcPath, dstPath, info); err != nil {
-				return err
+
+			if err := tr.fs.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return fmt.Errorf("failed to remove file: %w", err)
 			}
+			result.Orphans++
 		}
 	}
-	
-	srcInfo, err := os.Stat(src)
+
+	return nil
+}
+
+// Delete permanently removes the trashed item trashName.
+func (tr *Trasher) Delete(trashName string) error {
+	item, err := tr.findTrashItem(trashName)
 	if err != nil {
 		return err
 	}
-	
-	if err := os.Chtimes(dst, srcInfo.ModTime(), srcInfo.ModTime()); err != nil {
-		return err
-	}
-	
-	return os.RemoveAll(src)
+
+	return tr.deleteItem(item)
 }
 
-func List() ([]TrashItem, error) {
-	if err := ensureInitialized(); err != nil {
-		return nil, err
-	}
-	var items []TrashItem
-	
-	// List items from home trash
-	homeItems, err := listTrashDir(homeTrash)
-	if err == nil {
-		items = append(items, homeItems...)
-	}
-	
-	// List items from all mounted filesystems
-	mountPoints, err := getMountPoints()
-	if err == nil {
-		for _, mount := range mountPoints {
-			if mount == "/" {
-				continue // Already handled by home trash
-			}
-			
-			trashDir := filepath.Join(mount, ".Trash-"+uid)
-			if info, err := os.Stat(trashDir); err == nil && info.IsDir() {
-				mountItems, err := listTrashDir(trashDir)
-				if err == nil {
-					items = append(items, mountItems...)
-				}
-			}
+func (tr *Trasher) deleteItem(item TrashItem) (err error) {
+	defer func() {
+		if err == nil {
+			tr.recountDevice(item.TrashDir)
 		}
+	}()
+	defer tr.lockTrashDir(item.TrashDir)()
+
+	if err := tr.fs.RemoveAll(item.FilePath); err != nil {
+		return fmt.Errorf("failed to remove file: %w", err)
 	}
-	
-	return items, nil
+
+	if err := tr.fs.Remove(item.InfoPath); err != nil {
+		return fmt.Errorf("failed to remove info file: %w", err)
+	}
+	tr.pruneDataDirs(item)
+	tr.forgetDirectorySize(item)
+
+	tr.emit(EventDelete, item)
+	return nil
 }
 
-func listTrashDir(trashDir string) ([]TrashItem, error) {
-	infoDir := filepath.Join(trashDir, "info")
-	entries, err := os.ReadDir(infoDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []TrashItem{}, nil
+// pruneDataDirs removes the directories a layout nested item's data in,
+// such as DatedLayout's date directories, once they are empty.
+func (tr *Trasher) pruneDataDirs(item TrashItem) {
+	roots := tr.layout.Dirs(item.TrashDir)
+	for dir := filepath.Dir(item.FilePath); ; dir = filepath.Dir(dir) {
+		if !strings.HasPrefix(dir, item.TrashDir+string(filepath.Separator)) {
+			return
 		}
-		return nil, fmt.Errorf("failed to read info directory: %w", err)
-	}
-	
-	var items []TrashItem
-	
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".trashinfo") {
-			continue
+		for _, root := range roots {
+			if dir == root {
+				return
+			}
 		}
-		
-		infoPath := filepath.Join(infoDir, entry.Name())
-		item, err := parseTrashInfo(infoPath, trashDir)
-		if err != nil {
-			continue
+		// Fails, and so stops, at the first directory still in use
+		if err := tr.fs.Remove(dir); err != nil {
+			return
 		}
-		
-		items = append(items, item)
 	}
-	
-	return items, nil
 }
 
-func parseTrashInfo(infoPath string, trashDir string) (TrashItem, error) {
-	content, err := os.ReadFile(infoPath)
-	if err != nil {
-		return TrashItem{}, err
-	}
-	
-	lines := strings.Split(string(content), "\n")
-	if len(lines) < 3 || lines[0] != "[Trash Info]" {
-		return TrashItem{}, ErrInvalidTrashInfo
-	}
-	
-	var originalPath string
-	var deletionDate time.Time
-	
-	for _, line := range lines[1:] {
-		if strings.HasPrefix(line, "Path=") {
-			pathStr := strings.TrimPrefix(line, "Path=")
-			originalPath, _ = url.QueryUnescape(pathStr)
-		} else if strings.HasPrefix(line, "DeletionDate=") {
-			dateStr := strings.TrimPrefix(line, "DeletionDate=")
-			deletionDate, _ = time.Parse("2006-01-02T15:04:05", dateStr)
-		}
-	}
-	
-	if originalPath == "" {
-		return TrashItem{}, ErrInvalidTrashInfo
-	}
-	
-	baseName := strings.TrimSuffix(filepath.Base(infoPath), ".trashinfo")
-	
-	return TrashItem{
-		Name:         baseName,
-		OriginalPath: originalPath,
-		DeletionDate: deletionDate,
-		InfoPath:     infoPath,
-		FilePath:     filepath.Join(trashDir, "files", baseName),
-		TrashDir:     trashDir,
-	}, nil
+// getTrashDirForPath returns the trash directory for path, and whether it
+// is the home trash only because the trash on path's filesystem is unusable.
+func (tr *Trasher) getTrashDirForPath(path string) (string, bool, error) {
+	return tr.resolveTrashDir(path, true)
 }
 
-func Restore(trashName string) error {
-	if err := ensureInitialized(); err != nil {
-		return err
-	}
-	item, err := findTrashItem(trashName)
+// resolveTrashDir is getTrashDirForPath, but only creates a missing trash
+// directory on path's filesystem if create is set.
+func (tr *Trasher) resolveTrashDir(path string, create bool) (string, bool, error) {
+	pathMount, err := tr.mountPoint(path)
 	if err != nil {
-		return err
-	}
-	
-	if _, err := os.Lstat(item.OriginalPath); err == nil {
-		return ErrAlreadyExists
-	}
-	
-	dir := filepath.Dir(item.OriginalPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create parent directory: %w", err)
-	}
-	
-	if err := os.Rename(item.FilePath, item.OriginalPath); err != nil {
-		return fmt.Errorf("failed to restore file: %w", err)
+		return "", false, err
 	}
-	
-	if err := os.Remove(item.InfoPath); err != nil {
-		os.Rename(item.OriginalPath, item.FilePath)
-		return fmt.Errorf("failed to remove info file: %w", err)
+
+	// The home trash may not have been created yet
+	homeMount, err := tr.mountPoint(tr.nearestExisting(tr.homeTrash))
+	if err != nil {
+		return "", false, err
 	}
-	
-	return nil
-}
 
-func findTrashItem(trashName string) (TrashItem, error) {
-	// Check home trash first
-	infoPath := filepath.Join(homeTrash, "info", trashName+".trashinfo")
-	if _, err := os.Stat(infoPath); err == nil {
-		return parseTrashInfo(infoPath, homeTrash)
+	// If on same filesystem as home, use home trash
+	if pathMount == homeMount {
+		return tr.homeTrash, false, nil
 	}
-	
-	// Check all mounted filesystems
-	mountPoints, err := getMountPoints()
-	if err == nil {
-		for _, mount := range mountPoints {
-			if mount == "/" {
-				continue
-			}
-			
-			trashDir := filepath.Join(mount, ".Trash-"+uid)
-			infoPath := filepath.Join(trashDir, "info", trashName+".trashinfo")
-			if _, err := os.Stat(infoPath); err == nil {
-				return parseTrashInfo(infoPath, trashDir)
-			}
+
+	// Otherwise, use .Trash/$uid or .Trash-$uid on the mount point
+	for _, trashDir := range tr.mountTrashCandidates(pathMount) {
+		if err := tr.checkTrashDirSecurity(trashDir, create); err == nil {
+			return trashDir, false, nil
 		}
 	}
-	
-	return TrashItem{}, ErrFileNotInTrash
+
+	// If we can't use the trash dir on this mount, fall back to home trash
+	// This may result in cross-device moves, but it's better than failing
+	return tr.homeTrash, true, nil
 }
 
-func Empty() error {
-	if err := ensureInitialized(); err != nil {
-		return err
-	}
-	// Empty home trash
-	if err := emptyTrashDir(homeTrash); err != nil {
-		return err
-	}
-	
-	// Empty trash on all mounted filesystems
-	mountPoints, err := getMountPoints()
-	if err == nil {
-		for _, mount := range mountPoints {
-			if mount == "/" {
-				continue
-			}
-			
-			trashDir := filepath.Join(mount, ".Trash-"+uid)
-			if info, err := os.Stat(trashDir); err == nil && info.IsDir() {
-				if err := emptyTrashDir(trashDir); err != nil {
-					return err
-				}
-			}
+// evalSymlinks is filepath.EvalSymlinks for an absolute path, resolved
+// through tr.fs.
+func (tr *Trasher) evalSymlinks(path string) (string, error) {
+	resolved := filepath.VolumeName(path) + string(filepath.Separator)
+	pending := splitPath(path)
+	hops := 0
+
+	for len(pending) > 0 {
+		elem := pending[0]
+		pending = pending[1:]
+
+		if elem == ".." {
+			resolved = filepath.Dir(resolved)
+			continue
 		}
-	}
-	
-	return nil
-}
 
-func emptyTrashDir(trashDir string) error {
-	filesDir := filepath.Join(trashDir, "files")
-	infoDir := filepath.Join(trashDir, "info")
-	
-	if err := emptyDir(filesDir); err != nil {
-		return fmt.Errorf("failed to empty files directory: %w", err)
-	}
-	
-	if err := emptyDir(infoDir); err != nil {
-		return fmt.Errorf("failed to empty info directory: %w", err)
-	}
-	
-	return nil
-}
+		next := filepath.Join(resolved, elem)
+		info, err := tr.fs.Lstat(next)
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
 
-func emptyDir(dir string) error {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return err
-	}
-	
-	for _, entry := range entries {
-		path := filepath.Join(dir, entry.Name())
-		if err := os.RemoveAll(path); err != nil {
-			return err
+		if hops++; hops > maxSymlinkHops {
+			return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+		}
+		target, err := tr.fs.Readlink(next)
+		if err != nil {
+			return "", err
 		}
+		if filepath.IsAbs(target) {
+			resolved = filepath.VolumeName(target) + string(filepath.Separator)
+		}
+		pending = append(splitPath(target), pending...)
 	}
-	
-	return nil
+
+	return resolved, nil
 }
 
-func Delete(trashName string) error {
-	if err := ensureInitialized(); err != nil {
-		return err
-	}
-	item, err := findTrashItem(trashName)
-	if err != nil {
-		return err
-	}
-	
-	if err := os.RemoveAll(item.FilePath); err != nil {
-		return fmt.Errorf("failed to remove file: %w", err)
-	}
-	
-	if err := os.Remove(item.InfoPath); err != nil {
-		return fmt.Errorf("failed to remove info file: %w", err)
+// splitPath returns the elements of path after its volume name, dropping
+// empty and "." elements.
+func splitPath(path string) []string {
+	path = path[len(filepath.VolumeName(path)):]
+
+	var elems []string
+	for _, elem := range strings.Split(filepath.ToSlash(path), "/") {
+		if elem != "" && elem != "." {
+			elems = append(elems, elem)
+		}
 	}
-	
-	return nil
+	return elems
 }
 
-func getTrashDirForPath(path string) (string, error) {
-	pathMount, err := getMountPoint(path)
-	if err != nil {
-		return "", err
-	}
-	
-	homeMount, err := getMountPoint(homeTrash)
-	if err != nil {
-		return "", err
+// nearestExisting returns path or, if it doesn't exist, its closest
+// existing ancestor.
+func (tr *Trasher) nearestExisting(path string) string {
+	for {
+		if _, err := tr.fs.Lstat(path); err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
 	}
-	
-	// If on same filesystem as home, use home trash
-	if pathMount == homeMount {
-		return homeTrash, nil
-	}
-	
-	// Otherwise, use .Trash-$uid on the mount point
-	trashDir := filepath.Join(pathMount, ".Trash-"+uid)
-	
-	// Check if we can create/use this trash directory
-	if err := checkTrashDirSecurity(trashDir); err != nil {
-		// If we can't use the trash dir on this mount, fall back to home trash
-		// This may result in cross-device moves, but it's better than failing
-		return homeTrash, nil
-	}
-	
-	return trashDir, nil
 }
 
-func checkTrashDirSecurity(trashDir string) error {
-	info, err := os.Stat(trashDir)
+// checkTrashDirSecurity verifies that trashDir is safe to use: a directory
+// rather than a symlink, owned by the trash's user or the process, and not
+// writable by group or others. It creates trashDir if it doesn't exist and
+// create is set.
+func (tr *Trasher) checkTrashDirSecurity(trashDir string, create bool) error {
+	info, err := tr.fs.Lstat(trashDir)
 	if os.IsNotExist(err) {
+		if !create {
+			return nil
+		}
 		// Try to create it
-		if err := os.MkdirAll(trashDir, 0700); err != nil {
+		if err := tr.fs.MkdirAll(trashDir, 0700); err != nil {
 			return err
 		}
 		return nil
@@ -576,16 +2580,24 @@ func checkTrashDirSecurity(trashDir string) error {
 	if err != nil {
 		return err
 	}
-	
-	// Check that it's a directory
+
+	// Check that it's a directory, not a symlink to one somebody else
+	// could redirect
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("trash path is a symlink")
+	}
 	if !info.IsDir() {
 		return fmt.Errorf("trash path exists but is not a directory")
 	}
-	
-	// Check permissions (should be 0700)
-	if info.Mode().Perm() != 0700 {
-		return fmt.Errorf("trash directory has incorrect permissions")
+
+	// Others may be allowed to look, as with 0750 or 0711, but not to
+	// change what's in it
+	if owner, ok := fileOwner(info); ok && owner != tr.uid && owner != strconv.Itoa(getuid()) {
+		return fmt.Errorf("trash directory is owned by uid %s", owner)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("trash directory is writable by others")
 	}
-	
+
 	return nil
 }
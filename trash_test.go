@@ -1,9 +1,20 @@
 package trash
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/user"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestTrash(t *testing.T) {
@@ -279,18 +290,590 @@ func TestTrash(t *testing.T) {
 	})
 }
 
-func TestTrashNameGeneration(t *testing.T) {
+func TestRestoreAndTrashConflict(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "conflict.txt")
+	if err := os.WriteFile(testFile, []byte("original"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	if err := os.WriteFile(testFile, []byte("new file"), 0644); err != nil {
+		t.Fatalf("Failed to create conflicting file: %v", err)
+	}
+
+	items, err := List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+
+	var itemName string
+	for _, item := range items {
+		if item.OriginalPath == testFile {
+			itemName = item.Name
+			break
+		}
+	}
+
+	conflictName, err := RestoreAndTrashConflict(itemName)
+	if err != nil {
+		t.Fatalf("Failed to restore with conflict: %v", err)
+	}
+
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read restored file: %v", err)
+	}
+	if string(content) != "original" {
+		t.Errorf("Restored content = %q, want %q", content, "original")
+	}
+
+	tr, err := getDefault()
+	if err != nil {
+		t.Fatalf("Failed to get default trasher: %v", err)
+	}
+
+	conflict, err := tr.findTrashItem(conflictName)
+	if err != nil {
+		t.Fatalf("Conflicting file not found in trash: %v", err)
+	}
+	if conflict.OriginalPath != testFile {
+		t.Errorf("Conflict original path = %s, want %s", conflict.OriginalPath, testFile)
+	}
+
+	content, err = os.ReadFile(conflict.FilePath)
+	if err != nil {
+		t.Fatalf("Failed to read trashed conflict: %v", err)
+	}
+	if string(content) != "new file" {
+		t.Errorf("Trashed conflict content = %q, want %q", content, "new file")
+	}
+}
+
+func TestRestoreSymlinkedParent(t *testing.T) {
 	tempDir := t.TempDir()
+	parent := filepath.Join(tempDir, "parent")
+	elsewhere := filepath.Join(tempDir, "elsewhere")
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		t.Fatalf("Failed to create parent directory: %v", err)
+	}
+	if err := os.MkdirAll(elsewhere, 0755); err != nil {
+		t.Fatalf("Failed to create redirect target: %v", err)
+	}
 
-	for i := 0; i < 5; i++ {
-		testFile := filepath.Join(tempDir, "duplicate.txt")
-		if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+	testFile := filepath.Join(parent, "file.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	// Swap the now-empty parent for a symlink pointing somewhere else
+	if err := os.Remove(parent); err != nil {
+		t.Fatalf("Failed to remove parent directory: %v", err)
+	}
+	if err := os.Symlink(elsewhere, parent); err != nil {
+		t.Skipf("Failed to create symlink (may not be supported): %v", err)
+	}
+
+	if err := tr.Restore("file.txt"); !errors.Is(err, ErrSymlinkedParent) {
+		t.Fatalf("Expected ErrSymlinkedParent, got: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(elsewhere, "file.txt")); !os.IsNotExist(err) {
+		t.Error("File was restored through the symlinked parent")
+	}
+
+	if err := tr.RestoreWithOptions("file.txt", RestoreOptions{FollowSymlinks: true}); err != nil {
+		t.Fatalf("Failed to restore with FollowSymlinks: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(elsewhere, "file.txt")); err != nil {
+		t.Errorf("File not restored through the symlinked parent: %v", err)
+	}
+}
+
+func TestPeekHasNoSideEffects(t *testing.T) {
+	home := t.TempDir()
+	tr, err := New(WithEnv([]string{"HOME=" + home}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	if tr.TrashDirExists() {
+		t.Error("TrashDirExists reported a trash that was never created")
+	}
+	if _, err := tr.HasTrash(); err != nil {
+		t.Errorf("HasTrash failed: %v", err)
+	}
+	if _, err := tr.List(); err != nil {
+		t.Errorf("List failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".local")); !os.IsNotExist(err) {
+		t.Fatalf("Peeking created directories under %s", home)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "peek.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	if !tr.TrashDirExists() {
+		t.Error("TrashDirExists is false after trashing")
+	}
+	if ok, err := tr.HasTrash(); err != nil || !ok {
+		t.Errorf("HasTrash = %v, %v after trashing, want true", ok, err)
+	}
+}
+
+func TestListGrouped(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+	paths := []string{
+		filepath.Join(dirA, "one.txt"),
+		filepath.Join(dirA, "two.txt"),
+		filepath.Join(dirB, "three.txt"),
+	}
+
+	for _, path := range paths {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
 			t.Fatalf("Failed to create test file: %v", err)
 		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
 
-		if err := Trash(testFile); err != nil {
-			t.Fatalf("Failed to trash file %d: %v", i, err)
+	groups, err := tr.ListGrouped()
+	if err != nil {
+		t.Fatalf("Failed to list grouped trash: %v", err)
+	}
+
+	if len(groups) != 2 {
+		t.Errorf("Expected 2 groups, got %d", len(groups))
+	}
+	if n := len(groups[dirA]); n != 2 {
+		t.Errorf("Expected 2 items from %s, got %d", dirA, n)
+	}
+	if n := len(groups[dirB]); n != 1 {
+		t.Errorf("Expected 1 item from %s, got %d", dirB, n)
+	}
+}
+
+func TestTrashAt(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "dated.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.TrashAt(testFile, time.Now().Add(48*time.Hour)); !errors.Is(err, ErrInvalidDate) {
+		t.Fatalf("Expected ErrInvalidDate for a future date, got: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Fatalf("File was moved despite an invalid date: %v", err)
+	}
+
+	deletionTime := time.Date(2020, time.March, 4, 5, 6, 7, 0, time.UTC)
+	if err := tr.TrashAt(testFile, deletionTime); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item in trash, got %d", len(items))
+	}
+	if !items[0].DeletionDate.Equal(deletionTime) {
+		t.Errorf("DeletionDate = %v, want %v", items[0].DeletionDate, deletionTime)
+	}
+}
+
+func TestCopyMaxDepth(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "deep")
+	deepest := filepath.Join(src, "a", "b", "c", "d")
+	if err := os.MkdirAll(deepest, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(deepest, "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		t.Fatalf("Failed to stat test directory: %v", err)
+	}
+
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	dst := filepath.Join(tempDir, "copy")
+	job := newCopyJob(context.Background(), TrashOptions{MaxDepth: 2})
+	err = tr.copyAcrossDevices(job, src, dst, info)
+	if !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Fatalf("Expected ErrMaxDepthExceeded, got: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(deepest, "file.txt")); err != nil {
+		t.Errorf("Source was modified by a failed copy: %v", err)
+	}
+	if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+		t.Error("Partial copy left behind after failure")
+	}
+
+	job.opts.MaxDepth = 4
+	if err := tr.copyAcrossDevices(job, src, dst, info); err != nil {
+		t.Fatalf("Copy within the depth limit failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a", "b", "c", "d", "file.txt")); err != nil {
+		t.Errorf("Copied tree is incomplete: %v", err)
+	}
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Error("Source still exists after a successful copy")
+	}
+}
+
+// newTestTrasher returns a Trasher whose home trash lives in a temporary
+// directory, so tests don't touch the user's real trash.
+func newTestTrasher(t *testing.T) *Trasher {
+	t.Helper()
+	return newTestTrasherAt(t, t.TempDir())
+}
+
+// newTestTrasherAt is newTestTrasher for a given home directory, for tests
+// that need several Trashers to share one trash.
+func newTestTrasherAt(t *testing.T, home string) *Trasher {
+	t.Helper()
+
+	tr, err := New(WithEnv([]string{"HOME=" + home}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+	return tr
+}
+
+// fakeMount makes tr treat dir as the mount point of a separate filesystem,
+// with everything else on the root filesystem.
+func fakeMount(tr *Trasher, dir string) {
+	tr.mountPoint = func(path string) (string, error) {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return dir, nil
+		}
+		return "/", nil
+	}
+	tr.mountPoints = func() ([]string, error) {
+		return []string{"/", dir}, nil
+	}
+}
+
+// cancelFS cancels a context once a given number of trashinfo files have
+// been removed.
+type cancelFS struct {
+	osFS
+	after  int
+	cancel context.CancelFunc
+}
+
+func (fs *cancelFS) Remove(path string) error {
+	err := os.Remove(path)
+	if strings.HasSuffix(path, ".trashinfo") {
+		fs.after--
+		if fs.after == 0 {
+			fs.cancel()
+		}
+	}
+	return err
+}
+
+func TestEmptyContextResume(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tr.fs = &cancelFS{after: 3, cancel: cancel}
+
+	result, err := tr.EmptyContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if result.Removed != 3 {
+		t.Errorf("Removed = %d before cancellation, want 3", result.Removed)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 7 {
+		t.Fatalf("Expected 7 items left after cancellation, got %d", len(items))
+	}
+	for _, item := range items {
+		if _, err := os.Lstat(item.FilePath); err != nil {
+			t.Errorf("Remaining item %s lost its data: %v", item.Name, err)
+		}
+	}
+
+	tr.fs = osFS{}
+	result, err = tr.EmptyContext(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to resume empty: %v", err)
+	}
+	if result.Removed != 7 || result.Orphans != 0 {
+		t.Errorf("Resumed result = %+v, want 7 removed and no orphans", result)
+	}
+
+	items, err = tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("Expected an empty trash, got %d items", len(items))
+	}
+}
+
+func TestSharedMountTrash(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	shared := filepath.Join(mount, ".Trash")
+	if err := os.Mkdir(shared, 0777); err != nil {
+		t.Fatalf("Failed to create shared trash: %v", err)
+	}
+	if err := os.Chmod(shared, 0777|os.ModeSticky); err != nil {
+		t.Fatalf("Failed to set sticky bit: %v", err)
+	}
+	sharedTrash := filepath.Join(shared, tr.uid)
+
+	testFile := filepath.Join(mount, "report.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.TrashDir != sharedTrash {
+		t.Errorf("TrashDir = %s, want %s", result.TrashDir, sharedTrash)
+	}
+
+	// Another tool's item in the shared trash is listed and restorable
+	other := newTestTrasher(t)
+	fakeMount(other, mount)
+	items, err := other.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].TrashDir != sharedTrash {
+		t.Fatalf("List() = %+v, want the item in %s", items, sharedTrash)
+	}
+	if err := other.Restore(items[0].Name); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Restored file is missing: %v", err)
+	}
+
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if _, err := tr.EmptyWithOptions(context.Background(), EmptyOptions{RemoveMountTrashDirs: true}); err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if _, err := os.Stat(sharedTrash); !os.IsNotExist(err) {
+		t.Errorf("Emptied user trash still exists: %v", err)
+	}
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("Shared trash was removed: %v", err)
+	}
+}
+
+func TestSharedMountTrashUnsafe(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T, shared string){
+		"NotSticky": func(t *testing.T, shared string) {
+			if err := os.Mkdir(shared, 0777); err != nil {
+				t.Fatalf("Failed to create shared trash: %v", err)
+			}
+		},
+		"Symlink": func(t *testing.T, shared string) {
+			target := t.TempDir()
+			if err := os.Chmod(target, 0777|os.ModeSticky); err != nil {
+				t.Fatalf("Failed to set sticky bit: %v", err)
+			}
+			if err := os.Symlink(target, shared); err != nil {
+				t.Skipf("Can't create symlinks here: %v", err)
+			}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tr := newTestTrasher(t)
+			mount := t.TempDir()
+			fakeMount(tr, mount)
+			setup(t, filepath.Join(mount, ".Trash"))
+
+			testFile := filepath.Join(mount, "report.txt")
+			if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			result, err := tr.TrashWithResult(testFile, TrashOptions{})
+			if err != nil {
+				t.Fatalf("Failed to trash file: %v", err)
+			}
+			if want := filepath.Join(mount, ".Trash-"+tr.uid); result.TrashDir != want {
+				t.Errorf("TrashDir = %s, want %s", result.TrashDir, want)
+			}
+		})
+	}
+}
+
+func TestMountTrashPermissions(t *testing.T) {
+	for _, tt := range []struct {
+		mode   os.FileMode
+		usable bool
+	}{
+		{0700, true},
+		{0750, true},
+		{0711, true},
+		{0770, false},
+		{0777, false},
+	} {
+		t.Run(fmt.Sprintf("%04o", tt.mode), func(t *testing.T) {
+			tr := newTestTrasher(t)
+			mount := t.TempDir()
+			fakeMount(tr, mount)
+
+			mountTrash := filepath.Join(mount, ".Trash-"+tr.uid)
+			if err := os.Mkdir(mountTrash, 0700); err != nil {
+				t.Fatalf("Failed to create mount trash: %v", err)
+			}
+			if err := os.Chmod(mountTrash, tt.mode); err != nil {
+				t.Fatalf("Failed to set mode: %v", err)
+			}
+
+			trashDir, fallback, err := tr.getTrashDirForPath(filepath.Join(mount, "file.txt"))
+			if err != nil {
+				t.Fatalf("Failed to get trash directory: %v", err)
+			}
+			if usable := trashDir == mountTrash && !fallback; usable != tt.usable {
+				t.Errorf("Got trash dir %s (fallback %v), want usable %v", trashDir, fallback, tt.usable)
+			}
+		})
+	}
+
+	t.Run("Symlink", func(t *testing.T) {
+		tr := newTestTrasher(t)
+		mount := t.TempDir()
+		fakeMount(tr, mount)
+
+		if err := os.Symlink(t.TempDir(), filepath.Join(mount, ".Trash-"+tr.uid)); err != nil {
+			t.Skipf("Can't create symlinks here: %v", err)
+		}
+		if _, fallback, err := tr.getTrashDirForPath(filepath.Join(mount, "file.txt")); err != nil || !fallback {
+			t.Errorf("Symlinked mount trash used: fallback %v, %v", fallback, err)
 		}
+	})
+}
+
+func TestTrashWithResultFallback(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	mountTrash := filepath.Join(mount, ".Trash-"+tr.uid)
+
+	testFile := filepath.Join(mount, "file.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.TrashDir != mountTrash || result.Fallback {
+		t.Errorf("Got trash dir %s (fallback %v), want %s without fallback", result.TrashDir, result.Fallback, mountTrash)
+	}
+
+	// Make the mount trash unusable by replacing it with a regular file
+	if err := os.RemoveAll(mountTrash); err != nil {
+		t.Fatalf("Failed to remove mount trash: %v", err)
+	}
+	if err := os.WriteFile(mountTrash, nil, 0644); err != nil {
+		t.Fatalf("Failed to block mount trash: %v", err)
+	}
+
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err = tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.TrashDir != tr.homeTrash || !result.Fallback {
+		t.Errorf("Got trash dir %s (fallback %v), want home trash %s with fallback", result.TrashDir, result.Fallback, tr.homeTrash)
+	}
+	if _, err := os.Stat(result.FilePath); err != nil {
+		t.Errorf("Trashed file not at reported path: %v", err)
+	}
+}
+
+func TestRecordedOriginalPath(t *testing.T) {
+	project := t.TempDir()
+	relocated := t.TempDir()
+
+	testFile := filepath.Join(project, "sub", "file.txt")
+	if err := os.MkdirAll(filepath.Dir(testFile), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("relocatable"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Make the recorded path unique so stale entries from other runs don't match
+	recorded := filepath.Join("sub", filepath.Base(project), "file.txt")
+	if err := TrashWithOptions(testFile, TrashOptions{OriginalPath: recorded}); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("File still exists after trashing")
 	}
 
 	items, err := List()
@@ -298,15 +881,1746 @@ func TestTrashNameGeneration(t *testing.T) {
 		t.Fatalf("Failed to list trash: %v", err)
 	}
 
-	duplicateCount := 0
+	var itemName string
 	for _, item := range items {
-		if filepath.Base(item.OriginalPath) == "duplicate.txt" &&
-			filepath.Dir(item.OriginalPath) == tempDir {
-			duplicateCount++
+		if item.OriginalPath == recorded {
+			itemName = item.Name
+			break
 		}
 	}
 
-	if duplicateCount != 5 {
-		t.Errorf("Expected 5 duplicate files in trash, got %d", duplicateCount)
+	if itemName == "" {
+		t.Fatalf("Trashed file with recorded path %q not found", recorded)
+	}
+
+	if err := RestoreWithOptions(itemName, RestoreOptions{Base: relocated}); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(relocated, recorded))
+	if err != nil {
+		t.Fatalf("Failed to read restored file: %v", err)
+	}
+	if string(content) != "relocatable" {
+		t.Error("Restored file content doesn't match original")
+	}
+}
+
+func TestInitializeRetry(t *testing.T) {
+	resetDefault := func() {
+		defaultMu.Lock()
+		defaultTrasher = nil
+		defaultMu.Unlock()
+	}
+	resetDefault()
+	t.Cleanup(resetDefault)
+
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	if _, err := getDefault(); err == nil {
+		t.Fatal("Expected initialization to fail without a home directory")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tr, err := getDefault()
+	if err != nil {
+		t.Fatalf("Expected initialization to succeed on retry, got: %v", err)
+	}
+
+	if want := filepath.Join(home, ".local", "share", "Trash"); tr.homeTrash != want {
+		t.Errorf("homeTrash = %s, want %s", tr.homeTrash, want)
+	}
+}
+
+func TestWithEnv(t *testing.T) {
+	homeA := t.TempDir()
+	dataB := t.TempDir()
+
+	trA, err := New(WithEnv([]string{"HOME=" + homeA}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher A: %v", err)
+	}
+	trB, err := New(WithEnv([]string{"HOME=" + t.TempDir(), "XDG_DATA_HOME=" + dataB}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher B: %v", err)
+	}
+
+	if want := filepath.Join(homeA, ".local", "share", "Trash"); trA.homeTrash != want {
+		t.Errorf("trasher A homeTrash = %s, want %s", trA.homeTrash, want)
+	}
+	if want := filepath.Join(dataB, "Trash"); trB.homeTrash != want {
+		t.Errorf("trasher B homeTrash = %s, want %s", trB.homeTrash, want)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "env.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := trA.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	itemsA, err := trA.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash A: %v", err)
+	}
+	if len(itemsA) != 1 || itemsA[0].OriginalPath != testFile {
+		t.Fatalf("Trash A items = %+v, want only %s", itemsA, testFile)
+	}
+
+	itemsB, err := trB.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash B: %v", err)
+	}
+	if len(itemsB) != 0 {
+		t.Errorf("Trash B should be empty, got %+v", itemsB)
+	}
+
+	if err := trB.Restore(itemsA[0].Name); err != ErrFileNotInTrash {
+		t.Errorf("Restore from trash B: expected ErrFileNotInTrash, got %v", err)
+	}
+
+	if err := trA.Restore(itemsA[0].Name); err != nil {
+		t.Fatalf("Failed to restore from trash A: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Restored file missing: %v", err)
+	}
+}
+
+func TestWithTrashRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "sandbox-trash")
+
+	// No HOME is needed once the trash is given
+	tr, err := New(WithEnv([]string{}), WithTrashRoot(root))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+	if tr.homeTrash != root {
+		t.Errorf("homeTrash = %s, want %s", tr.homeTrash, root)
+	}
+	if dirs := tr.mountTrashDirs(); len(dirs) != 0 {
+		t.Errorf("mountTrashDirs() = %v, want none", dirs)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "sandboxed.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.TrashDir != root {
+		t.Errorf("TrashDir = %s, want %s", result.TrashDir, root)
+	}
+	if _, err := os.Stat(filepath.Join(root, "files", "sandboxed.txt")); err != nil {
+		t.Errorf("Trashed file is not in the trash root: %v", err)
+	}
+}
+
+func TestWithUID(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithUID("4242"))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	testFile := filepath.Join(mount, "shared.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if want := filepath.Join(mount, ".Trash-4242"); result.TrashDir != want {
+		t.Errorf("TrashDir = %s, want %s", result.TrashDir, want)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	// A directory in place of the lock file can't be locked
+	if err := tr.ensureTrashDirs(tr.homeTrash); err != nil {
+		t.Fatalf("Failed to create trash: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tr.homeTrash, lockFileName), 0700); err != nil {
+		t.Fatalf("Failed to block lock file: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "logged.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if !strings.Contains(buf.String(), "proceeding without lock") {
+		t.Errorf("Logger got %q, want a warning about the lock", buf.String())
+	}
+}
+
+func TestTrashNameGeneration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	for i := 0; i < 5; i++ {
+		testFile := filepath.Join(tempDir, "duplicate.txt")
+		if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		if err := Trash(testFile); err != nil {
+			t.Fatalf("Failed to trash file %d: %v", i, err)
+		}
+	}
+
+	items, err := List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+
+	duplicateCount := 0
+	for _, item := range items {
+		if filepath.Base(item.OriginalPath) == "duplicate.txt" &&
+			filepath.Dir(item.OriginalPath) == tempDir {
+			duplicateCount++
+		}
+	}
+
+	if duplicateCount != 5 {
+		t.Errorf("Expected 5 duplicate files in trash, got %d", duplicateCount)
+	}
+}
+
+func TestCanonicalizeOriginal(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	realDir := filepath.Join(t.TempDir(), "real")
+	if err := os.MkdirAll(realDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	linkDir := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(realDir, linkDir); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	// The temporary directory may itself be reached through a symlink
+	canonicalDir, err := filepath.EvalSymlinks(realDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve test directory: %v", err)
+	}
+
+	for _, canonical := range []bool{false, true} {
+		if err := os.WriteFile(filepath.Join(realDir, "file.txt"), []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		viaLink := filepath.Join(linkDir, "file.txt")
+		result, err := tr.TrashWithResult(viaLink, TrashOptions{CanonicalizeOriginal: canonical})
+		if err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+
+		want := viaLink
+		if canonical {
+			want = filepath.Join(canonicalDir, "file.txt")
+		}
+		if result.OriginalPath != want {
+			t.Errorf("CanonicalizeOriginal=%v: OriginalPath = %q, want %q", canonical, result.OriginalPath, want)
+		}
+		if _, err := os.Lstat(linkDir); err != nil {
+			t.Errorf("CanonicalizeOriginal=%v: symlinked parent was affected: %v", canonical, err)
+		}
+
+		if err := tr.Delete(result.Name); err != nil {
+			t.Fatalf("Failed to delete trashed file: %v", err)
+		}
+	}
+}
+
+// fullFS accepts new trashinfo files but fails writing to them, like an
+// info directory on a filesystem that has run out of space.
+type fullFS struct {
+	osFS
+}
+
+type fullFile struct {
+	File
+}
+
+func (fullFile) Write(p []byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func (fs fullFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := fs.osFS.OpenFile(name, flag, perm)
+	if err != nil || !strings.Contains(name, ".trashinfo") {
+		return f, err
+	}
+	return fullFile{f}, nil
+}
+
+func TestTrashInfoWriteFailure(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = fullFS{}
+
+	testFile := filepath.Join(t.TempDir(), "unwritten.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.Trash(testFile); err == nil {
+		t.Fatal("Expected an error when the trash info can't be written")
+	}
+
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Original was moved despite the info write failing: %v", err)
+	}
+	for _, dir := range []string{"files", "info"} {
+		entries, err := os.ReadDir(filepath.Join(tr.homeTrash, dir))
+		if err != nil {
+			t.Fatalf("Failed to read trash %s directory: %v", dir, err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("Failed info write left %d entries in %s/", len(entries), dir)
+		}
+	}
+}
+
+// mangleFS writes trashinfo files with "%25" turned back into a literal
+// "%", as a writer that decodes twice would.
+type mangleFS struct {
+	osFS
+}
+
+type mangleFile struct {
+	File
+}
+
+func (f mangleFile) Write(p []byte) (int, error) {
+	mangled := strings.ReplaceAll(string(p), "%25", "%")
+	if _, err := f.File.Write([]byte(mangled)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (fs mangleFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := fs.osFS.OpenFile(name, flag, perm)
+	if err != nil || flag&os.O_WRONLY == 0 || !strings.Contains(name, ".trashinfo") {
+		return f, err
+	}
+	return mangleFile{f}, nil
+}
+
+func TestTrashSelfCheck(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "a+b %20 100% #1&x=y é.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tr.fs = mangleFS{}
+	if err := tr.TrashWithOptions(testFile, TrashOptions{SelfCheck: true}); !errors.Is(err, ErrSelfCheckFailed) {
+		t.Fatalf("Expected ErrSelfCheckFailed for a mangled trash info, got: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("File was moved despite the failed self-check: %v", err)
+	}
+
+	tr.fs = osFS{}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{SelfCheck: true})
+	if err != nil {
+		t.Fatalf("Self-checked trash failed: %v", err)
+	}
+	if result.OriginalPath != testFile {
+		t.Errorf("OriginalPath = %q, want %q", result.OriginalPath, testFile)
+	}
+}
+
+func TestBindMountedTrash(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	testFile := filepath.Join(mount, "shared.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	// A second mount point showing the same filesystem, as a bind mount does
+	bind := filepath.Join(t.TempDir(), "bind")
+	if err := os.Symlink(mount, bind); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	tr.mountPoints = func() ([]string, error) {
+		return []string{"/", mount, bind}, nil
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item in trash, got %d: %v", len(items), items)
+	}
+
+	result, err := tr.EmptyContext(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if result.Removed != 1 {
+		t.Errorf("Empty removed %d items, want 1", result.Removed)
+	}
+}
+
+func TestEmptyRemoveMountTrashDirs(t *testing.T) {
+	for _, remove := range []bool{false, true} {
+		tr := newTestTrasher(t)
+		mount := t.TempDir()
+		fakeMount(tr, mount)
+
+		for _, dir := range []string{mount, t.TempDir()} {
+			path := filepath.Join(dir, "file.txt")
+			if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			if err := tr.Trash(path); err != nil {
+				t.Fatalf("Failed to trash file: %v", err)
+			}
+		}
+
+		result, err := tr.EmptyWithOptions(context.Background(), EmptyOptions{RemoveMountTrashDirs: remove})
+		if err != nil {
+			t.Fatalf("Failed to empty trash: %v", err)
+		}
+		if result.Removed != 2 {
+			t.Errorf("RemoveMountTrashDirs=%v: removed %d items, want 2", remove, result.Removed)
+		}
+
+		_, err = os.Stat(filepath.Join(mount, ".Trash-"+tr.uid))
+		if remove && !os.IsNotExist(err) {
+			t.Errorf("Mount trash directory still exists: %v", err)
+		}
+		if !remove && err != nil {
+			t.Errorf("Mount trash directory was removed without the option: %v", err)
+		}
+		if _, err := os.Stat(tr.homeTrash); err != nil {
+			t.Errorf("RemoveMountTrashDirs=%v: home trash was removed: %v", remove, err)
+		}
+	}
+}
+
+func TestReTrash(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "redo.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if err := tr.Restore(result.Name); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+
+	name, err := tr.ReTrash(result.TrashItem)
+	if err != nil {
+		t.Fatalf("Failed to re-trash file: %v", err)
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("File still exists after re-trashing")
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != name || items[0].OriginalPath != testFile {
+		t.Errorf("Expected one entry %s for %s, got %v", name, testFile, items)
+	}
+
+	if _, err := tr.ReTrash(result.TrashItem); err == nil {
+		t.Error("Expected an error re-trashing a file that no longer exists")
+	}
+}
+
+func TestTrashSkipEmpty(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	emptyFile := filepath.Join(dir, "empty.txt")
+	emptyDir := filepath.Join(dir, "empty")
+	fullFile := filepath.Join(dir, "full.txt")
+	if err := os.WriteFile(emptyFile, nil, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Mkdir(emptyDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(fullFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	opts := TrashOptions{SkipEmpty: true}
+	for _, path := range []string{emptyFile, emptyDir} {
+		if err := tr.TrashWithOptions(path, opts); !errors.Is(err, ErrSkippedEmpty) {
+			t.Errorf("Expected ErrSkippedEmpty for %s, got: %v", path, err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Skipped %s was moved: %v", path, err)
+		}
+	}
+
+	if err := tr.TrashWithOptions(fullFile, opts); err != nil {
+		t.Fatalf("Failed to trash non-empty file: %v", err)
+	}
+	if err := tr.Trash(emptyFile); err != nil {
+		t.Fatalf("Failed to trash empty file without SkipEmpty: %v", err)
+	}
+}
+
+func TestRestoreMerge(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	newTree := func(t *testing.T, root string, files ...string) {
+		t.Helper()
+		for _, name := range files {
+			path := filepath.Join(root, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create test directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+		}
+	}
+
+	project := filepath.Join(t.TempDir(), "project")
+	newTree(t, project, "a.txt", filepath.Join("sub", "b.txt"), "c.txt")
+	if err := tr.Trash(project); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+
+	// Partially recreated, with a file that conflicts with a trashed one
+	newTree(t, project, filepath.Join("sub", "new.txt"), "c.txt")
+
+	if err := tr.Restore("project"); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("Expected ErrAlreadyExists without Merge, got: %v", err)
+	}
+
+	err := tr.RestoreWithOptions("project", RestoreOptions{Merge: true})
+	if !errors.Is(err, ErrAlreadyExists) || !strings.Contains(err.Error(), "c.txt") {
+		t.Fatalf("Expected ErrAlreadyExists naming c.txt, got: %v", err)
+	}
+	// Nothing is moved before the conflicts are known
+	for _, name := range []string{"a.txt", filepath.Join("sub", "b.txt")} {
+		if _, err := os.Stat(filepath.Join(project, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected no %s after a refused merge: %v", name, err)
+		}
+	}
+	if items, err := tr.List(); err != nil || len(items) != 1 {
+		t.Fatalf("Expected the conflicting item to stay listed, got %v, %v", items, err)
+	}
+
+	// Once the conflict is cleared the rest of the merge completes
+	if err := os.Remove(filepath.Join(project, "c.txt")); err != nil {
+		t.Fatalf("Failed to remove conflicting file: %v", err)
+	}
+	if err := tr.RestoreWithOptions("project", RestoreOptions{Merge: true}); err != nil {
+		t.Fatalf("Failed to finish merge: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(project, "c.txt")); err != nil || string(content) != "c.txt" {
+		t.Errorf("Merged c.txt = %q, %v; want the trashed content", content, err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != 0 {
+		t.Errorf("Expected an empty trash after a complete merge, got %v, %v", items, err)
+	}
+}
+
+func TestRestoreMergeConflict(t *testing.T) {
+	for _, policy := range []ConflictPolicy{ConflictRename, ConflictOverwrite} {
+		tr := newTestTrasher(t)
+
+		project := filepath.Join(t.TempDir(), "project")
+		if err := os.MkdirAll(filepath.Join(project, "sub"), 0755); err != nil {
+			t.Fatalf("Failed to create test directory: %v", err)
+		}
+		for _, name := range []string{"a.txt", filepath.Join("sub", "b.txt")} {
+			if err := os.WriteFile(filepath.Join(project, name), []byte("trashed"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+		}
+		if err := tr.Trash(project); err != nil {
+			t.Fatalf("Failed to trash directory: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Join(project, "sub"), 0755); err != nil {
+			t.Fatalf("Failed to recreate directory: %v", err)
+		}
+		b := filepath.Join(project, "sub", "b.txt")
+		if err := os.WriteFile(b, []byte("existing"), 0644); err != nil {
+			t.Fatalf("Failed to create conflicting file: %v", err)
+		}
+
+		if err := tr.RestoreWithOptions("project", RestoreOptions{Merge: true, Conflict: policy}); err != nil {
+			t.Fatalf("Policy %d: failed to merge: %v", policy, err)
+		}
+		want := map[string]string{filepath.Join(project, "a.txt"): "trashed"}
+		if policy == ConflictRename {
+			want[b], want[b+".1"] = "existing", "trashed"
+		} else {
+			want[b] = "trashed"
+		}
+		for path, content := range want {
+			if got, err := os.ReadFile(path); err != nil || string(got) != content {
+				t.Errorf("Policy %d: %s = %q, %v; want %q", policy, path, got, err, content)
+			}
+		}
+		if entries, err := os.ReadDir(filepath.Join(project, "sub")); err != nil || len(entries) != len(want)-1 {
+			t.Errorf("Policy %d: sub holds %v, %v", policy, entries, err)
+		}
+		if items, err := tr.List(); err != nil || len(items) != 0 {
+			t.Errorf("Policy %d: expected an empty trash after the merge, got %v, %v", policy, items, err)
+		}
+	}
+}
+
+// failRenameFS fails renames to paths with the given base name.
+type failRenameFS struct {
+	osFS
+	base string
+}
+
+func (fs failRenameFS) Rename(oldpath, newpath string) error {
+	if filepath.Base(newpath) == fs.base {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrPermission}
+	}
+	return fs.osFS.Rename(oldpath, newpath)
+}
+
+func TestRestoreMergeRollback(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	project := filepath.Join(t.TempDir(), "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(project, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := tr.Trash(project); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+	if err := os.Mkdir(project, 0755); err != nil {
+		t.Fatalf("Failed to recreate directory: %v", err)
+	}
+
+	// One child can't be moved, so those moved before it go back
+	tr.fs = failRenameFS{base: "b.txt"}
+	if err := tr.RestoreWithOptions("project", RestoreOptions{Merge: true}); err == nil {
+		t.Fatal("Expected the merge to fail")
+	}
+	if entries, err := os.ReadDir(project); err != nil || len(entries) != 0 {
+		t.Errorf("Project holds %v, %v after a failed merge; want nothing", entries, err)
+	}
+
+	tr.fs = osFS{}
+	if err := tr.RestoreWithOptions("project", RestoreOptions{Merge: true}); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if entries, err := os.ReadDir(project); err != nil || len(entries) != 3 {
+		t.Errorf("Project holds %v, %v; want all three files", entries, err)
+	}
+}
+
+func TestTrashAs(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "output.log")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	name, err := tr.TrashAs(testFile, "job/42 output.log")
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if name != "job_42 output.log" {
+		t.Errorf("Trash name = %q, want %q", name, "job_42 output.log")
+	}
+	if _, err := os.Stat(filepath.Join(tr.homeTrash, "files", name)); err != nil {
+		t.Errorf("Trashed data not stored under the hinted name: %v", err)
+	}
+
+	if err := tr.Restore(name); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("File not restored to its original path: %v", err)
+	}
+}
+
+func TestUserLookupFailure(t *testing.T) {
+	defer func(cu func() (*user.User, error), gu func() int) {
+		currentUser, getuid = cu, gu
+	}(currentUser, getuid)
+	currentUser = func() (*user.User, error) {
+		return nil, errors.New("user: unknown userid")
+	}
+
+	getuid = func() int { return 1234 }
+	tr := newTestTrasher(t)
+	if tr.uid != "1234" {
+		t.Errorf("uid = %q, want the kernel uid 1234", tr.uid)
+	}
+
+	// Without any uid, only the home trash is available
+	getuid = func() int { return -1 }
+	tr = newTestTrasher(t)
+	if dirs := tr.mountTrashDirs(); len(dirs) != 0 {
+		t.Errorf("Expected no mount trashes without a uid, got %v", dirs)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file without a uid: %v", err)
+	}
+	if result.TrashDir != tr.homeTrash {
+		t.Errorf("Trashed to %s, want the home trash %s", result.TrashDir, tr.homeTrash)
+	}
+	if err := tr.Restore(result.Name); err != nil {
+		t.Fatalf("Failed to restore file without a uid: %v", err)
+	}
+}
+
+func TestTrashLeavePlaceholder(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(testFile, []byte("content"), 0640); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{LeavePlaceholder: true})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	info, err := os.Stat(testFile)
+	if err != nil || info.Size() != 0 {
+		t.Fatalf("Expected an empty placeholder at %s, got %v, %v", testFile, info, err)
+	}
+
+	items, err := tr.List()
+	if err != nil || len(items) != 1 || !items[0].Placeholder {
+		t.Fatalf("Expected one item marked as having a placeholder, got %v, %v", items, err)
+	}
+
+	if err := tr.Restore(result.Name); err != nil {
+		t.Fatalf("Failed to restore over the placeholder: %v", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "content" {
+		t.Errorf("Restored file = %q, %v; want the original content", content, err)
+	}
+
+	// A placeholder that has since been written to is a real conflict
+	result, err = tr.TrashWithResult(testFile, TrashOptions{LeavePlaceholder: true})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("new"), 0640); err != nil {
+		t.Fatalf("Failed to write to placeholder: %v", err)
+	}
+	if err := tr.Restore(result.Name); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists over a modified placeholder, got: %v", err)
+	}
+}
+
+// refuseCreateFS fails to create the file at path, as a read-only
+// directory would for a user other than root.
+type refuseCreateFS struct {
+	osFS
+	path string
+}
+
+func (f refuseCreateFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if name == f.path && flag&os.O_CREATE != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+	}
+	return f.osFS.OpenFile(name, flag, perm)
+}
+
+func TestTrashPlaceholderFailure(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(testFile, []byte("content"), 0640); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithFS(refuseCreateFS{path: testFile}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{LeavePlaceholder: true})
+	if !errors.Is(err, ErrPlaceholderFailed) {
+		t.Fatalf("Trash without a placeholder = %v, want ErrPlaceholderFailed", err)
+	}
+	if result.Name == "" || result.InfoPath == "" {
+		t.Fatalf("Result = %+v, want the trashed item", result)
+	}
+	if content, err := os.ReadFile(result.FilePath); err != nil || string(content) != "content" {
+		t.Errorf("Trashed data = %q, %v; want the original content", content, err)
+	}
+
+	if err := tr.Restore(result.Name); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "content" {
+		t.Errorf("Restored file = %q, %v; want the original content", content, err)
+	}
+}
+
+func TestTrashInfoPathEncoding(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	// Trashed by this package
+	testFile := filepath.Join(dir, "100%20 a+b.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	item, err := tr.parseTrashInfo(tr.homeTrash, result.Name)
+	if err != nil || item.OriginalPath != testFile {
+		t.Errorf("Read back %q, %v; want %q", item.OriginalPath, err, testFile)
+	}
+
+	// Written by other implementations, escaped as the spec asks or raw. A
+	// raw path is only recognizable if it isn't also valid escaped text.
+	slashDir := filepath.ToSlash(dir)
+	foreign := []struct {
+		name, path, want string
+	}{
+		{"escaped.txt", slashDir + "/100%2520%20a+b.txt", slashDir + "/100%20 a+b.txt"},
+		{"raw.txt", slashDir + "/100% a+b.txt", slashDir + "/100% a+b.txt"},
+	}
+	for _, f := range foreign {
+		content := "[Trash Info]\nPath=" + f.path + "\nDeletionDate=2024-03-01T12:00:00\n"
+		if err := os.WriteFile(filepath.Join(tr.homeTrash, "info", f.name+".trashinfo"), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write trash info: %v", err)
+		}
+
+		item, err := tr.parseTrashInfo(tr.homeTrash, f.name)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", f.name, err)
+		}
+		if got := filepath.ToSlash(item.OriginalPath); got != f.want {
+			t.Errorf("%s: read back %q, want %q", f.name, got, f.want)
+		}
+	}
+}
+
+func TestTrashInfoPathSlashes(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := filepath.Join(t.TempDir(), "My Documents", "50% off+more")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	testFile := filepath.Join(dir, "résumé (final) #2.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	content, err := os.ReadFile(result.InfoPath)
+	if err != nil {
+		t.Fatalf("Failed to read trashinfo: %v", err)
+	}
+	want := "Path=" + strings.ReplaceAll(filepath.ToSlash(filepath.Dir(dir)), " ", "%20") +
+		"/50%25%20off%2Bmore/r%C3%A9sum%C3%A9%20%28final%29%20%232.txt\n"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("Trashinfo is\n%s\nwant a line %q", content, want)
+	}
+
+	items, err := tr.List()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %v, %v", items, err)
+	}
+	if items[0].OriginalPath != testFile {
+		t.Errorf("OriginalPath = %q, want %q", items[0].OriginalPath, testFile)
+	}
+}
+
+func TestListForeignTrashInfo(t *testing.T) {
+	tr := newTestTrasher(t)
+	if err := tr.ensureTrashDirs(tr.homeTrash); err != nil {
+		t.Fatalf("Failed to create trash: %v", err)
+	}
+
+	gio, err := os.ReadFile(filepath.Join("testdata", "gio.trashinfo"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	infos := map[string][]byte{
+		// As written by gio trash
+		"résumé (final)+notes.txt": gio,
+		// As written by earlier versions of this package, with '/' escaped
+		"old.txt": []byte("[Trash Info]\nPath=%2Fhome%2Falice%2Fold%20file.txt\nDeletionDate=2024-03-05T14:22:31\n"),
+	}
+	for name, content := range infos {
+		if err := os.WriteFile(filepath.Join(tr.homeTrash, "info", name+".trashinfo"), content, 0600); err != nil {
+			t.Fatalf("Failed to write trashinfo: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tr.homeTrash, "files", name), nil, 0600); err != nil {
+			t.Fatalf("Failed to write trashed file: %v", err)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	got := map[string]string{}
+	for _, item := range items {
+		got[item.Name] = item.OriginalPath
+	}
+	want := map[string]string{
+		"résumé (final)+notes.txt": "/home/alice/My Documents/résumé (final)+notes.txt",
+		"old.txt":                  "/home/alice/old file.txt",
+	}
+	for name, path := range want {
+		if got[name] != path {
+			t.Errorf("OriginalPath of %s = %q, want %q", name, got[name], path)
+		}
+	}
+	if d := items[0].DeletionDate; d.Year() != 2024 || d.Month() != time.March || d.Day() != 5 {
+		t.Errorf("DeletionDate = %v, want 2024-03-05", d)
+	}
+}
+
+func TestMaxNameAttempts(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithMaxNameAttempts(5))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	dir := t.TempDir()
+	for i := 0; i < 6; i++ {
+		path := filepath.Join(dir, "build.log")
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		result, err := tr.TrashWithResult(path, TrashOptions{})
+		if err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+
+		// build.log and build.log.1 to .4 are the five attempts
+		if want := i == 5; result.RandomName != want {
+			t.Errorf("Trash %d: RandomName = %v, want %v (name %s)", i, result.RandomName, want, result.Name)
+		}
+		if !result.RandomName && i > 0 && result.Name != fmt.Sprintf("build.log.%d", i) {
+			t.Errorf("Trash %d: name = %s, want build.log.%d", i, result.Name, i)
+		}
+	}
+}
+
+// noLockFS hides the descriptors of the files it opens, so trash
+// directories can't be locked and only the names themselves keep
+// concurrent trashes apart. Lookups of trashinfo files return late, widening
+// the window between finding a name free and claiming it.
+type noLockFS struct {
+	osFS
+}
+
+func (fs noLockFS) Lstat(name string) (os.FileInfo, error) {
+	info, err := fs.osFS.Lstat(name)
+	if strings.HasSuffix(name, ".trashinfo") {
+		time.Sleep(time.Millisecond)
+	}
+	return info, err
+}
+
+func (fs noLockFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := fs.osFS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ File }{f}, nil
+}
+
+func TestTrashConcurrentNames(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = noLockFS{}
+
+	const n = 32
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(t.TempDir(), "report.txt")
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- tr.Trash(path)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Failed to trash file: %v", err)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != n {
+		t.Fatalf("Trash holds %d items, want %d", len(items), n)
+	}
+	names := map[string]bool{}
+	for _, item := range items {
+		names[item.Name] = true
+		// Each entry's data is the file its trashinfo names
+		content, err := os.ReadFile(item.FilePath)
+		if err != nil || string(content) != item.OriginalPath {
+			t.Errorf("Item %s holds %q, want the data of %s (%v)", item.Name, content, item.OriginalPath, err)
+		}
+	}
+	if len(names) != n {
+		t.Errorf("Trash names are not distinct: %v", names)
+	}
+}
+
+func TestTrashDetectMimeType(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	files := map[string][]byte{
+		"image.png": append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...),
+		"notes.txt": []byte("plain text notes\n"),
+	}
+	want := map[string]string{
+		"image.png": "image/png",
+		"notes.txt": "text/plain; charset=utf-8",
+		"folder":    "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "folder"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	for name := range want {
+		if err := tr.TrashWithOptions(filepath.Join(dir, name), TrashOptions{DetectMimeType: true}); err != nil {
+			t.Fatalf("Failed to trash %s: %v", name, err)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != len(want) {
+		t.Fatalf("Expected %d items, got %d", len(want), len(items))
+	}
+	for _, item := range items {
+		if item.MimeType != want[item.Name] {
+			t.Errorf("%s: MimeType = %q, want %q", item.Name, item.MimeType, want[item.Name])
+		}
+	}
+}
+
+func TestTrashWithReason(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "old.cfg")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Newlines and '=' must not be mistaken for further keys
+	reason := "removed per cleanup ticket #123\nPath=/etc/passwd\nsee 100% of a=b"
+	if err := tr.TrashWithReason(testFile, reason); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	if items[0].Reason != reason {
+		t.Errorf("Reason = %q, want %q", items[0].Reason, reason)
+	}
+	if items[0].OriginalPath != testFile {
+		t.Errorf("OriginalPath = %q, want %q", items[0].OriginalPath, testFile)
+	}
+
+	var buf strings.Builder
+	if err := FormatTable(&buf, items); err != nil {
+		t.Fatalf("FormatTable failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "REASON") || !strings.Contains(buf.String(), "ticket #123 Path=/etc/passwd") {
+		t.Errorf("Reason not shown in listing:\n%s", buf.String())
+	}
+}
+
+func TestTrashDisabled(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "import.csv")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tr.Disable()
+	if !tr.Disabled() {
+		t.Error("Disabled() = false after Disable")
+	}
+	if err := tr.Trash(testFile); !errors.Is(err, ErrTrashDisabled) {
+		t.Fatalf("Expected ErrTrashDisabled, got %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Disabled trash touched the file: %v", err)
+	}
+
+	tr.Enable()
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash after Enable: %v", err)
+	}
+	if items, _ := tr.List(); len(items) != 1 {
+		t.Errorf("Expected 1 item after Enable, got %d", len(items))
+	}
+}
+
+func TestTrashDisabledDelete(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithDeleteWhenDisabled())
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testDir := filepath.Join(t.TempDir(), "import")
+	if err := os.MkdirAll(filepath.Join(testDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(testDir, "sub", "row.csv"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tr.Disable()
+	if err := tr.Trash(testDir); err != nil {
+		t.Fatalf("Failed to delete while disabled: %v", err)
+	}
+	if _, err := os.Lstat(testDir); !os.IsNotExist(err) {
+		t.Error("Directory still exists after a disabled trash")
+	}
+	if items, _ := tr.List(); len(items) != 0 {
+		t.Errorf("Disabled trash added %d items, want none", len(items))
+	}
+
+	// A missing file is still an error rather than silently ignored
+	if err := tr.Trash(testDir); err == nil {
+		t.Error("Expected an error deleting a missing file")
+	}
+}
+
+func TestTrashDisabledUndoable(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithDeleteWhenDisabled())
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	testFile := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(testFile, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Neither the displaced file nor a batch's files may be deleted for good
+	tr.Disable()
+	if _, err := tr.RestoreAndTrashConflict("app.conf"); !errors.Is(err, ErrTrashDisabled) {
+		t.Errorf("RestoreAndTrashConflict = %v, want ErrTrashDisabled", err)
+	}
+	batch := tr.BeginBatch()
+	batch.Trash(testFile)
+	if err := batch.Commit(); !errors.Is(err, ErrTrashDisabled) {
+		t.Errorf("Batch.Commit = %v, want ErrTrashDisabled", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "new" {
+		t.Errorf("Conflicting file = %q, %v; want it untouched", content, err)
+	}
+}
+
+// seedMounts fakes n mounts, each with a trash holding perMount items.
+func seedMounts(b *testing.B, tr *Trasher, n, perMount int) {
+	b.Helper()
+
+	root := b.TempDir()
+	mounts := []string{"/"}
+	for i := 0; i < n; i++ {
+		mount := filepath.Join(root, fmt.Sprintf("mnt%d", i))
+		if err := os.MkdirAll(mount, 0755); err != nil {
+			b.Fatalf("Failed to create mount: %v", err)
+		}
+		mounts = append(mounts, mount)
+	}
+
+	tr.mountPoint = func(path string) (string, error) {
+		for _, mount := range mounts[1:] {
+			if strings.HasPrefix(path, mount+string(filepath.Separator)) {
+				return mount, nil
+			}
+		}
+		return "/", nil
+	}
+	tr.mountPoints = func() ([]string, error) {
+		return mounts, nil
+	}
+
+	for _, mount := range mounts[1:] {
+		for j := 0; j < perMount; j++ {
+			path := filepath.Join(mount, fmt.Sprintf("file%d", j))
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				b.Fatalf("Failed to create file: %v", err)
+			}
+			if err := tr.Trash(path); err != nil {
+				b.Fatalf("Failed to trash file: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkList(b *testing.B) {
+	tr, err := New(WithEnv([]string{"HOME=" + b.TempDir()}))
+	if err != nil {
+		b.Fatalf("Failed to create trasher: %v", err)
+	}
+	seedMounts(b, tr, 8, 50)
+
+	dirs := append([]string{tr.homeTrash}, tr.mountTrashDirs()...)
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"serial", 1},
+		{"concurrent", maxListWorkers},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if items := tr.listTrashDirs(dirs, bench.workers); len(items) != 8*50 {
+					b.Fatalf("Listed %d items, want %d", len(items), 8*50)
+				}
+			}
+		})
+	}
+}
+
+func TestTrashMountPoint(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	testFile := filepath.Join(mount, "data.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.Trash(mount); !errors.Is(err, ErrIsMountPoint) {
+		t.Fatalf("Trash of mount point = %v, want ErrIsMountPoint", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Mounted data was touched: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.homeTrash, "files", filepath.Base(mount))); !os.IsNotExist(err) {
+		t.Errorf("Mount point was copied into the trash: %v", err)
+	}
+
+	// Its contents can still be trashed
+	if err := tr.Trash(testFile); err != nil {
+		t.Errorf("Failed to trash file on mount: %v", err)
+	}
+}
+
+func TestTrashFlattenDirectories(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	dir := filepath.Join(t.TempDir(), "photos")
+	files := map[string]string{
+		filepath.Join(dir, "a.jpg"):                   "a",
+		filepath.Join(dir, "2023", "b.jpg"):           "b",
+		filepath.Join(dir, "2023", "summer", "b.jpg"): "c",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "empty"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	if err := tr.TrashWithOptions(dir, TrashOptions{FlattenDirectories: true}); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+	if _, err := os.Lstat(dir); !os.IsNotExist(err) {
+		t.Errorf("Emptied directory still exists: %v", err)
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != len(files) {
+		t.Fatalf("Expected %d items, got %+v", len(files), items)
+	}
+	for _, item := range items {
+		content, err := os.ReadFile(item.FilePath)
+		if err != nil || string(content) != files[item.OriginalPath] {
+			t.Errorf("Item %s for %s holds %q, %v", item.Name, item.OriginalPath, content, err)
+		}
+	}
+
+	// Each file comes back on its own, recreating its directories
+	target := filepath.Join(dir, "2023", "summer", "b.jpg")
+	for _, item := range items {
+		if item.OriginalPath != target {
+			continue
+		}
+		if err := tr.Restore(item.Name); err != nil {
+			t.Fatalf("Failed to restore %s: %v", item.Name, err)
+		}
+	}
+	if content, err := os.ReadFile(target); err != nil || string(content) != "c" {
+		t.Errorf("Restored file = %q, %v; want c", content, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "a.jpg")); !os.IsNotExist(err) {
+		t.Errorf("Other files were restored too: %v", err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != len(files)-1 {
+		t.Errorf("Expected %d items left, got %v, %v", len(files)-1, items, err)
+	}
+}
+
+func TestRestoreConflict(t *testing.T) {
+	tests := []struct {
+		conflict ConflictPolicy
+		wantErr  error
+		want     map[string]string // restore directory contents afterwards
+		inTrash  bool
+	}{
+		{ConflictFail, ErrAlreadyExists, map[string]string{"doc.txt": "new"}, true},
+		{ConflictSkip, nil, map[string]string{"doc.txt": "new"}, true},
+		{ConflictOverwrite, nil, map[string]string{"doc.txt": "old"}, false},
+		{ConflictRename, nil, map[string]string{"doc.txt": "new", "doc.txt.1": "taken", "doc.txt.2": "old"}, false},
+	}
+
+	for _, tt := range tests {
+		tr := newTestTrasher(t)
+		dir := t.TempDir()
+		testFile := filepath.Join(dir, "doc.txt")
+		if err := os.WriteFile(testFile, []byte("old"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(testFile); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+		if err := os.WriteFile(testFile, []byte("new"), 0644); err != nil {
+			t.Fatalf("Failed to create conflicting file: %v", err)
+		}
+		if tt.conflict == ConflictRename {
+			if err := os.WriteFile(testFile+".1", []byte("taken"), 0644); err != nil {
+				t.Fatalf("Failed to create conflicting file: %v", err)
+			}
+		}
+
+		err := tr.RestoreWithOptions("doc.txt", RestoreOptions{Conflict: tt.conflict})
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Conflict %d: RestoreWithOptions = %v, want %v", tt.conflict, err, tt.wantErr)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("Failed to read directory: %v", err)
+		}
+		got := map[string]string{}
+		for _, entry := range entries {
+			content, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+			got[entry.Name()] = string(content)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Conflict %d: directory holds %v, want %v", tt.conflict, got, tt.want)
+		}
+
+		items, err := tr.List()
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		if inTrash := len(items) == 1; inTrash != tt.inTrash {
+			t.Errorf("Conflict %d: item in trash = %v, want %v", tt.conflict, inTrash, tt.inTrash)
+		}
+	}
+}
+
+func TestRestoreOverwriteFailure(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "doc.txt")
+	if err := os.WriteFile(testFile, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create conflicting file: %v", err)
+	}
+
+	// The trashed data has vanished, so the restore fails
+	items, err := tr.List()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %v, %v", items, err)
+	}
+	if err := os.Remove(items[0].FilePath); err != nil {
+		t.Fatalf("Failed to remove trashed data: %v", err)
+	}
+	if err := tr.RestoreWithOptions("doc.txt", RestoreOptions{Conflict: ConflictOverwrite}); err == nil {
+		t.Fatal("Restore of missing data succeeded")
+	}
+
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "new" {
+		t.Errorf("Existing file = %q, %v; want it put back", content, err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the existing file, got %v, %v", entries, err)
+	}
+}
+
+func TestListComputeSizes(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, make([]byte, 100), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	tree := filepath.Join(dir, "tree")
+	if err := os.MkdirAll(filepath.Join(tree, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tree, "a"), make([]byte, 30), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tree, "sub", "b"), make([]byte, 12), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, path := range []string{file, tree} {
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash %s: %v", path, err)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	for _, item := range items {
+		if item.Size != 0 {
+			t.Errorf("List computed size %d for %s without being asked", item.Size, item.Name)
+		}
+	}
+
+	items, err = tr.ListWithOptions(ListOptions{ComputeSizes: true})
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	// Only file contents count, not the directories holding them
+	want := map[string]int64{"file.txt": 100, "tree": 42}
+	for _, item := range items {
+		if item.Size != want[item.Name] {
+			t.Errorf("Size of %s = %d, want %d", item.Name, item.Size, want[item.Name])
+		}
+		if size, err := tr.SizeOf(item); err != nil || size != item.Size {
+			t.Errorf("SizeOf(%s) = %d, %v; want %d", item.Name, size, err, item.Size)
+		}
+	}
+
+	if err := os.RemoveAll(items[0].FilePath); err != nil {
+		t.Fatalf("Failed to remove trashed data: %v", err)
+	}
+	if _, err := tr.SizeOf(items[0]); !errors.Is(err, ErrFileNotInTrash) {
+		t.Errorf("SizeOf of missing data = %v, want ErrFileNotInTrash", err)
+	}
+}
+
+func TestListFrom(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	for _, path := range []string{filepath.Join(t.TempDir(), "home.txt"), filepath.Join(mount, "drive.txt")} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	driveTrash := filepath.Join(mount, ".Trash-"+tr.uid)
+	items, err := tr.ListFrom(driveTrash)
+	if err != nil {
+		t.Fatalf("ListFrom failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "drive.txt" || items[0].TrashDir != driveTrash {
+		t.Errorf("ListFrom(%s) = %+v, want only drive.txt", driveTrash, items)
+	}
+
+	items, err = tr.ListFrom(tr.homeTrash)
+	if err != nil {
+		t.Fatalf("ListFrom failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "home.txt" {
+		t.Errorf("ListFrom(%s) = %+v, want only home.txt", tr.homeTrash, items)
+	}
+
+	if _, err := tr.ListFrom(filepath.Join(mount, "missing")); !errors.Is(err, ErrTrashNotFound) {
+		t.Errorf("ListFrom of missing directory = %v, want ErrTrashNotFound", err)
+	}
+}
+
+func TestListUnder(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	paths := []string{
+		filepath.Join(dir, "Downloads", "a.zip"),
+		filepath.Join(dir, "Downloads", "sub", "b.zip"),
+		filepath.Join(dir, "Downloads2", "c.zip"),
+		filepath.Join(dir, "d.zip"),
+		filepath.Join(mount, "Downloads", "e.zip"),
+	}
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	names := func(items []TrashItem) string {
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{filepath.Join(dir, "Downloads"), "a.zip,b.zip"},
+		{filepath.Join(dir, "Downloads") + string(filepath.Separator), "a.zip,b.zip"},
+		{filepath.Join(dir, "Downloads", "sub"), "b.zip"},
+		{dir, "a.zip,b.zip,c.zip,d.zip"},
+		{mount, "e.zip"},
+		{filepath.Join(mount, "Downloads", "e.zip"), "e.zip"},
+		{filepath.Join(dir, "Down"), ""},
+		{"/", "a.zip,b.zip,c.zip,d.zip,e.zip"},
+	}
+	for _, tt := range tests {
+		items, err := tr.ListUnder(tt.dir)
+		if err != nil {
+			t.Fatalf("ListUnder(%s) failed: %v", tt.dir, err)
+		}
+		if got := names(items); got != tt.want {
+			t.Errorf("ListUnder(%s) = %s, want %s", tt.dir, got, tt.want)
+		}
+	}
+
+	t.Chdir(dir)
+	items, err := tr.ListUnder("Downloads")
+	if err != nil {
+		t.Fatalf("ListUnder failed: %v", err)
+	}
+	if got := names(items); got != "a.zip,b.zip" {
+		t.Errorf("ListUnder(Downloads) = %s, want a.zip,b.zip", got)
+	}
+}
+
+func TestFind(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	testFile := filepath.Join(mount, "report.pdf")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.TrashWithReason(testFile, "outdated"); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	item, err := tr.Find("report.pdf")
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if item.OriginalPath != testFile || item.Reason != "outdated" || item.TrashDir != filepath.Join(mount, ".Trash-"+tr.uid) {
+		t.Errorf("Find = %+v, want report.pdf in the mount's trash", item)
+	}
+	if item.DeletionDate.IsZero() {
+		t.Error("Find returned no deletion date")
+	}
+
+	if _, err := tr.Find("missing.pdf"); !errors.Is(err, ErrFileNotInTrash) {
+		t.Errorf("Find of missing item = %v, want ErrFileNotInTrash", err)
+	}
+}
+
+func TestRestoreByOriginalPath(t *testing.T) {
+	tr := newTestTrasher(t)
+	testFile := filepath.Join(t.TempDir(), "report.pdf")
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, offset := range []time.Duration{0, 2 * time.Minute, time.Minute} {
+		if err := os.WriteFile(testFile, []byte(fmt.Sprint("version ", i)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.TrashWithOptions(testFile, TrashOptions{DeletionDate: base.Add(offset)}); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	if err := tr.RestoreByOriginalPath(testFile + ".old"); !errors.Is(err, ErrFileNotInTrash) {
+		t.Errorf("RestoreByOriginalPath of untrashed path = %v, want ErrFileNotInTrash", err)
+	}
+
+	// The latest deletion wins, not the latest trash name
+	if err := tr.RestoreByOriginalPath(testFile); err != nil {
+		t.Fatalf("RestoreByOriginalPath failed: %v", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "version 1" {
+		t.Errorf("Restored %q, %v; want version 1", content, err)
+	}
+	if items, err := tr.List(); err != nil || len(items) != 2 {
+		t.Errorf("Expected 2 items left, got %v, %v", items, err)
+	}
+
+	// Deleted in the same second, neither is later
+	if err := os.Remove(testFile); err != nil {
+		t.Fatalf("Failed to remove restored file: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("version 3"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.TrashWithOptions(testFile, TrashOptions{DeletionDate: base.Add(time.Minute)}); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	err := tr.RestoreByOriginalPath(testFile)
+	if !errors.Is(err, ErrAmbiguousPath) || !strings.Contains(err.Error(), "report.pdf.2") {
+		t.Errorf("RestoreByOriginalPath with a tie = %v, want ErrAmbiguousPath naming both", err)
+	}
+	if _, err := os.Lstat(testFile); !os.IsNotExist(err) {
+		t.Errorf("Ambiguous restore restored something: %v", err)
+	}
+}
+
+func TestEmptyDir(t *testing.T) {
+	tr := newTestTrasher(t)
+	mount := t.TempDir()
+	fakeMount(tr, mount)
+
+	homeFile := filepath.Join(t.TempDir(), "home.txt")
+	driveFile := filepath.Join(mount, "drive.txt")
+	for _, path := range []string{homeFile, driveFile} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(path); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+	}
+
+	// Not a trash directory, even though it has a files/ to empty
+	project := t.TempDir()
+	precious := filepath.Join(project, "files", "precious.txt")
+	if err := os.MkdirAll(filepath.Dir(precious), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(precious, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.EmptyDir(project); !errors.Is(err, ErrTrashNotFound) {
+		t.Errorf("EmptyDir of a non-trash directory = %v, want ErrTrashNotFound", err)
+	}
+	if _, err := os.Stat(precious); err != nil {
+		t.Errorf("EmptyDir touched a non-trash directory: %v", err)
+	}
+
+	if err := tr.EmptyDir(filepath.Join(mount, ".Trash-"+tr.uid)); err != nil {
+		t.Fatalf("EmptyDir failed: %v", err)
+	}
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != homeFile {
+		t.Errorf("Items left = %+v, want only the home trash's", items)
 	}
 }
@@ -5,9 +5,50 @@ package trash
 
 import (
 	"errors"
+	"os"
+	"strconv"
 	"syscall"
 )
 
 func isCrossDeviceError(err error) bool {
 	return errors.Is(err, syscall.EXDEV)
 }
+
+// isNoSpaceError reports whether err means the destination device is full.
+func isNoSpaceError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
+
+// isInvalidNameError reports whether err means the filesystem rejected a
+// file name, as FAT does for characters such as ':' or '?'.
+func isInvalidNameError(err error) bool {
+	return errors.Is(err, syscall.EINVAL)
+}
+
+// deviceID returns the ID of the device holding the file described by info.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
+
+// fileID returns the device and inode numbers of the file described by
+// info.
+func fileID(info os.FileInfo) (fileKey, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
+
+// fileOwner returns the uid owning the file described by info.
+func fileOwner(info os.FileInfo) (string, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(st.Uid), 10), true
+}
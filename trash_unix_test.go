@@ -0,0 +1,851 @@
+//go:build !windows
+// +build !windows
+
+package trash
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// otherDeviceInfo reports its file as living on a different device.
+type otherDeviceInfo struct {
+	os.FileInfo
+}
+
+func (fi otherDeviceInfo) Sys() interface{} {
+	st := *fi.FileInfo.Sys().(*syscall.Stat_t)
+	st.Dev++
+	return &st
+}
+
+// splitFS places every directory named info on its own device.
+type splitFS struct {
+	osFS
+}
+
+func (splitFS) Stat(name string) (os.FileInfo, error) {
+	info, err := os.Stat(name)
+	if err != nil || filepath.Base(name) != "info" {
+		return info, err
+	}
+	return otherDeviceInfo{info}, nil
+}
+
+func TestSplitTrash(t *testing.T) {
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+
+	if err := tr.ensureTrashDirs(tr.homeTrash); err != nil {
+		t.Fatalf("Unexpected error for a trash on one filesystem: %v", err)
+	}
+
+	tr.fs = splitFS{}
+
+	testFile := filepath.Join(t.TempDir(), "split.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.Trash(testFile); !errors.Is(err, ErrSplitTrash) {
+		t.Fatalf("Expected ErrSplitTrash, got: %v", err)
+	}
+
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("File was moved into a split trash: %v", err)
+	}
+}
+
+// crossDeviceFS fails every rename between directories with EXDEV, forcing
+// the copy fallback. Renames within a directory, which can never cross
+// devices, still work.
+type crossDeviceFS struct {
+	osFS
+}
+
+func (crossDeviceFS) Rename(oldpath, newpath string) error {
+	if filepath.Dir(oldpath) == filepath.Dir(newpath) {
+		return os.Rename(oldpath, newpath)
+	}
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+}
+
+// slowFS is a crossDeviceFS whose written files take delay per Write.
+type slowFS struct {
+	crossDeviceFS
+	delay time.Duration
+}
+
+type slowFile struct {
+	File
+	delay time.Duration
+}
+
+func (f slowFile) Write(p []byte) (int, error) {
+	time.Sleep(f.delay)
+	return f.File.Write(p)
+}
+
+func (fs slowFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := fs.crossDeviceFS.OpenFile(name, flag, perm)
+	if err != nil || flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return f, err
+	}
+	return slowFile{f, fs.delay}, nil
+}
+
+func TestTrashWithTimeout(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "slow.bin")
+	if err := os.WriteFile(testFile, make([]byte, 1<<20), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tr.fs = slowFS{delay: 10 * time.Millisecond}
+
+	err := tr.TrashWithTimeout(testFile, 50*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got: %v", err)
+	}
+
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Original was removed after a timed out trash: %v", err)
+	}
+
+	for _, dir := range []string{"files", "info"} {
+		entries, err := os.ReadDir(filepath.Join(tr.homeTrash, dir))
+		if err != nil {
+			t.Fatalf("Failed to read trash %s directory: %v", dir, err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("Timed out trash left %d entries in %s/", len(entries), dir)
+		}
+	}
+
+	tr.fs = crossDeviceFS{}
+	if err := tr.TrashWithTimeout(testFile, time.Minute); err != nil {
+		t.Fatalf("Failed to trash within the timeout: %v", err)
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("File still exists after trashing")
+	}
+}
+
+func TestTrashRateLimit(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	testDir := filepath.Join(t.TempDir(), "limited")
+	if err := os.MkdirAll(testDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"a.bin", "b.bin"} {
+		if err := os.WriteFile(filepath.Join(testDir, name), make([]byte, 32*1024), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// 64 KiB at 256 KiB/s across both files takes at least 250ms
+	start := time.Now()
+	if err := tr.TrashWithOptions(testDir, TrashOptions{RateLimit: 256 * 1024}); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("Rate limited copy took %v, want at least 250ms", elapsed)
+	}
+	if _, err := os.Stat(testDir); !os.IsNotExist(err) {
+		t.Error("Directory still exists after trashing")
+	}
+}
+
+// lockProbeFS calls check before each rename, e.g. to see whether a
+// trash directory is locked while data moves into it.
+type lockProbeFS struct {
+	osFS
+	check func(newpath string)
+}
+
+func (fs lockProbeFS) Rename(oldpath, newpath string) error {
+	fs.check(newpath)
+	return fs.osFS.Rename(oldpath, newpath)
+}
+
+func TestTrashMoveLocked(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	// Nothing sweeping the trash may see the info before the data is in
+	var checked bool
+	tr.fs = lockProbeFS{check: func(newpath string) {
+		if !within(newpath, tr.homeTrash) {
+			return
+		}
+		checked = true
+		f, err := os.OpenFile(filepath.Join(tr.homeTrash, lockFileName), os.O_RDWR, 0)
+		if err != nil {
+			t.Errorf("Failed to open lock file: %v", err)
+			return
+		}
+		defer f.Close()
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == nil {
+			t.Error("Trash directory is unlocked while the data moves in")
+		}
+	}}
+
+	testFile := filepath.Join(t.TempDir(), "moved.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(testFile); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if !checked {
+		t.Error("The data was never renamed into the trash")
+	}
+}
+
+func TestTrashCopyUnlocked(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	testFile := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(testFile, make([]byte, 100*1024), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Another process could take the lock while the data is being copied
+	var checked bool
+	opts := TrashOptions{Progress: func(copied, total int64, current string) {
+		if checked {
+			return
+		}
+		checked = true
+		f, err := os.OpenFile(filepath.Join(tr.homeTrash, lockFileName), os.O_RDWR, 0)
+		if err != nil {
+			t.Errorf("Failed to open lock file: %v", err)
+			return
+		}
+		defer f.Close()
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			t.Errorf("Trash directory is locked during the copy: %v", err)
+		}
+	}}
+	if err := tr.TrashWithOptions(testFile, opts); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if !checked {
+		t.Error("Progress was never called")
+	}
+}
+
+func TestTrashProgress(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	testDir := filepath.Join(t.TempDir(), "progress")
+	if err := os.MkdirAll(testDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	big := filepath.Join(testDir, "big.bin")
+	if err := os.WriteFile(big, make([]byte, 100*1024), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(testDir, "small.txt"), []byte("small"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Symlink("big.bin", filepath.Join(testDir, "link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	const want = 100*1024 + 5 + int64(len("big.bin"))
+
+	var calls, last int64
+	var bigCalls int
+	opts := TrashOptions{Progress: func(copied, total int64, current string) {
+		calls++
+		if total != want {
+			t.Errorf("Progress total = %d, want %d", total, want)
+		}
+		if copied < last || copied > total {
+			t.Errorf("Progress went from %d to %d of %d", last, copied, total)
+		}
+		if current == big {
+			bigCalls++
+		}
+		last = copied
+	}}
+	if err := tr.TrashWithOptions(testDir, opts); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+
+	if last != want {
+		t.Errorf("Progress ended at %d bytes, want %d", last, want)
+	}
+	// The big file spans several chunks, each reported on its own
+	if bigCalls < 2 {
+		t.Errorf("Progress called %d times for %s, want it called while copying", bigCalls, big)
+	}
+
+	// A rename within one filesystem copies nothing
+	tr.fs = osFS{}
+	file := filepath.Join(t.TempDir(), "renamed.txt")
+	if err := os.WriteFile(file, []byte("renamed"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	calls = 0
+	if err := tr.TrashWithOptions(file, opts); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Progress called %d times for a rename", calls)
+	}
+}
+
+// fatFS is a crossDeviceFS whose destination rejects names FAT cannot
+// store, the way a vfat mount fails with EINVAL.
+type fatFS struct {
+	crossDeviceFS
+}
+
+func (fs fatFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&os.O_CREATE != 0 && strings.ContainsAny(filepath.Base(name), `:?`) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EINVAL}
+	}
+	return fs.crossDeviceFS.OpenFile(name, flag, perm)
+}
+
+func TestTrashInvalidDestinationName(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = fatFS{}
+
+	testFile := filepath.Join(t.TempDir(), "notes: draft?.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if result.Name != "notes_ draft_.txt" {
+		t.Errorf("Trash name = %q, want %q", result.Name, "notes_ draft_.txt")
+	}
+	if result.OriginalPath != testFile {
+		t.Errorf("OriginalPath = %q, want %q", result.OriginalPath, testFile)
+	}
+
+	tr.fs = osFS{}
+	if err := tr.Restore(result.Name); err != nil {
+		t.Fatalf("Failed to restore file: %v", err)
+	}
+	if content, err := os.ReadFile(testFile); err != nil || string(content) != "content" {
+		t.Errorf("Restored file = %q, %v; want original content", content, err)
+	}
+}
+
+// remountFS reports src on another device once it has been looked up
+// after times Lstat calls, as if it were remounted mid-operation.
+type remountFS struct {
+	osFS
+	src   string
+	times int
+	calls *int
+}
+
+func (fs remountFS) Lstat(name string) (os.FileInfo, error) {
+	info, err := os.Lstat(name)
+	if err != nil || name != fs.src {
+		return info, err
+	}
+	*fs.calls++
+	if *fs.calls <= fs.times {
+		return info, nil
+	}
+	return otherDeviceInfo{info}, nil
+}
+
+func TestTrashVerifyDevice(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "remounted.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// The first two lookups are the initial stat and the device check made
+	// when resolving the trash; the re-check before the move sees a new device
+	calls := 0
+	tr.fs = remountFS{src: testFile, times: 2, calls: &calls}
+
+	err := tr.TrashWithOptions(testFile, TrashOptions{VerifyDevice: true})
+	if !errors.Is(err, ErrDeviceChanged) {
+		t.Fatalf("Expected ErrDeviceChanged, got: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("File was moved despite the device change: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(tr.homeTrash, "info"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Failed trash left info entries: %v, %v", entries, err)
+	}
+
+	calls = 0
+	if err := tr.TrashWithOptions(testFile, TrashOptions{}); err != nil {
+		t.Fatalf("Failed to trash without verification: %v", err)
+	}
+}
+
+// otherDeviceFS reports src on another device and counts the renames
+// attempted on it.
+type otherDeviceFS struct {
+	osFS
+	src     string
+	renames *int
+}
+
+func (fs otherDeviceFS) Lstat(name string) (os.FileInfo, error) {
+	info, err := os.Lstat(name)
+	if err != nil || name != fs.src {
+		return info, err
+	}
+	return otherDeviceInfo{info}, nil
+}
+
+func (fs otherDeviceFS) Rename(oldpath, newpath string) error {
+	if oldpath == fs.src {
+		*fs.renames++
+	}
+	return os.Rename(oldpath, newpath)
+}
+
+func TestTrashKnownCrossDevice(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "elsewhere.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	renames := 0
+	tr.fs = otherDeviceFS{src: testFile, renames: &renames}
+
+	result, err := tr.TrashWithResult(testFile, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if renames != 0 {
+		t.Errorf("Attempted %d renames of a file known to be on another device", renames)
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("File still exists after trashing")
+	}
+	if content, err := os.ReadFile(result.FilePath); err != nil || string(content) != "content" {
+		t.Errorf("Trashed copy = %q, %v; want the original content", content, err)
+	}
+}
+
+func TestTrashDryRunKnownCrossDevice(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testFile := filepath.Join(t.TempDir(), "elsewhere.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	sameFile := filepath.Join(t.TempDir(), "here.txt")
+	if err := os.WriteFile(sameFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// The home trash doesn't exist yet, and isn't a fallback either
+	renames := 0
+	tr.fs = otherDeviceFS{src: testFile, renames: &renames}
+	plans := tr.TrashDryRun([]string{testFile, sameFile})
+	if plan := plans[0]; plan.Err != nil || !plan.CrossDevice {
+		t.Errorf("Plan for a file on another device = %+v, want CrossDevice", plan)
+	}
+	if plan := plans[1]; plan.Err != nil || plan.CrossDevice {
+		t.Errorf("Plan for a file on the same device = %+v, want no CrossDevice", plan)
+	}
+}
+
+func TestTrashBytesFreedAtSource(t *testing.T) {
+	for _, crossDevice := range []bool{false, true} {
+		tr := newTestTrasher(t)
+		if crossDevice {
+			tr.fs = crossDeviceFS{}
+		}
+
+		testFile := filepath.Join(t.TempDir(), "data.bin")
+		if err := os.WriteFile(testFile, make([]byte, 4096), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		result, err := tr.TrashWithResult(testFile, TrashOptions{})
+		if err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+
+		want := int64(0)
+		if crossDevice {
+			want = 4096
+		}
+		if result.BytesFreedAtSource != want {
+			t.Errorf("Cross-device %v: BytesFreedAtSource = %d, want %d", crossDevice, result.BytesFreedAtSource, want)
+		}
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+
+	tr, err := New(WithEnv([]string{"HOME=" + t.TempDir()}), WithJSONLines(out))
+	if err != nil {
+		t.Fatalf("Failed to create trasher: %v", err)
+	}
+	tr.fs = crossDeviceFS{}
+
+	testDir := filepath.Join(t.TempDir(), "tree")
+	if err := os.MkdirAll(filepath.Join(testDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	files := map[string]int{"a.bin": 100, "sub/b.bin": 50}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(testDir, name), make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	if err := tr.Trash(testDir); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+	tr.fs = osFS{}
+	if err := tr.Restore("tree"); err != nil {
+		t.Fatalf("Failed to restore directory: %v", err)
+	}
+
+	// Nothing is left buffered, since every line is flushed
+	var got []progressRecord
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec progressRecord
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("Invalid JSON line: %v", err)
+		}
+		got = append(got, rec)
+	}
+
+	want := []progressRecord{
+		{Op: "trash", Path: testDir, Status: "started"},
+		{Op: "trash", Path: filepath.Join(testDir, "a.bin"), Bytes: 100, Status: "progress"},
+		{Op: "trash", Path: filepath.Join(testDir, "sub", "b.bin"), Bytes: 150, Status: "progress"},
+		{Op: "trash", Path: testDir, Bytes: 150, Status: "done"},
+		{Op: "restore", Path: testDir, Status: "started"},
+		{Op: "restore", Path: testDir, Status: "done"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Got events %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// fullDiskFS is a crossDeviceFS whose written files fill up after limit
+// bytes, either failing with ENOSPC or, if silent, returning a short count
+// without an error.
+type fullDiskFS struct {
+	crossDeviceFS
+	limit  int
+	silent bool
+}
+
+type fullDiskFile struct {
+	File
+	left   *int
+	silent bool
+}
+
+func (f fullDiskFile) Write(p []byte) (int, error) {
+	if len(p) <= *f.left {
+		*f.left -= len(p)
+		return f.File.Write(p)
+	}
+	n, _ := f.File.Write(p[:*f.left])
+	*f.left = 0
+	if f.silent {
+		return n, nil
+	}
+	return n, &os.PathError{Op: "write", Path: "full", Err: syscall.ENOSPC}
+}
+
+func (fs fullDiskFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := fs.crossDeviceFS.OpenFile(name, flag, perm)
+	if err != nil || flag&(os.O_WRONLY|os.O_RDWR) == 0 || strings.Contains(name, ".trashinfo") {
+		return f, err
+	}
+	left := fs.limit
+	return fullDiskFile{f, &left, fs.silent}, nil
+}
+
+func TestRestoreFullDisk(t *testing.T) {
+	for _, silent := range []bool{false, true} {
+		tr := newTestTrasher(t)
+
+		testFile := filepath.Join(t.TempDir(), "big.bin")
+		content := make([]byte, 64*1024)
+		if err := os.WriteFile(testFile, content, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := tr.Trash(testFile); err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+
+		// Restoring must copy, and the destination fills up part way
+		tr.fs = fullDiskFS{limit: 40 * 1024, silent: silent}
+		err := tr.Restore("big.bin")
+		if !errors.Is(err, ErrInsufficientSpace) {
+			t.Fatalf("silent=%v: expected ErrInsufficientSpace, got %v", silent, err)
+		}
+
+		// The partial copy is gone and the item is still in the trash
+		if _, err := os.Lstat(testFile); !os.IsNotExist(err) {
+			t.Errorf("silent=%v: truncated restore left %s behind", silent, testFile)
+		}
+		tr.fs = osFS{}
+		items, err := tr.List()
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		if len(items) != 1 {
+			t.Fatalf("silent=%v: expected the item to stay in the trash, got %d items", silent, len(items))
+		}
+		if info, err := os.Stat(items[0].FilePath); err != nil || info.Size() != int64(len(content)) {
+			t.Errorf("silent=%v: trashed file was damaged: %v", silent, err)
+		}
+
+		// With room to spare the copy succeeds
+		tr.fs = crossDeviceFS{}
+		if err := tr.Restore("big.bin"); err != nil {
+			t.Fatalf("silent=%v: failed to restore across devices: %v", silent, err)
+		}
+		if info, err := os.Stat(testFile); err != nil || info.Size() != int64(len(content)) {
+			t.Errorf("silent=%v: restored file is incomplete: %v", silent, err)
+		}
+	}
+}
+
+func TestTrashUmask(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	testDir := filepath.Join(t.TempDir(), "shared")
+	if err := os.MkdirAll(filepath.Join(testDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	testFile := filepath.Join(testDir, "sub", "notes.txt")
+	if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := tr.TrashWithOptions(testDir, TrashOptions{Umask: 0077}); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+
+	trashed := filepath.Join(tr.homeTrash, "files", "shared")
+	for path, want := range map[string]os.FileMode{
+		trashed:                       0700,
+		filepath.Join(trashed, "sub"): 0700,
+		filepath.Join(trashed, "sub", "notes.txt"): 0600,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("Mode of %s = %o, want %o", path, got, want)
+		}
+	}
+}
+
+func TestTrashDirectoryMode(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	testDir := filepath.Join(t.TempDir(), "private")
+	if err := os.MkdirAll(filepath.Join(testDir, "frozen"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(testDir, "shared"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(testDir, "frozen", "notes.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	// A read-only directory can only be given its mode once it is filled
+	modes := map[string]os.FileMode{"": 0700, "shared": 0750, "frozen": 0555}
+	for name, mode := range modes {
+		if err := os.Chmod(filepath.Join(testDir, name), mode); err != nil {
+			t.Fatalf("Failed to set mode: %v", err)
+		}
+	}
+	t.Cleanup(func() { os.Chmod(filepath.Join(testDir, "frozen"), 0755) })
+
+	if err := tr.Trash(testDir); err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+
+	check := func(root, when string) {
+		t.Helper()
+		for name, want := range modes {
+			info, err := os.Stat(filepath.Join(root, name))
+			if err != nil {
+				t.Fatalf("%s: failed to stat %q: %v", when, name, err)
+			}
+			if got := info.Mode().Perm(); got != want {
+				t.Errorf("%s: mode of %q = %o, want %o", when, name, got, want)
+			}
+		}
+	}
+	check(filepath.Join(tr.homeTrash, "files", "private"), "trashed")
+
+	if err := tr.Restore("private"); err != nil {
+		t.Fatalf("Failed to restore directory: %v", err)
+	}
+	check(testDir, "restored")
+}
+
+// bindLoopFS is a crossDeviceFS on which loop, a directory inside root,
+// shows root's contents again, as if root were bind-mounted onto it.
+type bindLoopFS struct {
+	crossDeviceFS
+	root, loop string
+}
+
+func (f bindLoopFS) redirect(name string) string {
+	if name == f.loop || strings.HasPrefix(name, f.loop+string(filepath.Separator)) {
+		return f.redirect(f.root + strings.TrimPrefix(name, f.loop))
+	}
+	return name
+}
+
+func (f bindLoopFS) Lstat(name string) (os.FileInfo, error) { return os.Lstat(f.redirect(name)) }
+func (f bindLoopFS) Stat(name string) (os.FileInfo, error)  { return os.Stat(f.redirect(name)) }
+func (f bindLoopFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(f.redirect(name))
+}
+
+func TestTrashDirectoryCycle(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = crossDeviceFS{}
+
+	// A symlink to its own directory is copied as a link, not followed
+	dir := filepath.Join(t.TempDir(), "project")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Symlink("..", filepath.Join(dir, "sub", "up")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if size, err := tr.diskUsage(dir); err != nil || size == 0 {
+		t.Errorf("diskUsage = %d, %v; want the size of the tree", size, err)
+	}
+	result, err := tr.TrashWithResult(dir, TrashOptions{})
+	if err != nil {
+		t.Fatalf("Failed to trash directory with symlink loop: %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(result.FilePath, "sub", "up")); err != nil || target != ".." {
+		t.Errorf("Trashed symlink = %q, %v; want ..", target, err)
+	}
+
+	// A directory mounted inside itself is refused rather than copied
+	// until the paths get too long
+	dir = filepath.Join(t.TempDir(), "looped")
+	loop := filepath.Join(dir, "mnt")
+	if err := os.MkdirAll(loop, 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	tr.fs = bindLoopFS{root: dir, loop: loop}
+	if _, err := tr.diskUsage(dir); !errors.Is(err, ErrDirectoryCycle) {
+		t.Errorf("diskUsage = %v, want ErrDirectoryCycle", err)
+	}
+	if err := tr.Trash(dir); !errors.Is(err, ErrDirectoryCycle) {
+		t.Errorf("Trash = %v, want ErrDirectoryCycle", err)
+	}
+	if _, err := os.Stat(loop); err != nil {
+		t.Errorf("Looped directory was not left in place: %v", err)
+	}
+}
+
+// cancelOnCreateFS is a crossDeviceFS that calls cancel once after creating
+// files for writing.
+type cancelOnCreateFS struct {
+	crossDeviceFS
+	after  int
+	cancel context.CancelFunc
+	n      *int
+}
+
+func (fs cancelOnCreateFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := fs.crossDeviceFS.OpenFile(name, flag, perm)
+	if err == nil && flag&os.O_CREATE != 0 {
+		if *fs.n++; *fs.n == fs.after {
+			fs.cancel()
+		}
+	}
+	return f, err
+}
+
+func TestTrashContextDirectory(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	testDir := filepath.Join(t.TempDir(), "big")
+	if err := os.MkdirAll(filepath.Join(testDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"a.bin", "b.bin", "sub/c.bin", "sub/d.bin"} {
+		if err := os.WriteFile(filepath.Join(testDir, name), make([]byte, 64<<10), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Cancelled while the second file is being copied
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tr.fs = cancelOnCreateFS{after: 3, cancel: cancel, n: new(int)}
+
+	if err := tr.TrashContext(ctx, testDir); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	for _, name := range []string{"a.bin", "b.bin", "sub/c.bin", "sub/d.bin"} {
+		if info, err := os.Stat(filepath.Join(testDir, name)); err != nil || info.Size() != 64<<10 {
+			t.Errorf("Original %s was not left in place: %v", name, err)
+		}
+	}
+	for _, dir := range []string{"files", "info"} {
+		entries, err := os.ReadDir(filepath.Join(tr.homeTrash, dir))
+		if err != nil {
+			t.Fatalf("Failed to read trash %s directory: %v", dir, err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("Cancelled trash left %d entries in %s/", len(entries), dir)
+		}
+	}
+}
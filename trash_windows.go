@@ -5,7 +5,16 @@ package trash
 
 import (
 	"errors"
+	"os"
 	"strings"
+	"syscall"
+)
+
+// Error codes syscall does not export.
+const (
+	errorHandleDiskFull = syscall.Errno(39)  // ERROR_HANDLE_DISK_FULL
+	errorDiskFull       = syscall.Errno(112) // ERROR_DISK_FULL
+	errorInvalidName    = syscall.Errno(123) // ERROR_INVALID_NAME
 )
 
 func isCrossDeviceError(err error) bool {
@@ -17,3 +26,32 @@ func isCrossDeviceError(err error) bool {
 	return strings.Contains(errStr, "The system cannot move the file to a different disk drive") ||
 		strings.Contains(errStr, "incorrect function")
 }
+
+// isNoSpaceError reports whether err means the destination disk is full.
+func isNoSpaceError(err error) bool {
+	return errors.Is(err, errorDiskFull) || errors.Is(err, errorHandleDiskFull)
+}
+
+// isInvalidNameError reports whether err means the filesystem rejected a
+// file name.
+func isInvalidNameError(err error) bool {
+	return errors.Is(err, errorInvalidName)
+}
+
+// deviceID is not implemented on Windows, where os.FileInfo carries no
+// device number.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// fileID is not implemented on Windows either, so directory cycles are not
+// detected there.
+func fileID(info os.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
+
+// fileOwner is not implemented on Windows, which has no uids, so trash
+// directories aren't checked for ownership there.
+func fileOwner(info os.FileInfo) (string, bool) {
+	return "", false
+}
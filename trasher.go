@@ -0,0 +1,367 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// A Trasher operates on the trash of one user environment. The package-level
+// functions use a Trasher configured from the process environment; create
+// others with New, e.g. to act on behalf of several users from one daemon.
+//
+// On Windows, a Trasher for the process's own environment, created without
+// WithEnv, WithFS, WithUID or WithTrashRoot, trashes to the Recycle Bin, so
+// Explorer can restore the files, and lists, restores and empties the
+// Recycle Bin alongside the XDG trash. Files the Recycle Bin can't take,
+// such as ones too big for it, go to the XDG trash instead, as do files
+// trashed in a Batch. TrashOptions that the Recycle Bin has nowhere to
+// record, such as Reason, OriginalPath or MaxVersionsPerPath, make Trash
+// fail with errors.ErrUnsupported; the copy options don't apply, since the
+// file is only renamed within its volume.
+//
+// On macOS, such a Trasher created WithMacTrash moves files to ~/.Trash or
+// a volume's .Trashes instead, so Finder shows them. See WithMacTrash for
+// what that gives up.
+type Trasher struct {
+	homeTrash string
+	uid       string
+	fs        FS
+	layout    Layout
+	hooks     []func(Event)
+	jsonl     *jsonlStream
+	logger    *slog.Logger
+
+	// disabled makes Trash refuse, or delete if deleteWhenDisabled is set
+	disabled           atomic.Bool
+	deleteWhenDisabled bool
+
+	// nameAttempts is how many numbered names are tried before a random one
+	nameAttempts int
+
+	// listIndex makes List read and write a listIndexFile per trash directory
+	listIndex bool
+
+	// recycleBin makes Trash use the Windows Recycle Bin, whose folders are
+	// listed as mount trashes
+	recycleBin bool
+
+	// macTrash is the user's ~/.Trash if Trash moves files to the macOS
+	// Trash, and empty otherwise
+	macTrash string
+
+	// index caches which trash directory holds each trash name
+	index nameIndex
+
+	// Mount detection, replaceable to simulate other filesystems
+	mountPoint  func(path string) (string, error)
+	mountPoints func() ([]string, error)
+	deviceLabel func(mount string) (uuid, label string)
+
+	// recycleFile moves a file to the Recycle Bin, replaceable to simulate
+	// Windows
+	recycleFile func(path string) (string, error)
+
+	// macTrashItem moves a file to the macOS Trash, replaceable to simulate
+	// macOS
+	macTrashItem func(path string) (string, error)
+}
+
+// An Option configures a Trasher created by New.
+type Option func(*options)
+
+type options struct {
+	env       []string
+	trashRoot string
+	uid       string
+	layout    Layout
+	fs        FS
+	hooks     []func(Event)
+	jsonl     *jsonlStream
+	logger    *slog.Logger
+
+	nameAttempts       int
+	deleteWhenDisabled bool
+	listIndex          bool
+	macTrash           bool
+}
+
+// WithEnv makes the Trasher read HOME and XDG_DATA_HOME from env, a list of
+// "key=value" strings in the form returned by os.Environ, instead of from the
+// process environment.
+func WithEnv(env []string) Option {
+	return func(o *options) {
+		o.env = env
+	}
+}
+
+// WithTrashRoot makes the Trasher keep its home trash in dir instead of
+// deriving it from HOME or XDG_DATA_HOME, e.g. to confine a sandboxed
+// application or a test. Like WithFS, it also confines the Trasher to that
+// one trash: files on other filesystems are copied there rather than moved
+// to the trash directories of their mounts.
+func WithTrashRoot(dir string) Option {
+	return func(o *options) {
+		o.trashRoot = dir
+	}
+}
+
+// WithUID makes the Trasher name the trash directories of mounted
+// filesystems after uid, as in .Trash-$uid, instead of the current
+// user's uid, e.g. for a daemon that trashes on behalf of another user.
+func WithUID(uid string) Option {
+	return func(o *options) {
+		o.uid = uid
+	}
+}
+
+// WithLogger makes the Trasher report problems it works around, such as a
+// trash directory that can't be locked, to logger instead of
+// slog.Default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithLayout makes the Trasher arrange files within trash directories
+// according to l instead of XDGLayout.
+func WithLayout(l Layout) Option {
+	return func(o *options) {
+		o.layout = l
+	}
+}
+
+// WithFS makes the Trasher perform all file operations through fsys instead
+// of the os package. Mount points of the local system mean nothing to
+// another filesystem, so such a Trasher uses only the home trash, at the
+// path derived from HOME or XDG_DATA_HOME as usual.
+func WithFS(fsys FS) Option {
+	return func(o *options) {
+		o.fs = fsys
+	}
+}
+
+// defaultNameAttempts is the number of names tried by default for a trash
+// entry before falling back to a random one.
+const defaultNameAttempts = 100
+
+// WithMaxNameAttempts makes the Trasher try at most n names, the base name
+// and then numbered variants, for a new trash entry before falling back to
+// a random suffix. The default is 100; lower values bound the time spent in
+// a trash crowded with one name.
+func WithMaxNameAttempts(n int) Option {
+	return func(o *options) {
+		o.nameAttempts = n
+	}
+}
+
+// WithDeleteWhenDisabled makes Trash on a disabled Trasher delete files
+// permanently instead of failing with ErrTrashDisabled. See
+// Trasher.Disable.
+func WithDeleteWhenDisabled() Option {
+	return func(o *options) {
+		o.deleteWhenDisabled = true
+	}
+}
+
+// WithListIndex makes the Trasher keep an index of each trash directory's
+// items, so List need not parse every trashinfo file of a large trash. See
+// listIndexFile.
+func WithListIndex() Option {
+	return func(o *options) {
+		o.listIndex = true
+	}
+}
+
+// WithMacTrash makes Trash on macOS move files to the macOS Trash, with
+// NSFileManager's trashItemAtURL, rather than the XDG trash, so they show
+// in Finder's Trash. Like the Recycle Bin on Windows, it applies only to a
+// Trasher for the process's own environment, takes TrashOptions that need
+// a trashinfo as errors.ErrUnsupported, and leaves Batch files to the XDG
+// trash. New fails with errors.ErrUnsupported if the option can't be
+// honoured: outside macOS, in a build without cgo, or together with
+// WithEnv, WithFS, WithUID or WithTrashRoot.
+//
+// macOS keeps no trashinfo for what it moved there, so the Trasher can't
+// list, restore or empty those files: List, Restore and Empty only see the
+// XDG trash, and the TrashItem returned for such a file has no InfoPath. Nor does trashItemAtURL record the original
+// location the way Finder's own Delete does, so Finder may not offer
+// "Put Back" for them; they are restored by dragging them out of the
+// Trash.
+func WithMacTrash() Option {
+	return func(o *options) {
+		o.macTrash = true
+	}
+}
+
+// New returns a Trasher configured by opts.
+func New(opts ...Option) (*Trasher, error) {
+	o := options{layout: XDGLayout{}, nameAttempts: defaultNameAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// The home trash is created lazily by the first Trash, so that merely
+	// creating a Trasher (or querying with HasTrash) has no side effects.
+	trashDir := o.trashRoot
+	if trashDir == "" {
+		dataHome, err := dataHome(o.env)
+		if err != nil {
+			return nil, err
+		}
+		trashDir = filepath.Join(dataHome, "Trash")
+	} else {
+		abs, err := filepath.Abs(trashDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		trashDir = abs
+	}
+
+	uid, ok := o.uid, o.uid != ""
+	if !ok {
+		uid, ok = lookupUID()
+	}
+
+	tr := &Trasher{
+		homeTrash: trashDir,
+		uid:       uid,
+		fs:        osFS{},
+		layout:    o.layout,
+		hooks:     o.hooks,
+		jsonl:     o.jsonl,
+		logger:    o.logger,
+
+		nameAttempts:       o.nameAttempts,
+		deleteWhenDisabled: o.deleteWhenDisabled,
+		listIndex:          o.listIndex,
+
+		mountPoint:  getMountPoint,
+		mountPoints: getMountPoints,
+		deviceLabel: lookupDeviceLabel,
+		recycleFile: recycleFile,
+
+		macTrashItem: macTrashItem,
+	}
+
+	if o.fs != nil {
+		tr.fs = o.fs
+	}
+
+	// Explorer and Finder only show what is in their own trash, but a
+	// Trasher set up for another environment or filesystem keeps to the XDG
+	// trash
+	native := o.uid == "" && ok && o.env == nil && o.fs == nil && o.trashRoot == ""
+	tr.recycleBin = native && recycleBinAvailable()
+	if o.macTrash {
+		if !native || !macTrashAvailable() {
+			return nil, fmt.Errorf("%w: WithMacTrash needs macOS, cgo and the process's own environment", errors.ErrUnsupported)
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the macOS Trash: %w", err)
+		}
+		tr.macTrash = filepath.Join(home, ".Trash")
+	}
+
+	if tr.logger == nil {
+		tr.logger = slog.Default()
+	}
+
+	// Mount trashes are named after the uid and mean nothing on another
+	// filesystem, so without either only the home trash is used, as it is
+	// when the Trasher is confined to a trash root
+	if o.fs != nil || !ok || o.trashRoot != "" {
+		tr.mountPoint = func(string) (string, error) { return "", nil }
+		tr.mountPoints = func() ([]string, error) { return nil, nil }
+	}
+
+	return tr, nil
+}
+
+// dataHome returns $XDG_DATA_HOME, or its default under the home directory,
+// from env if it is non-nil and from the process environment otherwise.
+func dataHome(env []string) (string, error) {
+	getenv := os.Getenv
+	homeDir := os.UserHomeDir
+	if env != nil {
+		getenv = envLookup(env)
+		homeDir = func() (string, error) {
+			if home := getenv("HOME"); home != "" {
+				return home, nil
+			}
+			return "", fmt.Errorf("$HOME is not defined")
+		}
+	}
+
+	if dataHome := getenv("XDG_DATA_HOME"); dataHome != "" {
+		return dataHome, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// Replaceable to simulate minimal environments
+var (
+	currentUser = user.Current
+	getuid      = os.Getuid
+)
+
+// Disable makes every later Trash fail with ErrTrashDisabled, or delete
+// permanently if the Trasher was created with WithDeleteWhenDisabled,
+// until Enable is called. It lets an application switch trashing off for a
+// while, e.g. during a bulk import, without changing its call sites.
+// Restore, Delete and Empty are unaffected. Trashing that has to be undone,
+// in a Batch or by RestoreAndTrashConflict, fails with ErrTrashDisabled
+// either way.
+func (tr *Trasher) Disable() {
+	tr.disabled.Store(true)
+}
+
+// Enable undoes Disable.
+func (tr *Trasher) Enable() {
+	tr.disabled.Store(false)
+}
+
+// Disabled reports whether trashing is disabled.
+func (tr *Trasher) Disabled() bool {
+	return tr.disabled.Load()
+}
+
+// lookupUID returns the current user's uid for naming mount trashes. If the
+// user database is unavailable, as in some static builds and containers,
+// the uid comes from the kernel instead. ok is false if there is no
+// meaningful uid, as on Windows.
+func lookupUID() (uid string, ok bool) {
+	if u, err := currentUser(); err == nil {
+		return u.Uid, true
+	}
+	if id := getuid(); id >= 0 {
+		return strconv.Itoa(id), true
+	}
+	return "", false
+}
+
+// envLookup returns a getenv-style function over env. Later entries win, as
+// they do for exec.Cmd.
+func envLookup(env []string) func(string) string {
+	return func(key string) string {
+		var value string
+		for _, kv := range env {
+			if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+				value = v
+			}
+		}
+		return value
+	}
+}
@@ -0,0 +1,190 @@
+package trash
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrashFS returns a read-only fs.FS over the trashed data, for code built
+// around fs.FS and fs.WalkDir such as generic file pickers. Its root holds
+// one entry per item, named after the item's original base name, or its
+// trash name where two items share one, and with the deletion date as its
+// modification time. Trashed directories can be walked into. The set of
+// items is fixed when TrashFS is called; their contents are read as they
+// are opened.
+func (tr *Trasher) TrashFS() (fs.FS, error) {
+	items, err := tr.List()
+	if err != nil {
+		return nil, err
+	}
+
+	bases := make(map[string]int, len(items))
+	for _, item := range items {
+		bases[filepath.Base(item.OriginalPath)]++
+	}
+
+	tfs := &trashFS{tr: tr, items: make(map[string]TrashItem, len(items))}
+	for _, item := range items {
+		name := item.Name
+		if base := filepath.Base(item.OriginalPath); bases[base] == 1 {
+			name = base
+		}
+		name = tfs.freeName(name)
+		tfs.items[name] = item
+		tfs.names = append(tfs.names, name)
+	}
+	sort.Strings(tfs.names)
+
+	return tfs, nil
+}
+
+type trashFS struct {
+	tr    *Trasher
+	items map[string]TrashItem
+	names []string
+}
+
+// freeName returns name, or a numbered variant of it if an item already
+// has it or it isn't a valid fs.FS name.
+func (tfs *trashFS) freeName(name string) string {
+	if _, ok := tfs.items[name]; !ok && fs.ValidPath(name) && !strings.Contains(name, "/") && name != "." {
+		return name
+	}
+	name = strings.ReplaceAll(name, "/", "_")
+	for i := 2; ; i++ {
+		numbered := name + " (" + strconv.Itoa(i) + ")"
+		if _, ok := tfs.items[numbered]; !ok {
+			return numbered
+		}
+	}
+}
+
+func (tfs *trashFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		entries := make([]fs.DirEntry, 0, len(tfs.names))
+		for _, n := range tfs.names {
+			entries = append(entries, fs.FileInfoToDirEntry(tfs.itemInfo(n)))
+		}
+		return &trashDirFile{info: rootInfo{}, entries: entries}, nil
+	}
+
+	top, rest, _ := strings.Cut(name, "/")
+	item, ok := tfs.items[top]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	realPath := item.FilePath
+	if rest != "" {
+		realPath = filepath.Join(realPath, filepath.FromSlash(rest))
+	}
+
+	info, err := tfs.tr.fs.Stat(realPath)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapPathError(err)}
+	}
+	if rest == "" {
+		info = renamedInfo{info, top, item.DeletionDate}
+	}
+
+	if info.IsDir() {
+		dirEntries, err := tfs.tr.fs.ReadDir(realPath)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapPathError(err)}
+		}
+		return &trashDirFile{info: info, entries: dirEntries}, nil
+	}
+
+	f, err := tfs.tr.fs.OpenFile(realPath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapPathError(err)}
+	}
+	return &trashFile{File: f, info: info}, nil
+}
+
+// itemInfo describes the root entry name, falling back to what the
+// trashinfo records if the data can't be examined.
+func (tfs *trashFS) itemInfo(name string) fs.FileInfo {
+	item := tfs.items[name]
+	info, err := tfs.tr.fs.Lstat(item.FilePath)
+	if err != nil {
+		return renamedInfo{rootInfo{}, name, item.DeletionDate}
+	}
+	return renamedInfo{info, name, item.DeletionDate}
+}
+
+// unwrapPathError returns the underlying error of a *fs.PathError, so the
+// error reported names the path within the FS rather than on disk.
+func unwrapPathError(err error) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return pe.Err
+	}
+	return err
+}
+
+// renamedInfo is a root entry's FileInfo, under its name in a trashFS and
+// dated at its deletion.
+type renamedInfo struct {
+	fs.FileInfo
+	name    string
+	modTime time.Time
+}
+
+func (fi renamedInfo) Name() string       { return fi.name }
+func (fi renamedInfo) ModTime() time.Time { return fi.modTime }
+
+// rootInfo describes the root directory of a trashFS.
+type rootInfo struct{}
+
+func (rootInfo) Name() string       { return "." }
+func (rootInfo) Size() int64        { return 0 }
+func (rootInfo) Mode() fs.FileMode  { return fs.ModeDir | 0500 }
+func (rootInfo) ModTime() time.Time { return time.Time{} }
+func (rootInfo) IsDir() bool        { return true }
+func (rootInfo) Sys() interface{}   { return nil }
+
+type trashFile struct {
+	File
+	info fs.FileInfo
+}
+
+func (f *trashFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+type trashDirFile struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *trashDirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *trashDirFile) Close() error               { return nil }
+
+func (d *trashDirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: fs.ErrInvalid}
+}
+
+func (d *trashDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
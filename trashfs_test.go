@@ -0,0 +1,99 @@
+package trash
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTrashFS(t *testing.T) {
+	tr := newTestTrasher(t)
+
+	deleted := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.Local)
+	first := t.TempDir()
+	second := t.TempDir()
+	for path, content := range map[string]string{
+		filepath.Join(first, "album", "one.jpg"):        "one",
+		filepath.Join(first, "album", "sub", "two.jpg"): "two",
+		filepath.Join(first, "notes.txt"):               "first notes",
+		filepath.Join(second, "notes.txt"):              "second notes",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	for _, path := range []string{
+		filepath.Join(first, "album"),
+		filepath.Join(first, "notes.txt"),
+		filepath.Join(second, "notes.txt"),
+	} {
+		if err := tr.TrashAt(path, deleted); err != nil {
+			t.Fatalf("Failed to trash %s: %v", path, err)
+		}
+	}
+
+	fsys, err := tr.TrashFS()
+	if err != nil {
+		t.Fatalf("TrashFS failed: %v", err)
+	}
+
+	var walked []string
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		walked = append(walked, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir failed: %v", err)
+	}
+	sort.Strings(walked)
+
+	// Both notes.txt keep their trash names, being ambiguous
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	want := []string{".", "album", "album/one.jpg", "album/sub", "album/sub/two.jpg"}
+	var notes []string
+	for _, item := range items {
+		if filepath.Base(item.OriginalPath) == "notes.txt" {
+			notes = append(notes, item.Name)
+		}
+	}
+	want = append(want, notes...)
+	sort.Strings(want)
+	if len(walked) != len(want) {
+		t.Fatalf("Walked %v, want %v", walked, want)
+	}
+	for i := range want {
+		if walked[i] != want[i] {
+			t.Fatalf("Walked %v, want %v", walked, want)
+		}
+	}
+
+	info, err := fs.Stat(fsys, "album")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if !info.IsDir() || !info.ModTime().Equal(deleted) {
+		t.Errorf("album: dir %v, modified %v; want a directory modified %v", info.IsDir(), info.ModTime(), deleted)
+	}
+
+	content, err := fs.ReadFile(fsys, "album/sub/two.jpg")
+	if err != nil || string(content) != "two" {
+		t.Errorf("ReadFile = %q, %v; want \"two\"", content, err)
+	}
+
+	if err := fstest.TestFS(fsys, append([]string{"album/one.jpg", "album/sub/two.jpg"}, notes...)...); err != nil {
+		t.Error(err)
+	}
+}
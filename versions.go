@@ -0,0 +1,69 @@
+package trash
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pruneVersions deletes the oldest items in item's trash directory that
+// share its original path, keeping the newest keep of them, item always
+// among them. Items deleted in the same second are ordered by name. Only
+// trash names derived from the path's base name, as Trash gives them, are
+// read, so pruning doesn't parse the whole trash directory.
+func (tr *Trasher) pruneVersions(item TrashItem, keep int) error {
+	names, err := tr.layout.Entries(item.TrashDir, tr.fs.ReadDir)
+	if err != nil {
+		return err
+	}
+
+	// Compared as parsed, so relative paths in a mount's trash match
+	current, err := tr.parseTrashInfo(item.TrashDir, item.Name)
+	if err != nil {
+		return err
+	}
+
+	base := filepath.Base(item.OriginalPath)
+	stems := []string{sanitizeFilename(base), sanitizeFilename(portableName(base))}
+	var older []TrashItem
+	for _, name := range names {
+		if name == item.Name || !versionName(name, stems) {
+			continue
+		}
+		it, err := tr.parseTrashInfo(item.TrashDir, name)
+		if err == nil && it.OriginalPath == current.OriginalPath {
+			older = append(older, it)
+		}
+	}
+	if len(older) < keep {
+		return nil
+	}
+
+	sort.Slice(older, func(i, j int) bool {
+		a, b := older[i], older[j]
+		if !a.DeletionDate.Equal(b.DeletionDate) {
+			return a.DeletionDate.After(b.DeletionDate)
+		}
+		return a.Name > b.Name
+	})
+
+	var errs []error
+	for _, it := range older[keep-1:] {
+		if err := tr.deleteItem(it); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// versionName reports whether the trash name name was derived from one of
+// stems: the stem itself, numbered, or with a random suffix.
+func versionName(name string, stems []string) bool {
+	for _, stem := range stems {
+		if name == stem || strings.HasPrefix(name, stem+".") {
+			return true
+		}
+	}
+	return false
+}
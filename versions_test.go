@@ -0,0 +1,118 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrashMaxVersionsPerPath(t *testing.T) {
+	tr := newTestTrasher(t)
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "build.cache")
+	other := filepath.Join(dir, "other.txt")
+
+	if err := os.WriteFile(other, []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := tr.Trash(other); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	const keep = 3
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i < 50; i++ {
+		if err := os.WriteFile(cache, []byte{byte(i)}, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		opts := TrashOptions{
+			MaxVersionsPerPath: keep,
+			DeletionDate:       base.Add(time.Duration(i) * time.Second),
+		}
+		result, err := tr.TrashWithResult(cache, opts)
+		if err != nil {
+			t.Fatalf("Failed to trash file %d: %v", i, err)
+		}
+		if _, err := os.Stat(result.InfoPath); err != nil {
+			t.Fatalf("Newest version %d was pruned: %v", i, err)
+		}
+
+		entries, err := os.ReadDir(filepath.Join(tr.homeTrash, "info"))
+		if err != nil {
+			t.Fatalf("Failed to read info directory: %v", err)
+		}
+		if want := min(i+1, keep) + 1; len(entries) != want {
+			t.Fatalf("After %d trashes info/ has %d entries, want %d", i+1, len(entries), want)
+		}
+	}
+
+	items, err := tr.List()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	var kept []byte
+	for _, item := range items {
+		if item.OriginalPath == other {
+			continue
+		}
+		data, err := os.ReadFile(item.FilePath)
+		if err != nil {
+			t.Fatalf("Failed to read trashed data: %v", err)
+		}
+		kept = append(kept, data...)
+	}
+	seen := map[byte]bool{}
+	for _, b := range kept {
+		seen[b] = true
+	}
+	if len(kept) != keep || !seen[47] || !seen[48] || !seen[49] {
+		t.Errorf("Kept versions %v, want the last %d", kept, keep)
+	}
+
+	files, err := os.ReadDir(filepath.Join(tr.homeTrash, "files"))
+	if err != nil {
+		t.Fatalf("Failed to read files directory: %v", err)
+	}
+	if len(files) != keep+1 {
+		t.Errorf("files/ has %d entries, want %d", len(files), keep+1)
+	}
+}
+
+// refuseRemoveFS fails to remove anything below dir.
+type refuseRemoveFS struct {
+	osFS
+	dir string
+}
+
+func (f refuseRemoveFS) RemoveAll(path string) error {
+	if within(path, f.dir) {
+		return &os.PathError{Op: "unlinkat", Path: path, Err: os.ErrPermission}
+	}
+	return f.osFS.RemoveAll(path)
+}
+
+func TestTrashPruneFailure(t *testing.T) {
+	tr := newTestTrasher(t)
+	tr.fs = refuseRemoveFS{dir: filepath.Join(tr.homeTrash, "files")}
+
+	cache := filepath.Join(t.TempDir(), "build.cache")
+	for i := 0; i < 2; i++ {
+		if err := os.WriteFile(cache, []byte{byte(i)}, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		result, err := tr.TrashWithResult(cache, TrashOptions{MaxVersionsPerPath: 1})
+		if err != nil {
+			t.Fatalf("Trash %d failed: %v", i, err)
+		}
+		if result.Name == "" || result.InfoPath == "" {
+			t.Fatalf("Trash %d result = %+v, want the trashed item", i, result)
+		}
+		if (i == 1) != (result.PruneErr != nil) {
+			t.Errorf("Trash %d PruneErr = %v", i, result.PruneErr)
+		}
+		if _, err := os.Stat(result.FilePath); err != nil {
+			t.Errorf("Trash %d data missing: %v", i, err)
+		}
+	}
+}